@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"multifinance-core/internal/config"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/usecase"
+)
+
+// bootstrap-admin creates the first back-office admin account. The password is
+// read from BOOTSTRAP_ADMIN_PASSWORD or, if unset, from stdin.
+func main() {
+	email := flag.String("email", "", "admin email address")
+	name := flag.String("name", "", "admin full name")
+	flag.Parse()
+
+	if *email == "" || *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("warning: .env not found, falling back to environment")
+	}
+
+	password := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Print("password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			log.Fatalf("failed to read password: %v", err)
+		}
+		password = strings.TrimSpace(line)
+	}
+
+	passwordCfg, err := config.LoadPassword()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	db, err := config.NewMySQL(os.Getenv("DSN"))
+	if err != nil {
+		log.Fatalf("database: %v", err)
+	}
+	defer db.Close()
+
+	staffUC := usecase.NewStaffUsecase(db, repository.NewStaffRepo(db), nil, nil, 0, nil, passwordCfg.Policy, nil)
+	id, err := staffUC.BootstrapAdmin(context.Background(), usecase.CreateStaffRequest{
+		Email:    *email,
+		FullName: *name,
+		Password: password,
+	})
+	if err != nil {
+		log.Fatalf("bootstrap failed: %v", err)
+	}
+
+	log.Printf("created admin %s with id %d", *email, id)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"multifinance-core/internal/config"
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/usecase"
+)
+
+// recalculate-limits recomputes every consumer's max limits with the active
+// limit policy, one consumer per transaction and -batch consumers at a time.
+// The server already does this after every publish; the command runs a pass
+// by hand, and with -dry-run only logs the changes it would make.
+func main() {
+	batch := flag.Int("batch", 200, "consumers per chunk")
+	dryRun := flag.Bool("dry-run", false, "report changes without writing them")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("warning: .env not found, falling back to environment")
+	}
+
+	keys, err := config.LoadPII()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	db, err := config.NewMySQL(os.Getenv("DSN"))
+	if err != nil {
+		log.Fatalf("database: %v", err)
+	}
+	defer db.Close()
+
+	recalcUC := usecase.NewLimitRecalcUsecase(db, repository.NewConsumerRepo(db, keys), repository.NewConsumerLimitRepo(db),
+		repository.NewLimitPolicyRepo(db), repository.NewLimitLedgerRepo(db))
+	trigger := usecase.RecalcTrigger{
+		Source:   entity.LimitSourceJob,
+		SourceID: "recalculate-limits",
+		Reason:   "limit policy recalculation",
+	}
+
+	var afterID uint64
+	consumers, changed := 0, 0
+	for {
+		results, lastID, err := recalcUC.RecalculateBatch(context.Background(), afterID, *batch, *dryRun, trigger)
+		for _, r := range results {
+			for _, ch := range r.Changes {
+				log.Printf("consumer %d tenor %d: max %.2f -> %.2f (computed %.2f, used %.2f, policy v%d)",
+					r.ConsumerID, ch.Tenor, ch.OldMax, ch.NewMax, ch.Computed, ch.UsedLimit, r.PolicyVersion)
+				changed++
+			}
+		}
+		consumers += len(results)
+		if err != nil {
+			log.Fatalf("recalculation failed after %d consumers: %v", consumers, err)
+		}
+		if len(results) == 0 {
+			break
+		}
+		afterID = lastID
+		log.Printf("processed %d consumers", consumers)
+	}
+
+	if *dryRun {
+		log.Printf("dry run: %d limits of %d consumers would change", changed, consumers)
+		return
+	}
+	log.Printf("done: %d limits of %d consumers changed", changed, consumers)
+}
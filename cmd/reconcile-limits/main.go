@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"multifinance-core/internal/config"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/usecase"
+)
+
+// reconcile-limits checks that every consumer limit's used_limit equals the
+// sum of its ledger entries. It lists each mismatch and exits with status 1
+// when there is any, so it can run from cron or CI.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("warning: .env not found, falling back to environment")
+	}
+
+	db, err := config.NewMySQL(os.Getenv("DSN"))
+	if err != nil {
+		log.Fatalf("database: %v", err)
+	}
+	defer db.Close()
+
+	limitUC := usecase.NewConsumerLimitUsecase(db, repository.NewConsumerLimitRepo(db), repository.NewLimitLedgerRepo(db))
+	mismatches, err := limitUC.Reconcile(context.Background())
+	if err != nil {
+		log.Fatalf("reconciliation failed: %v", err)
+	}
+
+	for _, m := range mismatches {
+		log.Printf("limit %d (consumer %d, tenor %d): used_limit %.2f, ledger %.2f",
+			m.ConsumerLimitID, m.ConsumerID, m.TenorMonth, m.UsedLimit, m.LedgerSum)
+	}
+	if len(mismatches) > 0 {
+		log.Printf("%d limits do not match their ledger", len(mismatches))
+		os.Exit(1)
+	}
+	log.Println("all limits match their ledger")
+}
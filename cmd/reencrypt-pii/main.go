@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"multifinance-core/internal/config"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/usecase"
+)
+
+// reencrypt-pii moves every consumer and change request row to the current PII
+// key version, encrypting rows written before encryption existed. Run it after
+// changing PII_KEY_ID, with the old key still set as PII_PREVIOUS_KEY; once it
+// reports zero remaining rows the previous key can be removed.
+func main() {
+	batch := flag.Int("batch", 200, "rows per transaction")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("warning: .env not found, falling back to environment")
+	}
+
+	keys, err := config.LoadPII()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	db, err := config.NewMySQL(os.Getenv("DSN"))
+	if err != nil {
+		log.Fatalf("database: %v", err)
+	}
+	defer db.Close()
+
+	piiUC := usecase.NewPIIUsecase(db, repository.NewConsumerRepo(db, keys), repository.NewConsumerChangeRequestRepo(db, keys))
+	total := 0
+	for {
+		n, err := piiUC.ReencryptBatch(context.Background(), *batch)
+		if err != nil {
+			log.Fatalf("re-encryption failed after %d rows: %v", total, err)
+		}
+		if n == 0 {
+			break
+		}
+		total += n
+		log.Printf("re-encrypted %d rows", total)
+	}
+
+	log.Printf("done: %d rows now on key %s", total, keys.Version())
+}
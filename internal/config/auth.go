@@ -0,0 +1,355 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/utils"
+)
+
+type AuthConfig struct {
+	TokenIssuer     string
+	TokenKeyID      string
+	TokenKeys       map[string][]byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	StaffSessionTTL time.Duration
+
+	LoginMaxAttempts int
+	LoginLockoutBase time.Duration
+	LoginLockoutMax  time.Duration
+
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+}
+
+type PasswordConfig struct {
+	Policy   utils.PasswordPolicy
+	ResetTTL time.Duration
+	// ResetURL is the page that receives the reset token as ?token=.
+	ResetURL string
+}
+
+type EmailVerificationConfig struct {
+	TTL time.Duration
+	// URL is the page that receives the verification token as ?token=.
+	URL string
+}
+
+type NotifierConfig struct {
+	Driver   string
+	FilePath string
+}
+
+// MerchantConfig holds the master key merchant API secrets are derived from.
+// Changing MasterKey invalidates every issued merchant key. PurchaseAuthTTL
+// is how long a consumer's purchase authorization stays redeemable.
+type MerchantConfig struct {
+	MasterKey       []byte
+	SignatureWindow time.Duration
+	PurchaseAuthTTL time.Duration
+}
+
+// DocumentConfig controls where identity photos are stored, how long signed
+// download links stay valid, how often anonymous clients may upload and how
+// long an upload may wait for a registration to claim it.
+type DocumentConfig struct {
+	Dir              string
+	URLKey           []byte
+	URLTTL           time.Duration
+	MaxSize          int64
+	AnonUploadLimit  int
+	AnonUploadWindow time.Duration
+	UnclaimedTTL     time.Duration
+	SweepInterval    time.Duration
+}
+
+// LimitConfig controls limit holds: how long a hold reserves credit and how
+// often expired holds are released.
+type LimitConfig struct {
+	HoldTTL           time.Duration
+	HoldSweepInterval time.Duration
+}
+
+type Config struct {
+	Auth     AuthConfig
+	Password PasswordConfig
+	Email    EmailVerificationConfig
+	Notifier NotifierConfig
+	Merchant MerchantConfig
+	Document DocumentConfig
+	Limit    LimitConfig
+	PII      *utils.Keyring
+}
+
+// Load reads application settings from the environment.
+//
+// TOKEN_SECRET is required. TOKEN_PREVIOUS_KEY_ID / TOKEN_PREVIOUS_SECRET may
+// hold the key being rotated out so tokens it signed stay valid until expiry.
+// MERCHANT_KEY_SECRET, DOCUMENT_URL_SECRET and the PII keys (see LoadPII) are
+// required as well.
+func Load() (*Config, error) {
+	secret := os.Getenv("TOKEN_SECRET")
+	if secret == "" {
+		return nil, errors.New("TOKEN_SECRET environment variable is required")
+	}
+	if len(secret) < 32 {
+		return nil, errors.New("TOKEN_SECRET must be at least 32 characters")
+	}
+
+	kid := getenv("TOKEN_KEY_ID", "k1")
+	keys := map[string][]byte{kid: []byte(secret)}
+	if prevKID, prev := os.Getenv("TOKEN_PREVIOUS_KEY_ID"), os.Getenv("TOKEN_PREVIOUS_SECRET"); prevKID != "" && prev != "" {
+		keys[prevKID] = []byte(prev)
+	}
+
+	accessTTL, err := getDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL, err := getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	staffTTL, err := getDuration("STAFF_SESSION_TTL", 8*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts, err := getInt("LOGIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	lockoutBase, err := getDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	lockoutMax, err := getDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaTTL, err := getDuration("MFA_CHALLENGE_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := LoadPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	verifyTTL, err := getDuration("EMAIL_VERIFY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	driver := getenv("NOTIFIER_DRIVER", "log")
+	if driver != "log" && driver != "file" {
+		return nil, errors.New("NOTIFIER_DRIVER must be log or file")
+	}
+
+	merchantKey := os.Getenv("MERCHANT_KEY_SECRET")
+	if len(merchantKey) < 32 {
+		return nil, errors.New("MERCHANT_KEY_SECRET must be at least 32 characters")
+	}
+	signatureWindow, err := getDuration("MERCHANT_SIGNATURE_WINDOW", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	purchaseAuthTTL, err := getDuration("MERCHANT_PURCHASE_AUTH_TTL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	documentKey := os.Getenv("DOCUMENT_URL_SECRET")
+	if len(documentKey) < 32 {
+		return nil, errors.New("DOCUMENT_URL_SECRET must be at least 32 characters")
+	}
+	documentTTL, err := getDuration("DOCUMENT_URL_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	documentMaxSize, err := getInt("DOCUMENT_MAX_BYTES", 5<<20)
+	if err != nil {
+		return nil, err
+	}
+	anonUploadLimit, err := getInt("DOCUMENT_ANON_UPLOAD_LIMIT", 10)
+	if err != nil {
+		return nil, err
+	}
+	anonUploadWindow, err := getDuration("DOCUMENT_ANON_UPLOAD_WINDOW", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	unclaimedTTL, err := getDuration("DOCUMENT_UNCLAIMED_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	documentSweep, err := getDuration("DOCUMENT_SWEEP_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	holdTTL, err := getDuration("LIMIT_HOLD_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	holdSweep, err := getDuration("LIMIT_HOLD_SWEEP_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	pii, err := LoadPII()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		Auth: AuthConfig{
+			TokenIssuer:     getenv("TOKEN_ISSUER", "multifinance-core"),
+			TokenKeyID:      kid,
+			TokenKeys:       keys,
+			AccessTokenTTL:  accessTTL,
+			RefreshTokenTTL: refreshTTL,
+			StaffSessionTTL: staffTTL,
+
+			LoginMaxAttempts: maxAttempts,
+			LoginLockoutBase: lockoutBase,
+			LoginLockoutMax:  lockoutMax,
+
+			MFAIssuer:       getenv("MFA_ISSUER", "Multifinance"),
+			MFAChallengeTTL: mfaTTL,
+		},
+		Password: password,
+		Email: EmailVerificationConfig{
+			TTL: verifyTTL,
+			URL: getenv("EMAIL_VERIFY_URL", "http://localhost:3000/verify-email"),
+		},
+		Notifier: NotifierConfig{
+			Driver:   driver,
+			FilePath: getenv("NOTIFIER_FILE", "notifications.log"),
+		},
+		Merchant: MerchantConfig{
+			MasterKey:       []byte(merchantKey),
+			SignatureWindow: signatureWindow,
+			PurchaseAuthTTL: purchaseAuthTTL,
+		},
+		Document: DocumentConfig{
+			Dir:              getenv("DOCUMENT_DIR", "uploads"),
+			URLKey:           []byte(documentKey),
+			URLTTL:           documentTTL,
+			MaxSize:          int64(documentMaxSize),
+			AnonUploadLimit:  anonUploadLimit,
+			AnonUploadWindow: anonUploadWindow,
+			UnclaimedTTL:     unclaimedTTL,
+			SweepInterval:    documentSweep,
+		},
+		Limit: LimitConfig{
+			HoldTTL:           holdTTL,
+			HoldSweepInterval: holdSweep,
+		},
+		PII: pii,
+	}, nil
+}
+
+// LoadPassword reads the password policy and reset settings on their own, for
+// tools that do not need the rest of the configuration.
+func LoadPassword() (PasswordConfig, error) {
+	policy := utils.DefaultPasswordPolicy
+
+	minLength, err := getInt("PASSWORD_MIN_LENGTH", policy.MinLength)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+	policy.MinLength = minLength
+
+	for key, rule := range map[string]*bool{
+		"PASSWORD_REQUIRE_UPPER":  &policy.RequireUpper,
+		"PASSWORD_REQUIRE_LOWER":  &policy.RequireLower,
+		"PASSWORD_REQUIRE_DIGIT":  &policy.RequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL": &policy.RequireSymbol,
+	} {
+		if *rule, err = getBool(key, *rule); err != nil {
+			return PasswordConfig{}, err
+		}
+	}
+
+	resetTTL, err := getDuration("PASSWORD_RESET_TTL", 30*time.Minute)
+	if err != nil {
+		return PasswordConfig{}, err
+	}
+
+	return PasswordConfig{
+		Policy:   policy,
+		ResetTTL: resetTTL,
+		ResetURL: getenv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+	}, nil
+}
+
+// LoadPII builds the keyring that encrypts consumer personal data from PII_KEY
+// (version PII_KEY_ID) and, during a rotation, PII_PREVIOUS_KEY_ID /
+// PII_PREVIOUS_KEY. PII_INDEX_KEY must never change: NIK lookups go through a
+// blind index derived from it.
+func LoadPII() (*utils.Keyring, error) {
+	secret := os.Getenv("PII_KEY")
+	if len(secret) < 32 {
+		return nil, errors.New("PII_KEY must be at least 32 characters")
+	}
+	indexKey := os.Getenv("PII_INDEX_KEY")
+	if len(indexKey) < 32 {
+		return nil, errors.New("PII_INDEX_KEY must be at least 32 characters")
+	}
+
+	kid := getenv("PII_KEY_ID", "k1")
+	keys := map[string][]byte{kid: []byte(secret)}
+	if prevKID, prev := os.Getenv("PII_PREVIOUS_KEY_ID"), os.Getenv("PII_PREVIOUS_KEY"); prevKID != "" && prev != "" {
+		keys[prevKID] = []byte(prev)
+	}
+	return utils.NewKeyring(kid, keys, []byte(indexKey))
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, errors.New(key + " must be a positive duration such as 15m or 24h")
+	}
+	return d, nil
+}
+
+func getInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, errors.New(key + " must be a positive integer")
+	}
+	return n, nil
+}
+
+func getBool(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New(key + " must be true or false")
+	}
+	return b, nil
+}
@@ -2,25 +2,31 @@ package config
 
 import (
 	"database/sql"
-	"log"
+	"errors"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
-func NewMySQL() *sql.DB {
-	dsn := "user:password@tcp(localhost:3333)/multifinance-db?parseTime=true"
+// NewMySQL opens and pings the database at dsn, normally the DSN environment
+// variable. There is no default: a missing DSN is an error rather than a
+// silent connection to a local development database.
+func NewMySQL(dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, errors.New("DSN environment variable is required")
+	}
 
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(25)
 
 	if err := db.Ping(); err != nil {
-		log.Fatal(err)
+		db.Close()
+		return nil, err
 	}
 
-	return db
+	return db, nil
 }
@@ -7,6 +7,7 @@ type Asset struct {
 	ProductName  string
 	PriceProduct float64
 	Seller       string
+	MerchantID   *uint64
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -1,8 +1,16 @@
 package entity
 
+import "time"
+
 type AuthUser struct {
 	ID         uint64
 	ConsumerID uint64
 	Email      string
 	Password   string
+	VerifiedAt *time.Time
+}
+
+// Verified reports whether the user has confirmed their email address.
+func (u *AuthUser) Verified() bool {
+	return u.VerifiedAt != nil
 }
@@ -1,13 +1,22 @@
 package entity
 
+import "time"
+
 type Consumer struct {
-	ID          uint64
-	NIK         string
-	FullName    string
-	LegalName   string
-	BirthPlace  string
-	BirthDate   string
-	Salary      float64
-	KTPPhoto    string
-	SelfiePhoto string
+	ID               uint64
+	NIK              string
+	FullName         string
+	LegalName        string
+	BirthPlace       string
+	BirthDate        string
+	Salary           float64
+	KTPDocumentID    string
+	SelfieDocumentID string
+	KYCStatus        KYCStatus
+	RiskGrade        RiskGrade
+	Status           ConsumerStatus
+	StatusReason     string
+	StatusChangedAt  *time.Time
+	// ErasedAt is set once the consumer's personal data has been anonymised.
+	ErasedAt *time.Time
 }
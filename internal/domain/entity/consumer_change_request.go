@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+type ChangeRequestStatus string
+
+const (
+	ChangeRequestPending  ChangeRequestStatus = "PENDING"
+	ChangeRequestApproved ChangeRequestStatus = "APPROVED"
+	ChangeRequestRejected ChangeRequestStatus = "REJECTED"
+)
+
+// ConsumerChangeRequest holds salary or identity changes a consumer asked for
+// until staff review them. Nil fields are left unchanged.
+type ConsumerChangeRequest struct {
+	ID               uint64
+	ConsumerID       uint64
+	NIK              *string
+	LegalName        *string
+	BirthPlace       *string
+	BirthDate        *string
+	Salary           *float64
+	KTPDocumentID    *string
+	SelfieDocumentID *string
+	Status           ChangeRequestStatus
+	ReviewedBy       *uint64
+	ReviewNote       string
+	ReviewedAt       *time.Time
+	CreatedAt        time.Time
+}
+
+// Apply copies the requested values onto the consumer.
+func (r *ConsumerChangeRequest) Apply(c *Consumer) {
+	setString(&c.NIK, r.NIK)
+	setString(&c.LegalName, r.LegalName)
+	setString(&c.BirthPlace, r.BirthPlace)
+	setString(&c.BirthDate, r.BirthDate)
+	setString(&c.KTPDocumentID, r.KTPDocumentID)
+	setString(&c.SelfieDocumentID, r.SelfieDocumentID)
+	if r.Salary != nil {
+		c.Salary = *r.Salary
+	}
+}
+
+func setString(dst *string, v *string) {
+	if v != nil {
+		*dst = *v
+	}
+}
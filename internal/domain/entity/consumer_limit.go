@@ -8,6 +8,16 @@ type ConsumerLimit struct {
 	TenorMonth uint8
 	MaxLimit   float64
 	UsedLimit  float64
+	// HeldLimit is reserved by open limit holds; it is not yet used but is
+	// no longer available either.
+	HeldLimit float64
+	Active    bool
+	// PolicyVersion is the limit policy that produced MaxLimit; nil for
+	// limits set before the policy engine existed.
+	PolicyVersion *uint32
+	// OverrideID is the approved staff override that set MaxLimit.
+	// Recalculation leaves overridden limits alone.
+	OverrideID *uint64
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
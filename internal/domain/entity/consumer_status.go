@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+type ConsumerStatus string
+
+const (
+	ConsumerActive      ConsumerStatus = "ACTIVE"
+	ConsumerSuspended   ConsumerStatus = "SUSPENDED"
+	ConsumerBlacklisted ConsumerStatus = "BLACKLISTED"
+	ConsumerClosed      ConsumerStatus = "CLOSED"
+)
+
+// consumerStatusTransitions lists the states each status may move to. A
+// closed account stays closed; a blacklisting can be lifted by staff.
+var consumerStatusTransitions = map[ConsumerStatus][]ConsumerStatus{
+	ConsumerActive:      {ConsumerSuspended, ConsumerBlacklisted, ConsumerClosed},
+	ConsumerSuspended:   {ConsumerActive, ConsumerBlacklisted, ConsumerClosed},
+	ConsumerBlacklisted: {ConsumerActive, ConsumerClosed},
+}
+
+func (s ConsumerStatus) Valid() bool {
+	switch s {
+	case ConsumerActive, ConsumerSuspended, ConsumerBlacklisted, ConsumerClosed:
+		return true
+	}
+	return false
+}
+
+func (s ConsumerStatus) CanMoveTo(next ConsumerStatus) bool {
+	for _, allowed := range consumerStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanLogin reports whether the consumer may sign in. Suspended consumers can
+// still see their account; blacklisted and closed ones cannot.
+func (s ConsumerStatus) CanLogin() bool {
+	return s == ConsumerActive || s == ConsumerSuspended
+}
+
+func (s ConsumerStatus) CanPurchase() bool {
+	return s == ConsumerActive
+}
+
+// ConsumerStatusChange is one entry in a consumer's status history.
+type ConsumerStatusChange struct {
+	ID         uint64
+	ConsumerID uint64
+	FromStatus ConsumerStatus
+	ToStatus   ConsumerStatus
+	Reason     string
+	ChangedBy  uint64
+	CreatedAt  time.Time
+}
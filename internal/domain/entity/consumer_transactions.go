@@ -14,5 +14,34 @@ type Transaction struct {
 	JumlahBunga     int64
 	JumlahCicilan   int64
 	Status          string
-	CreatedAt       time.Time
+	// AmountPaid and PrincipalPaid add up the repayments so far;
+	// PrincipalPaid is the part of them released back to the limit.
+	AmountPaid    int64
+	PrincipalPaid int64
+	ClosedAt      *time.Time
+	CreatedAt     time.Time
+}
+
+// Contract statuses. FAILED purchases never became a contract.
+const (
+	TransactionActive = "SUCCESS"
+	TransactionFailed = "FAILED"
+	TransactionClosed = "CLOSED"
+)
+
+// Total is what the consumer owes over the whole contract.
+func (t *Transaction) Total() int64 {
+	return t.OTR + t.AdminFee + t.JumlahBunga
+}
+
+// Outstanding is what is left to repay.
+func (t *Transaction) Outstanding() int64 {
+	return t.Total() - t.AmountPaid
+}
+
+type TransactionSummary struct {
+	Status     string
+	TenorMonth uint8
+	Count      int64
+	TotalOTR   int64
 }
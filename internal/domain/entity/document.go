@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+type DocumentKind string
+
+const (
+	DocumentKTP    DocumentKind = "KTP"
+	DocumentSelfie DocumentKind = "SELFIE"
+)
+
+func (k DocumentKind) Valid() bool {
+	return k == DocumentKTP || k == DocumentSelfie
+}
+
+// Document is an uploaded identity photo. The content lives in the blob store
+// under its SHA-256, so identical uploads share one blob. ConsumerID is nil
+// until the document is attached to a consumer, e.g. at registration.
+type Document struct {
+	ID          string
+	ConsumerID  *uint64
+	Kind        DocumentKind
+	SHA256      string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
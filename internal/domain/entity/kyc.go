@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+type KYCStatus string
+
+const (
+	KYCPendingReview     KYCStatus = "PENDING_REVIEW"
+	KYCApproved          KYCStatus = "APPROVED"
+	KYCRejected          KYCStatus = "REJECTED"
+	KYCNeedsResubmission KYCStatus = "NEEDS_RESUBMISSION"
+)
+
+// kycTransitions lists the states each KYC state may move to. Approval and
+// rejection are final; a case needing resubmission goes back to review once
+// the consumer sends new documents.
+var kycTransitions = map[KYCStatus][]KYCStatus{
+	KYCPendingReview:     {KYCApproved, KYCRejected, KYCNeedsResubmission},
+	KYCNeedsResubmission: {KYCPendingReview},
+}
+
+func (s KYCStatus) Valid() bool {
+	switch s {
+	case KYCPendingReview, KYCApproved, KYCRejected, KYCNeedsResubmission:
+		return true
+	}
+	return false
+}
+
+func (s KYCStatus) CanMoveTo(next KYCStatus) bool {
+	for _, allowed := range kycTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// KYCReview is one entry in a consumer's KYC history. ReviewedBy is nil when
+// the consumer moved the case, e.g. by resubmitting documents.
+type KYCReview struct {
+	ID         uint64
+	ConsumerID uint64
+	FromStatus KYCStatus
+	ToStatus   KYCStatus
+	Note       string
+	ReviewedBy *uint64
+	CreatedAt  time.Time
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+type LimitHoldStatus string
+
+const (
+	LimitHoldActive    LimitHoldStatus = "HELD"
+	LimitHoldConfirmed LimitHoldStatus = "CONFIRMED"
+	LimitHoldCancelled LimitHoldStatus = "CANCELLED"
+	LimitHoldExpired   LimitHoldStatus = "EXPIRED"
+)
+
+// LimitHold reserves part of a limit during checkout without creating a
+// contract. While HELD its Amount counts against the available limit; it is
+// then confirmed into a transaction, cancelled, or expires at ExpiresAt.
+type LimitHold struct {
+	ID              uint64
+	ConsumerID      uint64
+	ConsumerLimitID uint64
+	TenorMonth      uint8
+	AssetID         uint64
+	// MerchantID is set for holds placed by a merchant; only that merchant
+	// may confirm or cancel them.
+	MerchantID    *uint64
+	Amount        float64
+	Status        LimitHoldStatus
+	TransactionID *uint64
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Expired reports whether the hold has run past its TTL at now.
+func (h *LimitHold) Expired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
@@ -0,0 +1,59 @@
+package entity
+
+import "time"
+
+// LimitMovement is the kind of a limit ledger entry.
+type LimitMovement string
+
+const (
+	// LimitDebit takes credit, e.g. a purchase.
+	LimitDebit LimitMovement = "DEBIT"
+	// LimitRelease gives credit back, e.g. a repayment.
+	LimitRelease LimitMovement = "RELEASE"
+	// LimitAdjustment corrects the used limit or recomputes the max limit
+	// outside the normal flow, such as the opening balance of limits that
+	// predate the ledger.
+	LimitAdjustment LimitMovement = "ADJUSTMENT"
+	// LimitOverride is a max limit set by hand by staff.
+	LimitOverride LimitMovement = "OVERRIDE"
+)
+
+// LimitSource says who or what moved a limit; SourceID identifies it within
+// the source, e.g. the transaction or staff user ID.
+type LimitSource string
+
+const (
+	LimitSourceTransaction LimitSource = "TRANSACTION"
+	LimitSourceConsumer    LimitSource = "CONSUMER"
+	LimitSourceStaff       LimitSource = "STAFF"
+	LimitSourceJob         LimitSource = "JOB"
+)
+
+// LimitLedgerEntry is one append-only record of a limit movement. Amount is
+// the signed change of the used limit, so the amounts of a limit add up to its
+// used_limit. Entries that only move the max limit have a zero Amount.
+type LimitLedgerEntry struct {
+	ID              uint64
+	ConsumerLimitID uint64
+	ConsumerID      uint64
+	TenorMonth      uint8
+	Kind            LimitMovement
+	Amount          float64
+	UsedBefore      float64
+	UsedAfter       float64
+	MaxBefore       float64
+	MaxAfter        float64
+	SourceType      LimitSource
+	SourceID        string
+	Reason          string
+	CreatedAt       time.Time
+}
+
+// LimitMismatch is a limit whose used_limit differs from its ledger.
+type LimitMismatch struct {
+	ConsumerLimitID uint64
+	ConsumerID      uint64
+	TenorMonth      uint8
+	UsedLimit       float64
+	LedgerSum       float64
+}
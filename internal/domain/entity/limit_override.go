@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type LimitOverrideStatus string
+
+const (
+	LimitOverridePending  LimitOverrideStatus = "PENDING"
+	LimitOverrideApproved LimitOverrideStatus = "APPROVED"
+	LimitOverrideRejected LimitOverrideStatus = "REJECTED"
+)
+
+// LimitOverrideRequest is a max limit proposed by hand for one consumer limit.
+// The staff user who requests it cannot review it; the limit only changes when
+// another staff user approves.
+type LimitOverrideRequest struct {
+	ID              uint64
+	ConsumerLimitID uint64
+	ConsumerID      uint64
+	TenorMonth      uint8
+	// CurrentMaxLimit is the max limit when the override was requested.
+	CurrentMaxLimit float64
+	NewMaxLimit     float64
+	Justification   string
+	Status          LimitOverrideStatus
+	RequestedBy     uint64
+	ReviewedBy      *uint64
+	ReviewNote      string
+	ReviewedAt      *time.Time
+	CreatedAt       time.Time
+}
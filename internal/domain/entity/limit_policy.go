@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"math"
+	"time"
+)
+
+// RiskGrade is the credit grade staff assign to a consumer. New consumers are
+// ungraded (the empty grade) until someone grades them.
+type RiskGrade string
+
+const (
+	RiskGradeA RiskGrade = "A"
+	RiskGradeB RiskGrade = "B"
+	RiskGradeC RiskGrade = "C"
+	RiskGradeD RiskGrade = "D"
+	RiskGradeE RiskGrade = "E"
+)
+
+func (g RiskGrade) Valid() bool {
+	switch g {
+	case RiskGradeA, RiskGradeB, RiskGradeC, RiskGradeD, RiskGradeE:
+		return true
+	}
+	return false
+}
+
+// SalaryBand applies Ratio to salaries from MinSalary up to the next band.
+type SalaryBand struct {
+	MinSalary float64 `json:"min_salary"`
+	Ratio     float64 `json:"ratio"`
+}
+
+// TenorRule turns the salary-based amount into the limit for one tenor. Cap
+// is ignored when zero.
+type TenorRule struct {
+	Tenor      uint8   `json:"tenor"`
+	Multiplier float64 `json:"multiplier"`
+	Floor      float64 `json:"floor"`
+	Cap        float64 `json:"cap"`
+}
+
+// LimitRules is one rule set of the limit policy. Rule sets are stored as
+// JSON, hence the tags.
+type LimitRules struct {
+	SalaryBands     []SalaryBand          `json:"salary_bands"`
+	Tenors          []TenorRule           `json:"tenors"`
+	RiskAdjustments map[RiskGrade]float64 `json:"risk_adjustments,omitempty"`
+}
+
+// LimitPolicy is a numbered, immutable version of the rules. A policy takes
+// effect once published; the most recently published one is active.
+// CreatedBy is nil for the policy seeded by the migration.
+type LimitPolicy struct {
+	ID          uint64
+	Version     uint32
+	Rules       LimitRules
+	Note        string
+	CreatedBy   *uint64
+	CreatedAt   time.Time
+	PublishedBy *uint64
+	PublishedAt *time.Time
+}
+
+func (p *LimitPolicy) Published() bool {
+	return p.PublishedAt != nil
+}
+
+type TenorLimit struct {
+	Tenor    uint8
+	MaxLimit float64
+}
+
+// Compute returns the limit for every tenor of the rules. Salaries below the
+// lowest band get no credit at all, floors included. Otherwise the amount is
+// salary × band ratio × tenor multiplier × risk adjustment, kept between the
+// tenor's floor and cap and rounded to whole rupiah.
+func (r *LimitRules) Compute(salary float64, grade RiskGrade) []TenorLimit {
+	ratio, eligible := r.ratio(salary)
+	adjustment := 1.0
+	if a, ok := r.RiskAdjustments[grade]; ok {
+		adjustment = a
+	}
+
+	limits := make([]TenorLimit, 0, len(r.Tenors))
+	for _, t := range r.Tenors {
+		limit := 0.0
+		if eligible {
+			limit = salary * ratio * t.Multiplier * adjustment
+			if limit < t.Floor {
+				limit = t.Floor
+			}
+			if t.Cap > 0 && limit > t.Cap {
+				limit = t.Cap
+			}
+			limit = math.Round(limit)
+		}
+		limits = append(limits, TenorLimit{Tenor: t.Tenor, MaxLimit: limit})
+	}
+	return limits
+}
+
+func (r *LimitRules) ratio(salary float64) (float64, bool) {
+	ratio, found, min := 0.0, false, 0.0
+	for _, b := range r.SalaryBands {
+		if salary >= b.MinSalary && (!found || b.MinSalary >= min) {
+			ratio, found, min = b.Ratio, true, b.MinSalary
+		}
+	}
+	return ratio, found
+}
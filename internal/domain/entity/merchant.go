@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// Merchant is a partner seller that calls the API server-to-server.
+type Merchant struct {
+	ID        uint64
+	Code      string
+	Name      string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// MerchantAPIKey identifies a merchant on signed requests. Only a hash of the
+// signing secret is stored; the secret itself is shown once when issued.
+type MerchantAPIKey struct {
+	ID         uint64
+	MerchantID uint64
+	KeyID      string
+	SecretHash string
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (k *MerchantAPIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// MFACredential is a TOTP secret enrolled by a consumer or staff user. It only
+// protects logins once ConfirmedAt is set.
+type MFACredential struct {
+	ID           uint64
+	SubjectType  string
+	SubjectID    uint64
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (c *MFACredential) Confirmed() bool {
+	return c.ConfirmedAt != nil
+}
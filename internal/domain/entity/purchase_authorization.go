@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// PurchaseAuthorization is a consumer's consent to one merchant purchase:
+// a single-use token, stored hashed, bound to the merchant, asset and tenor
+// the consumer agreed to.
+type PurchaseAuthorization struct {
+	ID         uint64
+	ConsumerID uint64
+	MerchantID uint64
+	AssetID    uint64
+	TenorMonth uint8
+	TokenHash  string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+	CreatedAt  time.Time
+}
+
+// Redeemable reports whether the token is unused and still valid at now.
+func (a *PurchaseAuthorization) Redeemable(now time.Time) bool {
+	return a.UsedAt == nil && now.Before(a.ExpiresAt)
+}
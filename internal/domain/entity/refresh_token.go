@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type RefreshToken struct {
+	ID         uint64
+	AuthUserID uint64
+	FamilyID   string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RotatedAt  *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+type RepaymentKind string
+
+const (
+	// RepaymentInstallment is a regular payment towards a contract.
+	RepaymentInstallment RepaymentKind = "INSTALLMENT"
+	// RepaymentSettlement pays off everything left when a contract is closed
+	// early.
+	RepaymentSettlement RepaymentKind = "SETTLEMENT"
+)
+
+// Repayment is one payment recorded against a contract. Principal is the
+// share of Amount that repaid the OTR and was released back to the limit;
+// the rest covered fees and interest.
+type Repayment struct {
+	ID            uint64
+	TransactionID uint64
+	ConsumerID    uint64
+	Kind          RepaymentKind
+	Amount        int64
+	Principal     int64
+	Reference     string
+	RecordedBy    *uint64
+	CreatedAt     time.Time
+}
@@ -0,0 +1,50 @@
+package entity
+
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleOperator Role = "operator"
+	RoleConsumer Role = "consumer"
+)
+
+type Permission string
+
+const (
+	PermAssetWrite     Permission = "asset:write"
+	PermLimitOverride  Permission = "limit:override"
+	PermReportRead     Permission = "report:read"
+	PermStaffManage    Permission = "staff:manage"
+	PermAccountUnlock  Permission = "account:unlock"
+	PermMerchantManage Permission = "merchant:manage"
+	PermConsumerRead   Permission = "consumer:read"
+	PermConsumerWrite  Permission = "consumer:write"
+	PermKYCReview      Permission = "kyc:review"
+	PermLimitPolicy    Permission = "limit:policy"
+	PermPaymentWrite   Permission = "payment:write"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:    {PermAssetWrite, PermLimitOverride, PermReportRead, PermStaffManage, PermAccountUnlock, PermMerchantManage, PermConsumerRead, PermConsumerWrite, PermKYCReview, PermLimitPolicy, PermPaymentWrite},
+	RoleOperator: {PermAssetWrite, PermReportRead, PermAccountUnlock, PermConsumerRead, PermConsumerWrite, PermKYCReview, PermPaymentWrite},
+	RoleConsumer: {},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// IsStaff reports whether the role belongs to back-office personnel.
+func (r Role) IsStaff() bool {
+	return r == RoleAdmin || r == RoleOperator
+}
+
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Session belongs to either a consumer (AuthUserID) or a back-office user
+// (StaffUserID); the other ID is zero.
+type Session struct {
+	ID          string
+	AuthUserID  uint64
+	StaffUserID uint64
+	UserAgent   string
+	IPAddress   string
+	CreatedAt   time.Time
+	LastSeenAt  time.Time
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+}
+
+// Active reports whether the session can still authenticate requests.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
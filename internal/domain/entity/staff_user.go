@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// StaffUser is a back-office account. Unlike AuthUser it is not tied to a
+// consumer record.
+type StaffUser struct {
+	ID        uint64
+	Email     string
+	Password  string
+	FullName  string
+	Role      Role
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// TokenPurpose separates the kinds of one-time tokens mailed to users.
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+)
+
+// UserToken is a single-use token sent to an auth user, stored hashed.
+type UserToken struct {
+	ID         uint64
+	AuthUserID uint64
+	Purpose    TokenPurpose
+	TokenHash  string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+	CreatedAt  time.Time
+}
@@ -1,19 +1,26 @@
 package handler
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 
+	"multifinance-core/internal/domain/entity"
 	"multifinance-core/internal/usecase"
+	"multifinance-core/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	authUC *usecase.AuthUsecase
+	authUC   *usecase.AuthUsecase
+	verifyUC *usecase.EmailVerificationUsecase
 }
 
-func NewAuthHandler(authUC *usecase.AuthUsecase) *AuthHandler {
-	return &AuthHandler{authUC}
+func NewAuthHandler(authUC *usecase.AuthUsecase, verifyUC *usecase.EmailVerificationUsecase) *AuthHandler {
+	return &AuthHandler{authUC, verifyUC}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -25,11 +32,67 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	if err := h.authUC.Register(c.Request.Context(), req); err != nil {
+		if errors.Is(err, utils.ErrWeakPassword) || errors.Is(err, utils.ErrInvalidNIK) ||
+			err == usecase.ErrInvalidBirthDate || err == usecase.ErrNIKBirthDateMismatch ||
+			err == usecase.ErrDocumentNotFound || err == usecase.ErrDocumentKindMismatch {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == usecase.ErrNIKAlreadyRegistered || err == usecase.ErrDocumentInUse {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err == usecase.ErrNoActiveLimitPolicy {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "register success"})
+	// The account exists either way; a lost email can be re-sent once logged in.
+	if err := h.verifyUC.SendByEmail(c.Request.Context(), req.Email); err != nil {
+		log.Printf("sending verification email to %s: %v", req.Email, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "register success, check your email to verify your account"})
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req usecase.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.verifyUC.Verify(c.Request.Context(), req); err != nil {
+		if err == usecase.ErrInvalidVerificationToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	authI, ok := c.Get("auth_user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	authUser := authI.(*entity.AuthUser)
+
+	if err := h.verifyUC.Resend(c.Request.Context(), authUser.ID); err != nil {
+		if err == usecase.ErrEmailAlreadyVerified {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -40,11 +103,98 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authUC.Login(c.Request.Context(), req)
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	pair, err := h.authUC.Login(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credential"})
+		loginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req usecase.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.authUC.Refresh(c.Request.Context(), req)
+	if err != nil {
+		if err == usecase.ErrInvalidRefreshToken || err == usecase.ErrRefreshTokenReused {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err == usecase.ErrAccountDisabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+type UnlockAccountRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	// Subject is "consumer" (the default) or "staff".
+	Subject string `json:"subject" binding:"omitempty,oneof=consumer staff"`
+}
+
+// Unlock lets staff clear a lockout left by repeated failed logins.
+func (h *AuthHandler) Unlock(c *gin.Context) {
+	var req UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	subject := req.Subject
+	if subject == "" {
+		subject = utils.SubjectConsumer
+	}
+	h.authUC.Unlock(subject, req.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req usecase.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	res, err := h.authUC.LoginMFA(c.Request.Context(), req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// loginError answers a failed login, telling locked-out clients when to retry.
+func loginError(c *gin.Context, err error) {
+	var locked *usecase.LoginLockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": usecase.ErrLoginLocked.Error()})
+		return
+	}
+	switch err {
+	case usecase.ErrInvalidMFACode, usecase.ErrInvalidMFAToken:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case usecase.ErrMFANotEnrolled, usecase.ErrMFAAlreadyEnabled:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrAccountDisabled:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credential"})
+	}
 }
@@ -5,13 +5,18 @@ import (
 	"strings"
 	"time"
 
+	"multifinance-core/internal/domain/entity"
 	"multifinance-core/internal/repository"
 	"multifinance-core/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authRepo repository.AuthRepository) gin.HandlerFunc {
+// AuthMiddleware authenticates both consumer and staff access tokens. Consumer
+// requests get "auth_user" in the context, staff requests get "staff_user";
+// both get "role" and "token_claims". Blacklisted and closed consumers are
+// turned away even while their tokens are still valid.
+func AuthMiddleware(tokens *utils.TokenManager, authRepo repository.AuthRepository, staffRepo repository.StaffRepository, sessionRepo repository.SessionRepository, consumerRepo repository.ConsumerRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
 		if h == "" || !strings.HasPrefix(h, "Bearer ") {
@@ -19,17 +24,53 @@ func AuthMiddleware(authRepo repository.AuthRepository) gin.HandlerFunc {
 			return
 		}
 		token := strings.TrimPrefix(h, "Bearer ")
-		email, _, err := utils.ParseToken(token, 24*time.Hour)
+		claims, err := tokens.Parse(token)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-		user, err := authRepo.FindByEmail(c.Request.Context(), email)
+		subjectID, err := claims.SubjectID()
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
-		c.Set("auth_user", user)
+
+		session, err := sessionRepo.FindByID(c.Request.Context(), claims.SessionID)
+		if err != nil || !session.Active(time.Now().UTC()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			return
+		}
+
+		switch claims.SubjectType {
+		case utils.SubjectStaff:
+			staff, err := staffRepo.FindByID(c.Request.Context(), subjectID)
+			if err != nil || !staff.Active || session.StaffUserID != staff.ID {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
+			}
+			c.Set("staff_user", staff)
+			c.Set("role", staff.Role)
+		case utils.SubjectConsumer:
+			user, err := authRepo.FindByID(c.Request.Context(), subjectID)
+			if err != nil || user.ConsumerID != claims.ConsumerID || session.AuthUserID != user.ID {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				return
+			}
+			status, err := consumerRepo.FindStatus(c.Request.Context(), user.ConsumerID)
+			if err != nil || !status.CanLogin() {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account disabled"})
+				return
+			}
+			// Consumer tokens only ever carry the consumer role; back-office
+			// roles come from staff_users.
+			c.Set("auth_user", user)
+			c.Set("role", entity.RoleConsumer)
+		default:
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set("token_claims", claims)
 		c.Next()
 	}
 }
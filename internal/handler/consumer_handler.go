@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+	"multifinance-core/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConsumerHandler struct {
+	uc *usecase.ConsumerUsecase
+}
+
+func NewConsumerHandler(uc *usecase.ConsumerUsecase) *ConsumerHandler {
+	return &ConsumerHandler{uc: uc}
+}
+
+func (h *ConsumerHandler) Me(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	p, err := h.uc.Profile(c.Request.Context(), authUser.ConsumerID)
+	if err != nil {
+		consumerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// UpdateMe saves a new full name right away and returns 202 when salary or
+// identity changes were filed for staff review.
+func (h *ConsumerHandler) UpdateMe(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	var req usecase.UpdateConsumerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := h.uc.UpdateProfile(c.Request.Context(), authUser.ConsumerID, req)
+	if err != nil {
+		consumerError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if p.PendingChange != nil {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, p)
+}
+
+func (h *ConsumerHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	p, err := h.uc.Profile(c.Request.Context(), id)
+	if err != nil {
+		consumerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *ConsumerHandler) Update(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.UpdateConsumerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := h.uc.AdminUpdate(c.Request.Context(), id, staff.ID, req)
+	if err != nil {
+		consumerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *ConsumerHandler) ApproveChange(c *gin.Context) {
+	h.reviewChange(c, true)
+}
+
+func (h *ConsumerHandler) RejectChange(c *gin.Context) {
+	h.reviewChange(c, false)
+}
+
+func (h *ConsumerHandler) reviewChange(c *gin.Context, approve bool) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	requestID, err := strconv.ParseUint(c.Param("request_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request id"})
+		return
+	}
+
+	// The note is optional, so an empty body is fine.
+	var req usecase.ReviewChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.ReviewChange(c.Request.Context(), id, requestID, staff.ID, approve, req.Note); err != nil {
+		consumerError(c, err)
+		return
+	}
+	if approve {
+		c.JSON(http.StatusOK, gin.H{"message": "change request approved"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "change request rejected"})
+}
+
+func consumerError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrInvalidNIK) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case usecase.ErrConsumerNotFound, usecase.ErrChangeRequestNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrNothingToUpdate, usecase.ErrInvalidBirthDate, usecase.ErrNIKBirthDateMismatch,
+		usecase.ErrDocumentNotFound, usecase.ErrDocumentKindMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrChangeRequestPending, usecase.ErrChangeRequestReviewed, usecase.ErrNIKAlreadyRegistered,
+		usecase.ErrDocumentInUse:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case usecase.ErrNoActiveLimitPolicy:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
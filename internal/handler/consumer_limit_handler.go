@@ -18,45 +18,94 @@ func NewConsumerLimitHandler(uc *usecase.ConsumerLimitUsecase) *ConsumerLimitHan
 	return &ConsumerLimitHandler{uc: uc}
 }
 
-type useRequest struct {
-	Amount float64 `json:"amount" binding:"required"`
+// List returns every limit of the consumer with what is still available.
+func (h *ConsumerLimitHandler) List(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	limits, err := h.uc.List(c.Request.Context(), authUser.ConsumerID)
+	if err != nil {
+		consumerLimitError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": limits})
 }
 
-func (h *ConsumerLimitHandler) Use(c *gin.Context) {
-	authI, ok := c.Get("auth_user")
+func (h *ConsumerLimitHandler) Get(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	tenor, ok := limitTenor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	authUser := authI.(*entity.AuthUser)
-	consumerID := authUser.ConsumerID
 
-	tenorStr := c.Param("tenor")
-	t, err := strconv.ParseUint(tenorStr, 10, 8)
+	limit, err := h.uc.Get(c.Request.Context(), authUser.ConsumerID, tenor)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenor"})
+		consumerLimitError(c, err)
 		return
 	}
-	tenor := uint8(t)
-	if tenor != 1 && tenor != 2 && tenor != 3 && tenor != 6 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenor"})
+	c.JSON(http.StatusOK, limit)
+}
+
+// History lists every movement of one of the consumer's limits.
+func (h *ConsumerLimitHandler) History(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	tenor, ok := limitTenor(c)
+	if !ok {
 		return
 	}
 
-	var req useRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	entries, err := h.uc.History(c.Request.Context(), authUser.ConsumerID, tenor)
+	if err != nil {
+		consumerLimitError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
 
-	if err := h.uc.IncreaseUsedLimit(c.Request.Context(), consumerID, tenor, req.Amount); err != nil {
-		if err.Error() == "used limit exceeds max limit" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *ConsumerLimitHandler) AdminHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	tenor, ok := limitTenor(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "used limit updated"})
+	entries, err := h.uc.History(c.Request.Context(), id, tenor)
+	if err != nil {
+		consumerLimitError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// Reconcile reports limits whose used limit does not match their ledger.
+func (h *ConsumerLimitHandler) Reconcile(c *gin.Context) {
+	mismatches, err := h.uc.Reconcile(c.Request.Context())
+	if err != nil {
+		consumerLimitError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reconciled": len(mismatches) == 0, "mismatches": mismatches})
+}
+
+func limitTenor(c *gin.Context) (uint8, bool) {
+	t, err := strconv.ParseUint(c.Param("tenor"), 10, 8)
+	if err != nil || t == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenor"})
+		return 0, false
+	}
+	return uint8(t), true
+}
+
+func consumerLimitError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrLimitNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
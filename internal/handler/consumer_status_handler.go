@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConsumerStatusHandler struct {
+	uc *usecase.ConsumerStatusUsecase
+}
+
+func NewConsumerStatusHandler(uc *usecase.ConsumerStatusUsecase) *ConsumerStatusHandler {
+	return &ConsumerStatusHandler{uc: uc}
+}
+
+func (h *ConsumerStatusHandler) Change(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.ChangeConsumerStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.ChangeStatus(c.Request.Context(), id, staff.ID, req); err != nil {
+		consumerStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "consumer status changed", "status": req.Status})
+}
+
+func (h *ConsumerStatusHandler) History(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	changes, err := h.uc.History(c.Request.Context(), id)
+	if err != nil {
+		consumerStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": changes})
+}
+
+func consumerStatusError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrConsumerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidConsumerStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidStatusTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"multifinance-core/internal/domain/entity"
 	"multifinance-core/internal/usecase"
@@ -43,16 +44,50 @@ func (h *ConsumerTransactionHandler) Purchase(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err == usecase.ErrEmailNotVerified || err == usecase.ErrLimitInactive || err == usecase.ErrConsumerNotActive {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		if err == usecase.ErrInsufficientLimit {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient limit"})
 			return
 		}
+		if err == usecase.ErrLimitNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "purchase success", "transaction": tr})
 }
 
+// AuthorizePurchase issues a one-time token the consumer gives to a merchant
+// so it can complete one purchase of one of its assets on their limit.
+func (h *ConsumerTransactionHandler) AuthorizePurchase(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	var req usecase.AuthorizePurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tok, err := h.uc.AuthorizePurchase(c.Request.Context(), authUser.ConsumerID, req)
+	if err != nil {
+		switch err {
+		case usecase.ErrInvalidTenor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case usecase.ErrMerchantAssetNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, tok)
+}
+
 func (h *ConsumerTransactionHandler) List(c *gin.Context) {
 	authI, ok := c.Get("auth_user")
 	if !ok {
@@ -69,3 +104,35 @@ func (h *ConsumerTransactionHandler) List(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"transactions": list})
 }
+
+// Report summarises transactions per status and tenor for a date range given
+// as from/to (YYYY-MM-DD, to exclusive). It defaults to the current month.
+func (h *ConsumerTransactionHandler) Report(c *gin.Context) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date"})
+			return
+		}
+		to = t
+	}
+
+	summary, err := h.uc.Summary(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "summary": summary})
+}
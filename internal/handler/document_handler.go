@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead leaves room for the form boundaries and the kind field on
+// top of the file itself.
+const multipartOverhead = 64 << 10
+
+type DocumentHandler struct {
+	uc      *usecase.DocumentUsecase
+	maxSize int64
+}
+
+func NewDocumentHandler(uc *usecase.DocumentUsecase, maxSize int64) *DocumentHandler {
+	return &DocumentHandler{uc: uc, maxSize: maxSize}
+}
+
+// Upload takes a multipart form with "kind" (KTP or SELFIE) and "file".
+// Logged-in consumers own the document at once; anonymous uploads are claimed
+// by the registration that references them. Any other authenticated caller,
+// such as staff, is refused rather than being taken for a consumer.
+func (h *DocumentHandler) Upload(c *gin.Context) {
+	var consumerID *uint64
+	role, authed := c.Get("role")
+	if user, ok := c.Get("auth_user"); authed || ok {
+		if !ok || role != entity.RoleConsumer {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		id := user.(*entity.AuthUser).ConsumerID
+		consumerID = &id
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": usecase.ErrDocumentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	doc, err := h.uc.Upload(c.Request.Context(), consumerID, entity.DocumentKind(c.PostForm("kind")), f)
+	if err != nil {
+		documentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, doc)
+}
+
+// AnonymousUploadLimit caps uploads per client IP on the unauthenticated
+// upload route, answering 429 with Retry-After once the IP is over its limit.
+func AnonymousUploadLimit(l *usecase.UploadLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var limited *usecase.UploadLimitedError
+		if err := l.Allow(c.ClientIP()); errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": usecase.ErrUploadLimited.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// URL returns a signed download link for one of the consumer's documents.
+func (h *DocumentHandler) URL(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	link, err := h.uc.SignedURL(c.Request.Context(), c.Param("id"), &authUser.ConsumerID)
+	if err != nil {
+		documentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+func (h *DocumentHandler) AdminURL(c *gin.Context) {
+	link, err := h.uc.SignedURL(c.Request.Context(), c.Param("id"), nil)
+	if err != nil {
+		documentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// Content serves a document to whoever holds a valid signed link.
+func (h *DocumentHandler) Content(c *gin.Context) {
+	d, rc, err := h.uc.Open(c.Request.Context(), c.Param("id"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		documentError(c, err)
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, d.Size, d.ContentType, rc, map[string]string{
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func documentError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrDocumentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidDocumentKind, usecase.ErrDocumentEmpty:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrDocumentTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case usecase.ErrUnsupportedDocumentType:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidDocumentURL:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
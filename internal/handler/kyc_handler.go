@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KYCHandler struct {
+	uc *usecase.KYCUsecase
+}
+
+func NewKYCHandler(uc *usecase.KYCUsecase) *KYCHandler {
+	return &KYCHandler{uc: uc}
+}
+
+// List returns the cases in ?status=, the review queue by default.
+func (h *KYCHandler) List(c *gin.Context) {
+	status := entity.KYCStatus(c.DefaultQuery("status", string(entity.KYCPendingReview)))
+
+	cases, err := h.uc.List(c.Request.Context(), status)
+	if err != nil {
+		kycError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cases})
+}
+
+func (h *KYCHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	kc, err := h.uc.Get(c.Request.Context(), id)
+	if err != nil {
+		kycError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, kc)
+}
+
+func (h *KYCHandler) Approve(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// The note is optional, so an empty body is fine.
+	var req usecase.KYCApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Approve(c.Request.Context(), id, staff.ID, req.Note); err != nil {
+		kycError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "KYC approved"})
+}
+
+func (h *KYCHandler) Reject(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.KYCRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Reject(c.Request.Context(), id, staff.ID, req); err != nil {
+		kycError(c, err)
+		return
+	}
+	if req.Resubmit {
+		c.JSON(http.StatusOK, gin.H{"message": "KYC resubmission requested"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "KYC rejected"})
+}
+
+// Me shows the consumer their own case and the reviewers' notes.
+func (h *KYCHandler) Me(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	kc, err := h.uc.Get(c.Request.Context(), authUser.ConsumerID)
+	if err != nil {
+		kycError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, kc)
+}
+
+func (h *KYCHandler) Resubmit(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	var req usecase.KYCResubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Resubmit(c.Request.Context(), authUser.ConsumerID, req); err != nil {
+		kycError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "documents resubmitted for review"})
+}
+
+func kycError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrConsumerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidKYCStatus, usecase.ErrDocumentNotFound, usecase.ErrDocumentKindMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidKYCTransition, usecase.ErrDocumentInUse:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
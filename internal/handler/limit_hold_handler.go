@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LimitHoldHandler serves the two-phase checkout endpoints for consumers and
+// merchants.
+type LimitHoldHandler struct {
+	uc *usecase.LimitHoldUsecase
+}
+
+func NewLimitHoldHandler(uc *usecase.LimitHoldUsecase) *LimitHoldHandler {
+	return &LimitHoldHandler{uc: uc}
+}
+
+type createHoldRequest struct {
+	AssetID uint64 `json:"asset_id" binding:"required"`
+	Tenor   uint8  `json:"tenor" binding:"required"`
+}
+
+type useLimitRequest struct {
+	AssetID uint64 `json:"asset_id" binding:"required"`
+}
+
+type merchantHoldRequest struct {
+	ConsumerID    uint64 `json:"consumer_id" binding:"required"`
+	AssetID       uint64 `json:"asset_id" binding:"required"`
+	Tenor         uint8  `json:"tenor" binding:"required"`
+	PurchaseToken string `json:"purchase_token" binding:"required"`
+}
+
+// Create reserves the price of an asset on one of the consumer's limits.
+func (h *LimitHoldHandler) Create(c *gin.Context) {
+	var req createHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.create(c, req.AssetID, req.Tenor)
+}
+
+// Use is Create with the tenor taken from the path. Credit is only ever taken
+// through a hold, which is confirmed into a repayable contract or released
+// when cancelled or expired.
+func (h *LimitHoldHandler) Use(c *gin.Context) {
+	tenor, ok := limitTenor(c)
+	if !ok {
+		return
+	}
+
+	var req useLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.create(c, req.AssetID, tenor)
+}
+
+func (h *LimitHoldHandler) create(c *gin.Context, assetID uint64, tenor uint8) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	hold, err := h.uc.Create(c.Request.Context(), authUser.ConsumerID, assetID, tenor)
+	if err != nil {
+		limitHoldError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, hold)
+}
+
+// Confirm turns a hold on the consumer's limit into a transaction, including
+// holds a merchant placed for them.
+func (h *LimitHoldHandler) Confirm(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	tr, err := h.uc.Confirm(c.Request.Context(), authUser.ConsumerID, id)
+	if err != nil {
+		limitHoldError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "purchase success", "transaction": tr})
+}
+
+func (h *LimitHoldHandler) Cancel(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+	h.cancel(c, usecase.HoldOwner{ConsumerID: authUser.ConsumerID})
+}
+
+// MerchantCreate places a hold for a customer who authorized it with a
+// purchase token. Only the consumer can confirm it, so a merchant cannot
+// spend credit the consumer did not agree to.
+func (h *LimitHoldHandler) MerchantCreate(c *gin.Context) {
+	merchant := c.MustGet("merchant").(*entity.Merchant)
+
+	var req merchantHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hold, err := h.uc.CreateForMerchant(c.Request.Context(), merchant.ID, req.ConsumerID, req.AssetID, req.Tenor, req.PurchaseToken)
+	if err != nil {
+		limitHoldError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *LimitHoldHandler) MerchantCancel(c *gin.Context) {
+	merchant := c.MustGet("merchant").(*entity.Merchant)
+	h.cancel(c, usecase.HoldOwner{MerchantID: merchant.ID})
+}
+
+func (h *LimitHoldHandler) cancel(c *gin.Context, owner usecase.HoldOwner) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.uc.Cancel(c.Request.Context(), owner, id); err != nil {
+		limitHoldError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "hold cancelled"})
+}
+
+func limitHoldError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrInvalidTenor, usecase.ErrInsufficientLimit:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrHoldNotFound, usecase.ErrLimitNotFound, usecase.ErrMerchantAssetNotFound, usecase.ErrConsumerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrHoldNotActive, usecase.ErrHoldExpired:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case usecase.ErrEmailNotVerified, usecase.ErrLimitInactive, usecase.ErrConsumerNotActive, usecase.ErrPurchaseNotAuthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
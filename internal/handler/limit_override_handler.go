@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LimitOverrideHandler serves the maker-checker endpoints for overriding a
+// consumer's max limit, and for handing an overridden limit back to the
+// limit policy.
+type LimitOverrideHandler struct {
+	uc     *usecase.LimitOverrideUsecase
+	recalc *usecase.LimitRecalcUsecase
+}
+
+func NewLimitOverrideHandler(uc *usecase.LimitOverrideUsecase, recalc *usecase.LimitRecalcUsecase) *LimitOverrideHandler {
+	return &LimitOverrideHandler{uc: uc, recalc: recalc}
+}
+
+// Propose requests a new max limit for one of the consumer's tenors.
+func (h *LimitOverrideHandler) Propose(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	consumerID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.ProposeLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	o, err := h.uc.Propose(c.Request.Context(), staff.ID, consumerID, req)
+	if err != nil {
+		limitOverrideError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, o)
+}
+
+// ListPending returns the queue of override requests waiting for a checker.
+func (h *LimitOverrideHandler) ListPending(c *gin.Context) {
+	list, err := h.uc.ListPending(c.Request.Context())
+	if err != nil {
+		limitOverrideError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func (h *LimitOverrideHandler) ListByConsumer(c *gin.Context) {
+	consumerID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	list, err := h.uc.ListByConsumer(c.Request.Context(), consumerID)
+	if err != nil {
+		limitOverrideError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func (h *LimitOverrideHandler) Approve(c *gin.Context) {
+	h.review(c, true)
+}
+
+func (h *LimitOverrideHandler) Reject(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *LimitOverrideHandler) review(c *gin.Context, approve bool) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	// The note is optional, so an empty body is fine.
+	var req usecase.ReviewLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Review(c.Request.Context(), staff.ID, id, approve, req.Note); err != nil {
+		limitOverrideError(c, err)
+		return
+	}
+	if approve {
+		c.JSON(http.StatusOK, gin.H{"message": "limit override approved"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "limit override rejected"})
+}
+
+// Clear removes the override on one of the consumer's limits and recalculates
+// it with the active policy.
+func (h *LimitOverrideHandler) Clear(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	consumerID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.ClearLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.recalc.ClearOverride(c.Request.Context(), staff.ID, consumerID, req)
+	if err != nil {
+		limitOverrideError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func limitOverrideError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrLimitNotFound, usecase.ErrOverrideNotFound, usecase.ErrConsumerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrOverrideUnchanged, usecase.ErrOverrideBelowUsed:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrOverridePending, usecase.ErrOverrideReviewed, usecase.ErrLimitNotOverridden:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case usecase.ErrOverrideSelfReview:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case usecase.ErrNoActiveLimitPolicy:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
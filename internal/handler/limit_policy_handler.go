@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LimitPolicyHandler struct {
+	uc     *usecase.LimitPolicyUsecase
+	recalc *usecase.LimitRecalcUsecase
+}
+
+func NewLimitPolicyHandler(uc *usecase.LimitPolicyUsecase, recalc *usecase.LimitRecalcUsecase) *LimitPolicyHandler {
+	return &LimitPolicyHandler{uc: uc, recalc: recalc}
+}
+
+func (h *LimitPolicyHandler) Create(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	var req usecase.CreateLimitPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := h.uc.Create(c.Request.Context(), staff.ID, req)
+	if err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+func (h *LimitPolicyHandler) List(c *gin.Context) {
+	policies, err := h.uc.List(c.Request.Context())
+	if err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+func (h *LimitPolicyHandler) Get(c *gin.Context) {
+	version, ok := policyVersion(c)
+	if !ok {
+		return
+	}
+
+	p, err := h.uc.Get(c.Request.Context(), version)
+	if err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *LimitPolicyHandler) Publish(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	version, ok := policyVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.uc.Publish(c.Request.Context(), version, staff.ID); err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "limit policy published; existing limits are being recalculated", "version": version})
+}
+
+func (h *LimitPolicyHandler) Preview(c *gin.Context) {
+	version, ok := policyVersion(c)
+	if !ok {
+		return
+	}
+
+	var req usecase.PreviewLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limits, err := h.uc.Preview(c.Request.Context(), version, req)
+	if err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": version, "data": limits})
+}
+
+func (h *LimitPolicyHandler) SetRiskGrade(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.SetRiskGradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.SetRiskGrade(c.Request.Context(), id, req.RiskGrade); err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "risk grade updated", "risk_grade": req.RiskGrade})
+}
+
+func policyVersion(c *gin.Context) (uint32, bool) {
+	v, err := strconv.ParseUint(c.Param("version"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+// Recalculate recomputes one consumer's limits with the active policy. With
+// ?dry_run=true it only reports what would change.
+func (h *LimitPolicyHandler) Recalculate(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	res, err := h.recalc.Recalculate(c.Request.Context(), id, dryRun, usecase.RecalcTrigger{
+		Source:   entity.LimitSourceStaff,
+		SourceID: strconv.FormatUint(staff.ID, 10),
+		Reason:   "recalculated by staff",
+	})
+	if err != nil {
+		limitPolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func limitPolicyError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrLimitPolicyNotFound, usecase.ErrConsumerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidLimitRules, usecase.ErrInvalidRiskGrade:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrLimitPolicyPublished:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case usecase.ErrNoActiveLimitPolicy:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MerchantHandler serves the back-office merchant endpoints and the signed
+// endpoints merchants call themselves.
+type MerchantHandler struct {
+	uc   *usecase.MerchantUsecase
+	txUC *usecase.ConsumerTransactionUsecase
+}
+
+func NewMerchantHandler(uc *usecase.MerchantUsecase, txUC *usecase.ConsumerTransactionUsecase) *MerchantHandler {
+	return &MerchantHandler{uc: uc, txUC: txUC}
+}
+
+func (h *MerchantHandler) Create(c *gin.Context) {
+	var req usecase.CreateMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.uc.Create(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// IssueAPIKey returns a new key ID and secret. The secret is only shown here.
+func (h *MerchantHandler) IssueAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	key, err := h.uc.IssueAPIKey(c.Request.Context(), id)
+	if err != nil {
+		if err == usecase.ErrMerchantNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *MerchantHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.uc.RevokeAPIKey(c.Request.Context(), id, c.Param("key_id")); err != nil {
+		if err == usecase.ErrMerchantAPIKeyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "api key revoked"})
+}
+
+func (h *MerchantHandler) ListAssets(c *gin.Context) {
+	merchant := c.MustGet("merchant").(*entity.Merchant)
+
+	list, err := h.uc.ListAssets(c.Request.Context(), merchant.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"assets": list})
+}
+
+type merchantPurchaseRequest struct {
+	ConsumerID    uint64 `json:"consumer_id" binding:"required"`
+	AssetID       uint64 `json:"asset_id" binding:"required"`
+	Tenor         uint8  `json:"tenor" binding:"required"`
+	PurchaseToken string `json:"purchase_token" binding:"required"`
+}
+
+// Purchase starts a purchase of one of the merchant's assets for a customer
+// who authorized it with a purchase token.
+func (h *MerchantHandler) Purchase(c *gin.Context) {
+	merchant := c.MustGet("merchant").(*entity.Merchant)
+
+	var req merchantPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tr, err := h.txUC.PurchaseForMerchant(c.Request.Context(), merchant.ID, req.ConsumerID, req.AssetID, req.Tenor, req.PurchaseToken)
+	if err != nil {
+		switch err {
+		case usecase.ErrInvalidTenor, usecase.ErrInsufficientLimit:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case usecase.ErrMerchantAssetNotFound, usecase.ErrConsumerNotFound, usecase.ErrLimitNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case usecase.ErrPurchaseNotAuthorized, usecase.ErrEmailNotVerified, usecase.ErrLimitInactive, usecase.ErrConsumerNotActive:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "purchase success", "transaction": tr})
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSignedBody caps how much of a merchant request is read for signing.
+const maxSignedBody = 1 << 20
+
+// MerchantMiddleware authenticates server-to-server calls signed with a
+// merchant API key. The X-Api-Key, X-Timestamp and X-Signature headers are
+// required; the merchant is put in the context as "merchant".
+func MerchantMiddleware(uc *usecase.MerchantUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID := c.GetHeader("X-Api-Key")
+		timestamp := c.GetHeader("X-Timestamp")
+		signature := c.GetHeader("X-Signature")
+		if keyID == "" || timestamp == "" || signature == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing signature headers"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBody))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		merchant, err := uc.Authenticate(c.Request.Context(), usecase.SignedRequest{
+			KeyID:     keyID,
+			Timestamp: timestamp,
+			Signature: signature,
+			Method:    c.Request.Method,
+			URI:       c.Request.URL.RequestURI(),
+			Body:      body,
+		})
+		if err != nil {
+			if err == usecase.ErrInvalidSignature || err == usecase.ErrRequestExpired || err == usecase.ErrRequestReplayed {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("merchant", merchant)
+		c.Next()
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler manages the second factor of whoever is logged in, consumer or
+// staff.
+type MFAHandler struct {
+	uc *usecase.MFAUsecase
+}
+
+func NewMFAHandler(uc *usecase.MFAUsecase) *MFAHandler {
+	return &MFAHandler{uc: uc}
+}
+
+func mfaSubject(c *gin.Context) usecase.MFASubject {
+	if staffI, ok := c.Get("staff_user"); ok {
+		return usecase.StaffMFASubject(staffI.(*entity.StaffUser))
+	}
+	return usecase.ConsumerMFASubject(c.MustGet("auth_user").(*entity.AuthUser))
+}
+
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.uc.Enroll(c.Request.Context(), mfaSubject(c))
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	var req usecase.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.uc.Confirm(c.Request.Context(), mfaSubject(c), req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req usecase.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.uc.RegenerateRecoveryCodes(c.Request.Context(), mfaSubject(c), req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req usecase.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Disable(c.Request.Context(), mfaSubject(c), req.Code); err != nil {
+		mfaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func mfaError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrInvalidMFACode, usecase.ErrMFANotEnrolled:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrMFAAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case usecase.ErrMFAMandatory:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
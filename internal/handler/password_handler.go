@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+	"multifinance-core/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	uc *usecase.PasswordUsecase
+}
+
+func NewPasswordHandler(uc *usecase.PasswordUsecase) *PasswordHandler {
+	return &PasswordHandler{uc: uc}
+}
+
+func (h *PasswordHandler) Change(c *gin.Context) {
+	var req usecase.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	claims := c.MustGet("token_claims").(*utils.TokenClaims)
+
+	var err error
+	if staffI, ok := c.Get("staff_user"); ok {
+		err = h.uc.ChangeStaff(c.Request.Context(), staffI.(*entity.StaffUser).ID, claims.SessionID, req)
+	} else {
+		authUser := c.MustGet("auth_user").(*entity.AuthUser)
+		err = h.uc.Change(c.Request.Context(), authUser.ID, claims.SessionID, req)
+	}
+	if err != nil {
+		if err == usecase.ErrWrongPassword || err == usecase.ErrSamePassword || errors.Is(err, utils.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+func (h *PasswordHandler) Forgot(c *gin.Context) {
+	var req usecase.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Forgot(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link has been sent"})
+}
+
+func (h *PasswordHandler) Reset(c *gin.Context) {
+	var req usecase.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Reset(c.Request.Context(), req); err != nil {
+		if err == usecase.ErrInvalidResetToken || errors.Is(err, utils.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password reset"})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	uc *usecase.PrivacyUsecase
+}
+
+func NewPrivacyHandler(uc *usecase.PrivacyUsecase) *PrivacyHandler {
+	return &PrivacyHandler{uc: uc}
+}
+
+// Export gives the consumer a copy of their own data.
+func (h *PrivacyHandler) Export(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+	h.export(c, authUser.ConsumerID)
+}
+
+func (h *PrivacyHandler) AdminExport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	h.export(c, id)
+}
+
+// export answers with JSON, or with a ZIP that also holds the identity photos
+// when ?format=zip.
+func (h *PrivacyHandler) export(c *gin.Context, consumerID uint64) {
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		export, err := h.uc.Export(c.Request.Context(), consumerID)
+		if err != nil {
+			privacyError(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, export)
+	case "zip":
+		var buf bytes.Buffer
+		if err := h.uc.WriteArchive(c.Request.Context(), consumerID, &buf); err != nil {
+			privacyError(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="consumer-%d-export.zip"`, consumerID))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+	}
+}
+
+func (h *PrivacyHandler) Erase(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req usecase.EraseConsumerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.uc.Erase(c.Request.Context(), id, staff.ID, req); err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "personal data erased"})
+}
+
+func privacyError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrConsumerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrOpenContracts, usecase.ErrConsumerErased:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole must run after AuthMiddleware and lets the request through only
+// when the caller holds one of the given roles.
+func RequireRole(roles ...entity.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleI, ok := c.Get("role")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		role := roleI.(entity.Role)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
+// RequirePermission must run after AuthMiddleware and checks the caller's role
+// grants the permission.
+func RequirePermission(p entity.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleI, ok := c.Get("role")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !roleI.(entity.Role).Can(p) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
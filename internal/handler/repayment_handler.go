@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RepaymentHandler struct {
+	uc *usecase.RepaymentUsecase
+}
+
+func NewRepaymentHandler(uc *usecase.RepaymentUsecase) *RepaymentHandler {
+	return &RepaymentHandler{uc: uc}
+}
+
+// Record books an instalment paid on a contract.
+func (h *RepaymentHandler) Record(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	var req usecase.RecordRepaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, tr, err := h.uc.Record(c.Request.Context(), staff.ID, id, req)
+	if err != nil {
+		repaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"repayment": p, "transaction": tr})
+}
+
+// Close settles the outstanding balance of a contract and closes it.
+func (h *RepaymentHandler) Close(c *gin.Context) {
+	staff := c.MustGet("staff_user").(*entity.StaffUser)
+
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	var req usecase.CloseContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, tr, err := h.uc.Close(c.Request.Context(), staff.ID, id, req)
+	if err != nil {
+		repaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"repayment": p, "transaction": tr})
+}
+
+func (h *RepaymentHandler) List(c *gin.Context) {
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.uc.List(c.Request.Context(), id)
+	if err != nil {
+		repaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func (h *RepaymentHandler) ConsumerList(c *gin.Context) {
+	authUser := c.MustGet("auth_user").(*entity.AuthUser)
+
+	id, ok := transactionID(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.uc.ListForConsumer(c.Request.Context(), authUser.ConsumerID, id)
+	if err != nil {
+		repaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func transactionID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func repaymentError(c *gin.Context, err error) {
+	switch err {
+	case usecase.ErrContractNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case usecase.ErrInvalidRepayment:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case usecase.ErrContractNotActive:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/usecase"
+	"multifinance-core/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	uc *usecase.SessionUsecase
+}
+
+func NewSessionHandler(uc *usecase.SessionUsecase) *SessionHandler {
+	return &SessionHandler{uc: uc}
+}
+
+func (h *SessionHandler) Logout(c *gin.Context) {
+	claimsI, ok := c.Get("token_claims")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	claims := claimsI.(*utils.TokenClaims)
+
+	if err := h.uc.Logout(c.Request.Context(), claims.SessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+func (h *SessionHandler) LogoutAll(c *gin.Context) {
+	authI, ok := c.Get("auth_user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	authUser := authI.(*entity.AuthUser)
+
+	if err := h.uc.RevokeAll(c.Request.Context(), authUser.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out from all sessions"})
+}
+
+func (h *SessionHandler) List(c *gin.Context) {
+	authI, ok := c.Get("auth_user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	authUser := authI.(*entity.AuthUser)
+	claims := c.MustGet("token_claims").(*utils.TokenClaims)
+
+	list, err := h.uc.List(c.Request.Context(), authUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": list, "current_session_id": claims.SessionID})
+}
+
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	authI, ok := c.Get("auth_user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	authUser := authI.(*entity.AuthUser)
+
+	if err := h.uc.Revoke(c.Request.Context(), authUser.ID, c.Param("id")); err != nil {
+		if err == usecase.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
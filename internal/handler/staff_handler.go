@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"multifinance-core/internal/usecase"
+	"multifinance-core/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaffHandler struct {
+	uc *usecase.StaffUsecase
+}
+
+func NewStaffHandler(uc *usecase.StaffUsecase) *StaffHandler {
+	return &StaffHandler{uc: uc}
+}
+
+func (h *StaffHandler) Login(c *gin.Context) {
+	var req usecase.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	pair, err := h.uc.Login(c.Request.Context(), req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+type staffMFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+func (h *StaffHandler) EnrollMFA(c *gin.Context) {
+	var req staffMFAEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.uc.EnrollMFA(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *StaffHandler) ConfirmMFA(c *gin.Context) {
+	var req usecase.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	res, err := h.uc.ConfirmMFA(c.Request.Context(), req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *StaffHandler) LoginMFA(c *gin.Context) {
+	var req usecase.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	res, err := h.uc.LoginMFA(c.Request.Context(), req)
+	if err != nil {
+		loginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *StaffHandler) Create(c *gin.Context) {
+	var req usecase.CreateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.uc.Create(c.Request.Context(), req)
+	if err != nil {
+		if err == usecase.ErrInvalidStaffRole || errors.Is(err, utils.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
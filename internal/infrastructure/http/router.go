@@ -3,51 +3,212 @@ package http
 import (
 	"database/sql"
 
+	"multifinance-core/internal/config"
+	"multifinance-core/internal/domain/entity"
 	"multifinance-core/internal/handler"
+	"multifinance-core/internal/infrastructure/notifier"
+	"multifinance-core/internal/infrastructure/storage"
 	"multifinance-core/internal/repository"
 	"multifinance-core/internal/usecase"
+	"multifinance-core/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter(db *sql.DB) *gin.Engine {
+// NewRouter wires the API. limitRecalcUC is shared with the background worker
+// started by main, so publishing a policy here wakes that worker.
+func NewRouter(db *sql.DB, cfg *config.Config, limitRecalcUC *usecase.LimitRecalcUsecase) *gin.Engine {
 	r := gin.Default()
 
+	tokens := utils.NewTokenManager(cfg.Auth.TokenIssuer, cfg.Auth.TokenKeyID, cfg.Auth.TokenKeys, cfg.Auth.AccessTokenTTL)
+	staffTokens := utils.NewTokenManager(cfg.Auth.TokenIssuer, cfg.Auth.TokenKeyID, cfg.Auth.TokenKeys, cfg.Auth.StaffSessionTTL)
+	mfaTokens := utils.NewTokenManager(cfg.Auth.TokenIssuer, cfg.Auth.TokenKeyID, cfg.Auth.TokenKeys, cfg.Auth.MFAChallengeTTL)
+
 	authRepo := repository.NewAuthRepo(db)
-	consumerRepo := repository.NewConsumerRepo()
+	refreshTokenRepo := repository.NewRefreshTokenRepo(db)
+	sessionRepo := repository.NewSessionRepo(db)
+	staffRepo := repository.NewStaffRepo(db)
+	userTokenRepo := repository.NewUserTokenRepo(db)
+	mfaRepo := repository.NewMFARepo(db)
+	consumerRepo := repository.NewConsumerRepo(db, cfg.PII)
 	consumerLimitRepo := repository.NewConsumerLimitRepo(db)
 	consumerTxRepo := repository.NewConsumerTransactionRepo(db)
 	assetRepo := repository.NewAssetRepo(db)
+	merchantRepo := repository.NewMerchantRepo(db)
+	purchaseAuthRepo := repository.NewPurchaseAuthorizationRepo(db)
+	changeRequestRepo := repository.NewConsumerChangeRequestRepo(db, cfg.PII)
+	kycReviewRepo := repository.NewKYCReviewRepo(db)
+	documentRepo := repository.NewDocumentRepo(db)
+	statusChangeRepo := repository.NewConsumerStatusChangeRepo(db)
+	limitPolicyRepo := repository.NewLimitPolicyRepo(db)
+	limitLedgerRepo := repository.NewLimitLedgerRepo(db)
+	limitHoldRepo := repository.NewLimitHoldRepo(db)
+	repaymentRepo := repository.NewRepaymentRepo(db)
+	limitOverrideRepo := repository.NewLimitOverrideRepo(db)
+
+	loginGuard := usecase.NewLoginGuard(cfg.Auth.LoginMaxAttempts, cfg.Auth.LoginLockoutBase, cfg.Auth.LoginLockoutMax, nil)
+	uploadLimiter := usecase.NewUploadLimiter(cfg.Document.AnonUploadLimit, cfg.Document.AnonUploadWindow, nil)
+	notify := notifier.New(cfg.Notifier.Driver, cfg.Notifier.FilePath)
+	blobs := storage.NewLocalStore(cfg.Document.Dir)
 
-	authUC := usecase.NewAuthUsecase(db, consumerRepo, authRepo)
+	mfaUC := usecase.NewMFAUsecase(db, mfaRepo, mfaTokens, cfg.Auth.MFAIssuer)
+	authUC := usecase.NewAuthUsecase(db, consumerRepo, authRepo, documentRepo, refreshTokenRepo, sessionRepo, tokens, cfg.Auth.RefreshTokenTTL, loginGuard, cfg.Password.Policy, mfaUC, limitPolicyRepo)
+	sessionUC := usecase.NewSessionUsecase(db, sessionRepo, refreshTokenRepo)
+	staffUC := usecase.NewStaffUsecase(db, staffRepo, sessionRepo, staffTokens, cfg.Auth.StaffSessionTTL, loginGuard, cfg.Password.Policy, mfaUC)
+	passwordUC := usecase.NewPasswordUsecase(db, authRepo, staffRepo, userTokenRepo, sessionRepo, refreshTokenRepo, notify, cfg.Password.Policy, cfg.Password.ResetTTL, cfg.Password.ResetURL, loginGuard)
 	assetUC := usecase.NewAssetUsecase(db, assetRepo)
-	consumerTxUC := usecase.NewConsumerTransactionUsecase(db, assetRepo, consumerLimitRepo, consumerTxRepo)
+	verifyUC := usecase.NewEmailVerificationUsecase(db, authRepo, userTokenRepo, notify, cfg.Email.TTL, cfg.Email.URL)
+	consumerLimitUC := usecase.NewConsumerLimitUsecase(db, consumerLimitRepo, limitLedgerRepo)
+	consumerTxUC := usecase.NewConsumerTransactionUsecase(db, assetRepo, consumerLimitRepo, consumerTxRepo, authRepo, consumerRepo, limitLedgerRepo, purchaseAuthRepo, cfg.Merchant.PurchaseAuthTTL)
+	consumerUC := usecase.NewConsumerUsecase(db, consumerRepo, authRepo, changeRequestRepo, documentRepo, limitRecalcUC)
+	consumerStatusUC := usecase.NewConsumerStatusUsecase(db, consumerRepo, statusChangeRepo, authRepo, sessionRepo, refreshTokenRepo)
+	kycUC := usecase.NewKYCUsecase(db, consumerRepo, kycReviewRepo, consumerLimitRepo, documentRepo)
+	privacyUC := usecase.NewPrivacyUsecase(db, consumerRepo, authRepo, consumerLimitRepo, consumerTxRepo, repaymentRepo, limitLedgerRepo, limitHoldRepo, documentRepo, changeRequestRepo, kycReviewRepo, statusChangeRepo, sessionRepo, refreshTokenRepo, mfaRepo, userTokenRepo, blobs)
+	documentUC := usecase.NewDocumentUsecase(db, documentRepo, blobs, cfg.Document.URLKey, cfg.Document.URLTTL, cfg.Document.MaxSize, cfg.Document.UnclaimedTTL)
+	limitPolicyUC := usecase.NewLimitPolicyUsecase(db, limitPolicyRepo, consumerRepo, limitRecalcUC)
+	limitHoldUC := usecase.NewLimitHoldUsecase(db, limitHoldRepo, consumerLimitRepo, assetRepo, consumerTxRepo, limitLedgerRepo, authRepo, consumerRepo, purchaseAuthRepo, cfg.Limit.HoldTTL)
+	repaymentUC := usecase.NewRepaymentUsecase(db, repaymentRepo, consumerTxRepo, consumerLimitRepo, limitLedgerRepo)
+	limitOverrideUC := usecase.NewLimitOverrideUsecase(db, limitOverrideRepo, consumerLimitRepo, limitLedgerRepo)
+	merchantUC := usecase.NewMerchantUsecase(db, merchantRepo, assetRepo, cfg.Merchant.MasterKey, cfg.Merchant.SignatureWindow)
 
-	authHandler := handler.NewAuthHandler(authUC)
+	authHandler := handler.NewAuthHandler(authUC, verifyUC)
+	sessionHandler := handler.NewSessionHandler(sessionUC)
+	staffHandler := handler.NewStaffHandler(staffUC)
+	passwordHandler := handler.NewPasswordHandler(passwordUC)
+	mfaHandler := handler.NewMFAHandler(mfaUC)
 	assetHandler := handler.NewAssetHandler(assetUC)
 	consumerTxHandler := handler.NewConsumerTransactionHandler(consumerTxUC)
+	consumerLimitHandler := handler.NewConsumerLimitHandler(consumerLimitUC)
+	merchantHandler := handler.NewMerchantHandler(merchantUC, consumerTxUC)
+	consumerHandler := handler.NewConsumerHandler(consumerUC)
+	consumerStatusHandler := handler.NewConsumerStatusHandler(consumerStatusUC)
+	kycHandler := handler.NewKYCHandler(kycUC)
+	documentHandler := handler.NewDocumentHandler(documentUC, cfg.Document.MaxSize)
+	privacyHandler := handler.NewPrivacyHandler(privacyUC)
+	limitPolicyHandler := handler.NewLimitPolicyHandler(limitPolicyUC, limitRecalcUC)
+	limitHoldHandler := handler.NewLimitHoldHandler(limitHoldUC)
+	repaymentHandler := handler.NewRepaymentHandler(repaymentUC)
+	limitOverrideHandler := handler.NewLimitOverrideHandler(limitOverrideUC, limitRecalcUC)
 
-	authMiddleware := handler.AuthMiddleware(authRepo)
+	authMiddleware := handler.AuthMiddleware(tokens, authRepo, staffRepo, sessionRepo, consumerRepo)
 
 	api := r.Group("/api")
 	{
 		api.POST("/register", authHandler.Register)
 		api.POST("/login", authHandler.Login)
+		api.POST("/token/refresh", authHandler.Refresh)
+		api.POST("/login/mfa", authHandler.LoginMFA)
+		api.POST("/staff/login", staffHandler.Login)
+		api.POST("/staff/login/mfa", staffHandler.LoginMFA)
+		api.POST("/staff/mfa/enroll", staffHandler.EnrollMFA)
+		api.POST("/staff/mfa/confirm", staffHandler.ConfirmMFA)
+		api.POST("/password/forgot", passwordHandler.Forgot)
+		api.POST("/password/reset", passwordHandler.Reset)
+		api.POST("/email/verify", authHandler.VerifyEmail)
+		api.POST("/documents", handler.AnonymousUploadLimit(uploadLimiter), documentHandler.Upload)
+		api.GET("/documents/:id/content", documentHandler.Content)
+
+		authed := api.Group("")
+		authed.Use(authMiddleware)
+		{
+			authed.POST("/logout", sessionHandler.Logout)
+			authed.POST("/logout-all", sessionHandler.LogoutAll)
+			authed.GET("/sessions", sessionHandler.List)
+			authed.DELETE("/sessions/:id", sessionHandler.Revoke)
+			authed.PUT("/password", passwordHandler.Change)
+			authed.POST("/mfa/enroll", mfaHandler.Enroll)
+			authed.POST("/mfa/confirm", mfaHandler.Confirm)
+			authed.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+			authed.DELETE("/mfa", mfaHandler.Disable)
+		}
 
 		consumers := api.Group("/consumers")
-		consumers.Use(authMiddleware)
+		consumers.Use(authMiddleware, handler.RequireRole(entity.RoleConsumer))
 		{
 			consumers.POST("transactions", consumerTxHandler.Purchase)
+			consumers.POST("purchase-authorizations", consumerTxHandler.AuthorizePurchase)
 			consumers.GET("transactions", consumerTxHandler.List)
+			consumers.GET("transactions/:id/repayments", repaymentHandler.ConsumerList)
+			consumers.GET("limits", consumerLimitHandler.List)
+			consumers.GET("limits/:tenor", consumerLimitHandler.Get)
+			consumers.POST("limits/:tenor/use", limitHoldHandler.Use)
+			consumers.GET("limits/:tenor/history", consumerLimitHandler.History)
+			consumers.POST("holds", limitHoldHandler.Create)
+			consumers.POST("holds/:id/confirm", limitHoldHandler.Confirm)
+			consumers.POST("holds/:id/cancel", limitHoldHandler.Cancel)
+			consumers.POST("email/verify/resend", authHandler.ResendVerification)
+			consumers.GET("me", consumerHandler.Me)
+			consumers.PATCH("me", consumerHandler.UpdateMe)
+			consumers.GET("me/export", privacyHandler.Export)
+			consumers.GET("kyc", kycHandler.Me)
+			consumers.POST("kyc/resubmit", kycHandler.Resubmit)
+			consumers.POST("documents", documentHandler.Upload)
+			consumers.GET("documents/:id/url", documentHandler.URL)
 		}
 
 		assets := api.Group("/assets")
 		{
-			assets.POST("", assetHandler.Create)
 			assets.GET("", assetHandler.List)
 			assets.GET(":id", assetHandler.Get)
-			assets.PUT(":id", assetHandler.Update)
-			assets.DELETE(":id", assetHandler.Delete)
+		}
+
+		assetWrites := api.Group("/assets")
+		assetWrites.Use(authMiddleware, handler.RequirePermission(entity.PermAssetWrite))
+		{
+			assetWrites.POST("", assetHandler.Create)
+			assetWrites.PUT(":id", assetHandler.Update)
+			assetWrites.DELETE(":id", assetHandler.Delete)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware, handler.RequireRole(entity.RoleAdmin, entity.RoleOperator))
+		{
+			admin.GET("reports/transactions", handler.RequirePermission(entity.PermReportRead), consumerTxHandler.Report)
+			admin.GET("transactions/:id/repayments", handler.RequirePermission(entity.PermConsumerRead), repaymentHandler.List)
+			admin.POST("transactions/:id/repayments", handler.RequirePermission(entity.PermPaymentWrite), repaymentHandler.Record)
+			admin.POST("transactions/:id/close", handler.RequirePermission(entity.PermPaymentWrite), repaymentHandler.Close)
+			admin.POST("staff", handler.RequirePermission(entity.PermStaffManage), staffHandler.Create)
+			admin.POST("accounts/unlock", handler.RequirePermission(entity.PermAccountUnlock), authHandler.Unlock)
+			admin.GET("consumers/:id", handler.RequirePermission(entity.PermConsumerRead), consumerHandler.Get)
+			admin.PATCH("consumers/:id", handler.RequirePermission(entity.PermConsumerWrite), consumerHandler.Update)
+			admin.POST("consumers/:id/change-requests/:request_id/approve", handler.RequirePermission(entity.PermConsumerWrite), consumerHandler.ApproveChange)
+			admin.POST("consumers/:id/change-requests/:request_id/reject", handler.RequirePermission(entity.PermConsumerWrite), consumerHandler.RejectChange)
+			admin.POST("consumers/:id/status", handler.RequirePermission(entity.PermConsumerWrite), consumerStatusHandler.Change)
+			admin.GET("consumers/:id/status-changes", handler.RequirePermission(entity.PermConsumerRead), consumerStatusHandler.History)
+			admin.GET("consumers/:id/export", handler.RequirePermission(entity.PermConsumerRead), privacyHandler.AdminExport)
+			admin.POST("consumers/:id/erase", handler.RequirePermission(entity.PermConsumerWrite), privacyHandler.Erase)
+			admin.PUT("consumers/:id/risk-grade", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.SetRiskGrade)
+			admin.POST("consumers/:id/limits/recalculate", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.Recalculate)
+			admin.GET("consumers/:id/limit-overrides", handler.RequirePermission(entity.PermLimitOverride), limitOverrideHandler.ListByConsumer)
+			admin.POST("consumers/:id/limit-overrides", handler.RequirePermission(entity.PermLimitOverride), limitOverrideHandler.Propose)
+			admin.POST("consumers/:id/limit-overrides/clear", handler.RequirePermission(entity.PermLimitOverride), limitOverrideHandler.Clear)
+			admin.GET("consumers/:id/limits/:tenor/history", handler.RequirePermission(entity.PermConsumerRead), consumerLimitHandler.AdminHistory)
+			admin.GET("limit-overrides", handler.RequirePermission(entity.PermLimitOverride), limitOverrideHandler.ListPending)
+			admin.POST("limit-overrides/:id/approve", handler.RequirePermission(entity.PermLimitOverride), limitOverrideHandler.Approve)
+			admin.POST("limit-overrides/:id/reject", handler.RequirePermission(entity.PermLimitOverride), limitOverrideHandler.Reject)
+			admin.GET("limits/reconciliation", handler.RequirePermission(entity.PermReportRead), consumerLimitHandler.Reconcile)
+			admin.GET("documents/:id/url", handler.RequirePermission(entity.PermConsumerRead), documentHandler.AdminURL)
+			admin.GET("kyc", handler.RequirePermission(entity.PermKYCReview), kycHandler.List)
+			admin.GET("kyc/:id", handler.RequirePermission(entity.PermKYCReview), kycHandler.Get)
+			admin.POST("kyc/:id/approve", handler.RequirePermission(entity.PermKYCReview), kycHandler.Approve)
+			admin.POST("kyc/:id/reject", handler.RequirePermission(entity.PermKYCReview), kycHandler.Reject)
+			admin.GET("limit-policies", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.List)
+			admin.POST("limit-policies", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.Create)
+			admin.GET("limit-policies/:version", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.Get)
+			admin.POST("limit-policies/:version/preview", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.Preview)
+			admin.POST("limit-policies/:version/publish", handler.RequirePermission(entity.PermLimitPolicy), limitPolicyHandler.Publish)
+			admin.POST("merchants", handler.RequirePermission(entity.PermMerchantManage), merchantHandler.Create)
+			admin.POST("merchants/:id/api-keys", handler.RequirePermission(entity.PermMerchantManage), merchantHandler.IssueAPIKey)
+			admin.DELETE("merchants/:id/api-keys/:key_id", handler.RequirePermission(entity.PermMerchantManage), merchantHandler.RevokeAPIKey)
+		}
+
+		merchant := api.Group("/merchant")
+		merchant.Use(handler.MerchantMiddleware(merchantUC))
+		{
+			merchant.GET("assets", merchantHandler.ListAssets)
+			merchant.POST("purchases", merchantHandler.Purchase)
+			merchant.POST("holds", limitHoldHandler.MerchantCreate)
+			merchant.POST("holds/:id/cancel", limitHoldHandler.MerchantCancel)
 		}
 	}
 
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is an outgoing notification such as a password reset link.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Production deployments plug in an
+// email or SMS gateway; the log and file notifiers are for local development.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the notifier for the configured driver: "file" appends to path,
+// anything else writes to stdout.
+func New(driver, path string) Notifier {
+	if driver == "file" {
+		return NewFileNotifier(path)
+	}
+	return NewLogNotifier(os.Stdout)
+}
+
+type logNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogNotifier(w io.Writer) Notifier {
+	return &logNotifier{w: w}
+}
+
+func (n *logNotifier) Send(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return write(n.w, msg)
+}
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Send(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f, msg)
+}
+
+func write(w io.Writer, msg Message) error {
+	_, err := fmt.Fprintf(w, "---- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().UTC().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps immutable content under a caller-chosen key, normally the
+// hex SHA-256 of the content. Production deployments plug in object storage;
+// the local store is for development and single-node setups.
+type BlobStore interface {
+	// Put stores the content under key. Storing an existing key is a no-op.
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the content. Deleting a missing key is a no-op.
+	Delete(ctx context.Context, key string) error
+}
+
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore stores blobs below dir, fanned out by the first two characters
+// of the key.
+func NewLocalStore(dir string) BlobStore {
+	return &localStore{dir: dir}
+}
+
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial blob.
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *localStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStore) path(key string) (string, error) {
+	if len(key) < 3 || filepath.Base(key) != key {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.dir, key[:2], key), nil
+}
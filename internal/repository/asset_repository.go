@@ -12,6 +12,7 @@ type AssetRepository interface {
 	Create(ctx context.Context, tx *sql.Tx, a *entity.Asset) (uint64, error)
 	GetByID(ctx context.Context, id uint64) (*entity.Asset, error)
 	List(ctx context.Context) ([]*entity.Asset, error)
+	ListByMerchant(ctx context.Context, merchantID uint64) ([]*entity.Asset, error)
 	Update(ctx context.Context, tx *sql.Tx, a *entity.Asset) error
 	Delete(ctx context.Context, tx *sql.Tx, id uint64) error
 }
@@ -27,9 +28,9 @@ func NewAssetRepo(db *sql.DB) AssetRepository {
 func (r *assetRepo) Create(ctx context.Context, tx *sql.Tx, a *entity.Asset) (uint64, error) {
 	now := time.Now().UTC()
 	res, err := tx.ExecContext(ctx, `
-        INSERT INTO assets (product_name, price_product, seller, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?)`,
-		a.ProductName, a.PriceProduct, a.Seller, now, now,
+        INSERT INTO assets (product_name, price_product, seller, merchant_id, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?)`,
+		a.ProductName, a.PriceProduct, a.Seller, a.MerchantID, now, now,
 	)
 	if err != nil {
 		return 0, err
@@ -44,42 +45,36 @@ func (r *assetRepo) Create(ctx context.Context, tx *sql.Tx, a *entity.Asset) (ui
 
 func (r *assetRepo) GetByID(ctx context.Context, id uint64) (*entity.Asset, error) {
 	row := r.db.QueryRowContext(ctx, `
-        SELECT id, product_name, price_product, seller, created_at, updated_at
+        SELECT id, product_name, price_product, seller, merchant_id, created_at, updated_at
         FROM assets WHERE id = ?`, id)
-
-	var a entity.Asset
-	err := row.Scan(&a.ID, &a.ProductName, &a.PriceProduct, &a.Seller, &a.CreatedAt, &a.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &a, nil
+	return scanAsset(row)
 }
 
 func (r *assetRepo) List(ctx context.Context) ([]*entity.Asset, error) {
 	rows, err := r.db.QueryContext(ctx, `
-        SELECT id, product_name, price_product, seller, created_at, updated_at
+        SELECT id, product_name, price_product, seller, merchant_id, created_at, updated_at
         FROM assets`)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanAssets(rows)
+}
 
-	var res []*entity.Asset
-	for rows.Next() {
-		var a entity.Asset
-		if err := rows.Scan(&a.ID, &a.ProductName, &a.PriceProduct, &a.Seller, &a.CreatedAt, &a.UpdatedAt); err != nil {
-			return nil, err
-		}
-		res = append(res, &a)
+func (r *assetRepo) ListByMerchant(ctx context.Context, merchantID uint64) ([]*entity.Asset, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, product_name, price_product, seller, merchant_id, created_at, updated_at
+        FROM assets WHERE merchant_id = ?`, merchantID)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	return scanAssets(rows)
 }
 
 func (r *assetRepo) Update(ctx context.Context, tx *sql.Tx, a *entity.Asset) error {
 	now := time.Now().UTC()
 	_, err := tx.ExecContext(ctx, `
-        UPDATE assets SET product_name = ?, price_product = ?, seller = ?, updated_at = ? WHERE id = ?`,
-		a.ProductName, a.PriceProduct, a.Seller, now, a.ID,
+        UPDATE assets SET product_name = ?, price_product = ?, seller = ?, merchant_id = ?, updated_at = ? WHERE id = ?`,
+		a.ProductName, a.PriceProduct, a.Seller, a.MerchantID, now, a.ID,
 	)
 	return err
 }
@@ -88,3 +83,31 @@ func (r *assetRepo) Delete(ctx context.Context, tx *sql.Tx, id uint64) error {
 	_, err := tx.ExecContext(ctx, `DELETE FROM assets WHERE id = ?`, id)
 	return err
 }
+
+func scanAsset(row rowScanner) (*entity.Asset, error) {
+	var a entity.Asset
+	var merchantID sql.NullInt64
+	err := row.Scan(&a.ID, &a.ProductName, &a.PriceProduct, &a.Seller, &merchantID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if merchantID.Valid {
+		id := uint64(merchantID.Int64)
+		a.MerchantID = &id
+	}
+	return &a, nil
+}
+
+func scanAssets(rows *sql.Rows) ([]*entity.Asset, error) {
+	defer rows.Close()
+
+	var res []*entity.Asset
+	for rows.Next() {
+		a, err := scanAsset(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
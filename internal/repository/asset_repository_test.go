@@ -43,9 +43,9 @@ func TestAssetRepo_Create(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
-        INSERT INTO assets (product_name, price_product, seller, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?)`)).
-		WithArgs(asset.ProductName, asset.PriceProduct, asset.Seller, sqlmock.AnyArg(), sqlmock.AnyArg()).
+        INSERT INTO assets (product_name, price_product, seller, merchant_id, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?)`)).
+		WithArgs(asset.ProductName, asset.PriceProduct, asset.Seller, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectCommit()
@@ -66,11 +66,11 @@ func TestAssetRepo_GetByID(t *testing.T) {
 	ctx := context.Background()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "product_name", "price_product", "seller", "created_at", "updated_at",
-	}).AddRow(1, "Motor Yamaha", 17000000, "Dealer B", time.Now(), time.Now())
+		"id", "product_name", "price_product", "seller", "merchant_id", "created_at", "updated_at",
+	}).AddRow(1, "Motor Yamaha", 17000000, "Dealer B", nil, time.Now(), time.Now())
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT id, product_name, price_product, seller, created_at, updated_at
+        SELECT id, product_name, price_product, seller, merchant_id, created_at, updated_at
         FROM assets WHERE id = ?`)).
 		WithArgs(1).
 		WillReturnRows(rows)
@@ -80,6 +80,7 @@ func TestAssetRepo_GetByID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(1), asset.ID)
 	assert.Equal(t, "Motor Yamaha", asset.ProductName)
+	assert.Nil(t, asset.MerchantID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -90,13 +91,13 @@ func TestAssetRepo_List(t *testing.T) {
 	ctx := context.Background()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "product_name", "price_product", "seller", "created_at", "updated_at",
+		"id", "product_name", "price_product", "seller", "merchant_id", "created_at", "updated_at",
 	}).
-		AddRow(1, "TV Samsung", 5000000, "Seller A", time.Now(), time.Now()).
-		AddRow(2, "Kulkas LG", 4000000, "Seller B", time.Now(), time.Now())
+		AddRow(1, "TV Samsung", 5000000, "Seller A", nil, time.Now(), time.Now()).
+		AddRow(2, "Kulkas LG", 4000000, "Seller B", nil, time.Now(), time.Now())
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT id, product_name, price_product, seller, created_at, updated_at
+        SELECT id, product_name, price_product, seller, merchant_id, created_at, updated_at
         FROM assets`)).
 		WillReturnRows(rows)
 
@@ -108,6 +109,28 @@ func TestAssetRepo_List(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAssetRepo_ListByMerchant(t *testing.T) {
+	_, mock, repo, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "product_name", "price_product", "seller", "merchant_id", "created_at", "updated_at",
+	}).AddRow(3, "Laptop Asus", 9000000, "Toko Elektronik", 7, time.Now(), time.Now())
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+        SELECT id, product_name, price_product, seller, merchant_id, created_at, updated_at
+        FROM assets WHERE merchant_id = ?`)).
+		WithArgs(uint64(7)).
+		WillReturnRows(rows)
+
+	list, err := repo.ListByMerchant(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, uint64(7), *list[0].MerchantID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAssetRepo_Update(t *testing.T) {
 	db, mock, repo, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -116,8 +139,8 @@ func TestAssetRepo_Update(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
-		UPDATE assets SET product_name = ?, price_product = ?, seller = ?, updated_at = ? WHERE id = ?`)).
-		WithArgs("Updated Name", float64(20000000), "Dealer C", sqlmock.AnyArg(), uint64(1)).
+        UPDATE assets SET product_name = ?, price_product = ?, seller = ?, merchant_id = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs("Updated Name", float64(20000000), "Dealer C", nil, sqlmock.AnyArg(), uint64(1)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"multifinance-core/internal/domain/entity"
 )
@@ -10,6 +11,11 @@ import (
 type AuthRepository interface {
 	Create(ctx context.Context, tx *sql.Tx, u *entity.AuthUser) error
 	FindByEmail(ctx context.Context, email string) (*entity.AuthUser, error)
+	FindByID(ctx context.Context, id uint64) (*entity.AuthUser, error)
+	FindByConsumerID(ctx context.Context, consumerID uint64) (*entity.AuthUser, error)
+	UpdatePassword(ctx context.Context, tx *sql.Tx, id uint64, hash string) error
+	MarkVerified(ctx context.Context, tx *sql.Tx, id uint64) error
+	Anonymise(ctx context.Context, tx *sql.Tx, id uint64, email string) error
 }
 
 type authRepo struct {
@@ -31,13 +37,51 @@ func (r *authRepo) Create(ctx context.Context, tx *sql.Tx, u *entity.AuthUser) e
 
 func (r *authRepo) FindByEmail(ctx context.Context, email string) (*entity.AuthUser, error) {
 	row := r.db.QueryRowContext(ctx, `
-		SELECT id, consumer_id, email, password
+		SELECT id, consumer_id, email, password, verified_at
 		FROM auth_users WHERE email = ?`, email)
+	return scanAuthUser(row)
+}
 
+func (r *authRepo) FindByID(ctx context.Context, id uint64) (*entity.AuthUser, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, consumer_id, email, password, verified_at
+		FROM auth_users WHERE id = ?`, id)
+	return scanAuthUser(row)
+}
+
+func (r *authRepo) FindByConsumerID(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, consumer_id, email, password, verified_at
+		FROM auth_users WHERE consumer_id = ?`, consumerID)
+	return scanAuthUser(row)
+}
+
+func (r *authRepo) UpdatePassword(ctx context.Context, tx *sql.Tx, id uint64, hash string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE auth_users SET password = ? WHERE id = ?`, hash, id)
+	return err
+}
+
+func (r *authRepo) MarkVerified(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE auth_users SET verified_at = ? WHERE id = ? AND verified_at IS NULL`, time.Now().UTC(), id)
+	return err
+}
+
+// Anonymise replaces the email with a placeholder and clears the password
+// hash, which no password can match.
+func (r *authRepo) Anonymise(ctx context.Context, tx *sql.Tx, id uint64, email string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE auth_users SET email = ?, password = '', verified_at = NULL WHERE id = ?`, email, id)
+	return err
+}
+
+func scanAuthUser(row rowScanner) (*entity.AuthUser, error) {
 	var u entity.AuthUser
-	err := row.Scan(&u.ID, &u.ConsumerID, &u.Email, &u.Password)
+	var verifiedAt sql.NullTime
+	err := row.Scan(&u.ID, &u.ConsumerID, &u.Email, &u.Password, &verifiedAt)
 	if err != nil {
 		return nil, err
 	}
+	if verifiedAt.Valid {
+		u.VerifiedAt = &verifiedAt.Time
+	}
 	return &u, nil
 }
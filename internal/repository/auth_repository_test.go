@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -92,11 +93,11 @@ func TestAuthRepo_FindByEmail_Success(t *testing.T) {
 	ctx := context.Background()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "consumer_id", "email", "password",
-	}).AddRow(1, 10, "budi@mail.com", "hashedpassword")
+		"id", "consumer_id", "email", "password", "verified_at",
+	}).AddRow(1, 10, "budi@mail.com", "hashedpassword", nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, consumer_id, email, password
+		SELECT id, consumer_id, email, password, verified_at
 		FROM auth_users WHERE email = ?`)).
 		WithArgs("budi@mail.com").
 		WillReturnRows(rows)
@@ -117,7 +118,7 @@ func TestAuthRepo_FindByEmail_NotFound(t *testing.T) {
 	ctx := context.Background()
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, consumer_id, email, password
+		SELECT id, consumer_id, email, password, verified_at
 		FROM auth_users WHERE email = ?`)).
 		WithArgs("notfound@mail.com").
 		WillReturnError(sql.ErrNoRows)
@@ -129,3 +130,108 @@ func TestAuthRepo_FindByEmail_NotFound(t *testing.T) {
 	assert.Equal(t, sql.ErrNoRows, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestAuthRepo_FindByID_Success(t *testing.T) {
+	_, mock, repo, cleanup := setupAuthMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "consumer_id", "email", "password", "verified_at",
+	}).AddRow(1, 10, "budi@mail.com", "hashedpassword", nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, consumer_id, email, password, verified_at
+		FROM auth_users WHERE id = ?`)).
+		WithArgs(uint64(1)).
+		WillReturnRows(rows)
+
+	user, err := repo.FindByID(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), user.ID)
+	assert.Equal(t, uint64(10), user.ConsumerID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthRepo_UpdatePassword(t *testing.T) {
+	db, mock, repo, cleanup := setupAuthMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE auth_users SET password = ? WHERE id = ?`)).
+		WithArgs("newhash", uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.UpdatePassword(ctx, tx, 1, "newhash"))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthRepo_FindByConsumerID_Verified(t *testing.T) {
+	_, mock, repo, cleanup := setupAuthMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	verifiedAt := time.Date(2026, 2, 5, 10, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "consumer_id", "email", "password", "verified_at",
+	}).AddRow(1, 10, "budi@mail.com", "hashedpassword", verifiedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, consumer_id, email, password, verified_at
+		FROM auth_users WHERE consumer_id = ?`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	user, err := repo.FindByConsumerID(ctx, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), user.ID)
+	assert.Equal(t, &verifiedAt, user.VerifiedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthRepo_MarkVerified(t *testing.T) {
+	db, mock, repo, cleanup := setupAuthMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE auth_users SET verified_at = ? WHERE id = ? AND verified_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.MarkVerified(ctx, tx, 1))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthRepo_Anonymise(t *testing.T) {
+	db, mock, repo, cleanup := setupAuthMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE auth_users SET email = ?, password = '', verified_at = NULL WHERE id = ?`)).
+		WithArgs("erased-5@erased.invalid", uint64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Anonymise(context.Background(), tx, 5, "erased-5@erased.invalid")
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
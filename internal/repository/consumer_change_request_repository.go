@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+)
+
+type ConsumerChangeRequestRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, r *entity.ConsumerChangeRequest) (uint64, error)
+	FindPendingByConsumer(ctx context.Context, consumerID uint64) (*entity.ConsumerChangeRequest, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.ConsumerChangeRequest, error)
+	Review(ctx context.Context, tx *sql.Tx, id uint64, status entity.ChangeRequestStatus, staffUserID uint64, note string) error
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerChangeRequest, error)
+	AnonymiseByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error
+	ListIDsForReencryption(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error)
+	UpdatePII(ctx context.Context, tx *sql.Tx, cr *entity.ConsumerChangeRequest) error
+}
+
+// consumerChangeRequestRepo encrypts the requested NIK, legal name, birth date
+// and salary the same way consumerRepo does. Values left unchanged stay NULL.
+type consumerChangeRequestRepo struct {
+	db   *sql.DB
+	keys *utils.Keyring
+}
+
+func NewConsumerChangeRequestRepo(db *sql.DB, keys *utils.Keyring) ConsumerChangeRequestRepository {
+	return &consumerChangeRequestRepo{db, keys}
+}
+
+const changeRequestColumns = `id, consumer_id, nik, legal_name, birth_place, birth_date, salary, ktp_document_id, selfie_document_id,
+		       status, reviewed_by, review_note, reviewed_at, created_at, pii_key`
+
+// sealedChangeRequest holds the column values of the encrypted fields; a nil
+// value is stored as NULL.
+type sealedChangeRequest struct {
+	nik, legalName, birthDate, salary any
+	dataKey, keyVersion               string
+}
+
+func (r *consumerChangeRequestRepo) Create(ctx context.Context, tx *sql.Tx, cr *entity.ConsumerChangeRequest) (uint64, error) {
+	p, err := r.seal(cr)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO consumer_change_requests
+		(consumer_id, nik, legal_name, birth_place, birth_date, salary, ktp_document_id, selfie_document_id, status, created_at, pii_key, key_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		cr.ConsumerID, p.nik, p.legalName, cr.BirthPlace, p.birthDate, p.salary, cr.KTPDocumentID, cr.SelfieDocumentID,
+		entity.ChangeRequestPending, time.Now().UTC(), p.dataKey, p.keyVersion,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *consumerChangeRequestRepo) FindPendingByConsumer(ctx context.Context, consumerID uint64) (*entity.ConsumerChangeRequest, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+changeRequestColumns+`
+		FROM consumer_change_requests WHERE consumer_id = ? AND status = ?
+		ORDER BY id DESC LIMIT 1`, consumerID, entity.ChangeRequestPending)
+	return r.scan(row)
+}
+
+func (r *consumerChangeRequestRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.ConsumerChangeRequest, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+changeRequestColumns+`
+		FROM consumer_change_requests WHERE id = ? FOR UPDATE`, id)
+	return r.scan(row)
+}
+
+func (r *consumerChangeRequestRepo) Review(ctx context.Context, tx *sql.Tx, id uint64, status entity.ChangeRequestStatus, staffUserID uint64, note string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE consumer_change_requests SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ?
+		WHERE id = ?`,
+		status, staffUserID, note, time.Now().UTC(), id,
+	)
+	return err
+}
+
+func (r *consumerChangeRequestRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerChangeRequest, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+changeRequestColumns+`
+		FROM consumer_change_requests WHERE consumer_id = ? ORDER BY id`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.ConsumerChangeRequest
+	for rows.Next() {
+		cr, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cr)
+	}
+	return res, rows.Err()
+}
+
+// AnonymiseByConsumer drops the requested values and keeps the review trail.
+func (r *consumerChangeRequestRepo) AnonymiseByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE consumer_change_requests
+		SET nik = NULL, legal_name = NULL, birth_place = NULL, birth_date = NULL, salary = NULL,
+		    ktp_document_id = NULL, selfie_document_id = NULL, pii_key = '', key_version = ?
+		WHERE consumer_id = ?`, r.keys.Version(), consumerID)
+	return err
+}
+
+// ListIDsForReencryption locks up to limit rows that are still plaintext or
+// wrapped with an older key version. Requests of erased consumers are
+// skipped.
+func (r *consumerChangeRequestRepo) ListIDsForReencryption(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM consumer_change_requests cr
+		WHERE key_version <> ?
+		  AND NOT EXISTS (SELECT 1 FROM consumers c WHERE c.id = cr.consumer_id AND c.erased_at IS NOT NULL)
+		ORDER BY id LIMIT ? FOR UPDATE`,
+		r.keys.Version(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdatePII rewrites the encrypted fields under a fresh data key wrapped with
+// the current key version.
+func (r *consumerChangeRequestRepo) UpdatePII(ctx context.Context, tx *sql.Tx, cr *entity.ConsumerChangeRequest) error {
+	p, err := r.seal(cr)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE consumer_change_requests
+		SET nik = ?, legal_name = ?, birth_date = ?, salary = ?, pii_key = ?, key_version = ?
+		WHERE id = ?`,
+		p.nik, p.legalName, p.birthDate, p.salary, p.dataKey, p.keyVersion, cr.ID,
+	)
+	return err
+}
+
+func (r *consumerChangeRequestRepo) seal(cr *entity.ConsumerChangeRequest) (*sealedChangeRequest, error) {
+	dek, wrapped, err := r.keys.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var salary *string
+	if cr.Salary != nil {
+		s := strconv.FormatFloat(*cr.Salary, 'f', -1, 64)
+		salary = &s
+	}
+
+	p := &sealedChangeRequest{dataKey: wrapped, keyVersion: r.keys.Version()}
+	for _, f := range []struct {
+		dst   *any
+		label string
+		value *string
+	}{
+		{&p.nik, "nik", cr.NIK},
+		{&p.legalName, "legal_name", cr.LegalName},
+		{&p.birthDate, "birth_date", cr.BirthDate},
+		{&p.salary, "salary", salary},
+	} {
+		if f.value == nil {
+			continue
+		}
+		sealed, err := utils.SealString(dek, f.label, *f.value)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = sealed
+	}
+	return p, nil
+}
+
+func (r *consumerChangeRequestRepo) scan(row rowScanner) (*entity.ConsumerChangeRequest, error) {
+	var cr entity.ConsumerChangeRequest
+	var nik, legalName, birthPlace, birthDate, salary, ktpDocumentID, selfieDocumentID sql.NullString
+	var reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	var dataKey string
+	err := row.Scan(&cr.ID, &cr.ConsumerID, &nik, &legalName, &birthPlace, &birthDate, &salary, &ktpDocumentID, &selfieDocumentID,
+		&cr.Status, &reviewedBy, &cr.ReviewNote, &reviewedAt, &cr.CreatedAt, &dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataKey != "" {
+		dek, err := r.keys.UnwrapDataKey(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range []struct {
+			dst   *sql.NullString
+			label string
+		}{
+			{&nik, "nik"},
+			{&legalName, "legal_name"},
+			{&birthDate, "birth_date"},
+			{&salary, "salary"},
+		} {
+			if !f.dst.Valid {
+				continue
+			}
+			if f.dst.String, err = utils.OpenString(dek, f.label, f.dst.String); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cr.NIK = nullString(nik)
+	cr.LegalName = nullString(legalName)
+	cr.BirthPlace = nullString(birthPlace)
+	cr.BirthDate = nullString(birthDate)
+	cr.KTPDocumentID = nullString(ktpDocumentID)
+	cr.SelfieDocumentID = nullString(selfieDocumentID)
+	if salary.Valid {
+		v, err := strconv.ParseFloat(salary.String, 64)
+		if err != nil {
+			return nil, err
+		}
+		cr.Salary = &v
+	}
+	if reviewedBy.Valid {
+		id := uint64(reviewedBy.Int64)
+		cr.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		cr.ReviewedAt = &reviewedAt.Time
+	}
+	return &cr, nil
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+)
+
+func setupChangeRequestMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, ConsumerChangeRequestRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewConsumerChangeRequestRepo(db, testKeyring(t))
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+var changeRequestRowColumns = []string{"id", "consumer_id", "nik", "legal_name", "birth_place", "birth_date", "salary", "ktp_document_id", "selfie_document_id",
+	"status", "reviewed_by", "review_note", "reviewed_at", "created_at", "pii_key"}
+
+func TestConsumerChangeRequestRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupChangeRequestMockDB(t)
+	defer cleanup()
+
+	salary := 8000000.0
+	cr := &entity.ConsumerChangeRequest{ConsumerID: 10, Salary: &salary}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO consumer_change_requests
+		(consumer_id, nik, legal_name, birth_place, birth_date, salary, ktp_document_id, selfie_document_id, status, created_at, pii_key, key_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(10), nil, nil, nil, nil, sealedArg{"8000000"}, nil, nil, entity.ChangeRequestPending, sqlmock.AnyArg(), sqlmock.AnyArg(), "k2").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.Create(context.Background(), tx, cr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerChangeRequestRepo_FindPendingByConsumer(t *testing.T) {
+	_, mock, repo, cleanup := setupChangeRequestMockDB(t)
+	defer cleanup()
+
+	keys := testKeyring(t)
+	dek, wrapped, err := keys.NewDataKey()
+	require.NoError(t, err)
+	legalName, err := utils.SealString(dek, "legal_name", "BUDI S")
+	require.NoError(t, err)
+	salary, err := utils.SealString(dek, "salary", "8000000")
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows(changeRequestRowColumns).
+		AddRow(3, 10, nil, legalName, nil, nil, salary, nil, nil, "PENDING", nil, "", nil, time.Now(), wrapped)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		FROM consumer_change_requests WHERE consumer_id = ? AND status = ?
+		ORDER BY id DESC LIMIT 1`)).
+		WithArgs(uint64(10), entity.ChangeRequestPending).
+		WillReturnRows(rows)
+
+	cr, err := repo.FindPendingByConsumer(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Nil(t, cr.NIK)
+	assert.Equal(t, "BUDI S", *cr.LegalName)
+	assert.Equal(t, 8000000.0, *cr.Salary)
+	assert.Nil(t, cr.ReviewedBy)
+	assert.Equal(t, entity.ChangeRequestPending, cr.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerChangeRequestRepo_AnonymiseByConsumer(t *testing.T) {
+	db, mock, repo, cleanup := setupChangeRequestMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumer_change_requests
+		SET nik = NULL, legal_name = NULL, birth_place = NULL, birth_date = NULL, salary = NULL,
+		    ktp_document_id = NULL, selfie_document_id = NULL, pii_key = '', key_version = ?
+		WHERE consumer_id = ?`)).
+		WithArgs("k2", uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.AnonymiseByConsumer(context.Background(), tx, 10)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerChangeRequestRepo_ReadsPlaintextRows(t *testing.T) {
+	_, mock, repo, cleanup := setupChangeRequestMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows(changeRequestRowColumns).
+		AddRow(3, 10, "3173010101900001", nil, nil, nil, "8000000.00", nil, nil, "APPROVED", 2, "ok", time.Now(), time.Now(), "")
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM consumer_change_requests WHERE consumer_id = ? ORDER BY id`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	list, err := repo.ListByConsumer(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "3173010101900001", *list[0].NIK)
+	assert.Equal(t, 8000000.0, *list[0].Salary)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerChangeRequestRepo_UpdatePII(t *testing.T) {
+	db, mock, repo, cleanup := setupChangeRequestMockDB(t)
+	defer cleanup()
+
+	nik := "3173010101900001"
+	cr := &entity.ConsumerChangeRequest{ID: 3, ConsumerID: 10, NIK: &nik}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumer_change_requests
+		SET nik = ?, legal_name = ?, birth_date = ?, salary = ?, pii_key = ?, key_version = ?
+		WHERE id = ?`)).
+		WithArgs(sealedArg{nik}, nil, nil, nil, sqlmock.AnyArg(), "k2", uint64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.UpdatePII(context.Background(), tx, cr)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerChangeRequestRepo_ListIDsForReencryption(t *testing.T) {
+	db, mock, repo, cleanup := setupChangeRequestMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id FROM consumer_change_requests cr
+		WHERE key_version <> ?
+		  AND NOT EXISTS (SELECT 1 FROM consumers c WHERE c.id = cr.consumer_id AND c.erased_at IS NOT NULL)
+		ORDER BY id LIMIT ? FOR UPDATE`)).
+		WithArgs(sqlmock.AnyArg(), 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	ids, err := repo.ListIDsForReencryption(context.Background(), tx, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{4}, ids)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -10,7 +10,21 @@ import (
 
 type ConsumerLimitRepository interface {
 	GetByConsumerAndTenor(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error)
-	UpdateUsedLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, newUsed float64) error
+	GetByConsumerAndTenorForUpdate(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error)
+	GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.ConsumerLimit, error)
+	AddUsedLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error)
+	AddHeldLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error)
+	ReleaseHeldLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error
+	ConvertHeldLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error
+	ReleaseUsedLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error
+	ActivateByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerLimit, error)
+	ListConsumerIDs(ctx context.Context, afterID uint64, limit int) ([]uint64, error)
+	ListConsumerIDsBehindPolicy(ctx context.Context, version uint32, afterID uint64, limit int) ([]uint64, error)
+	UpdateMaxLimit(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, policyVersion uint32) error
+	OverrideMaxLimit(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, overrideID uint64) error
+	ClearOverride(ctx context.Context, tx *sql.Tx, id uint64) error
+	HasOutstanding(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error)
 }
 
 type consumerLimitRepo struct {
@@ -23,17 +37,219 @@ func NewConsumerLimitRepo(db *sql.DB) ConsumerLimitRepository {
 
 func (r *consumerLimitRepo) GetByConsumerAndTenor(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
 	row := r.db.QueryRowContext(ctx, `
-        SELECT id, consumer_id, tenor_month, max_limit, used_limit, created_at, updated_at
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
         FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ?`, consumerID, tenor)
+	return scanConsumerLimit(row)
+}
 
-	var cl entity.ConsumerLimit
-	if err := row.Scan(&cl.ID, &cl.ConsumerID, &cl.TenorMonth, &cl.MaxLimit, &cl.UsedLimit, &cl.CreatedAt, &cl.UpdatedAt); err != nil {
+func (r *consumerLimitRepo) GetByConsumerAndTenorForUpdate(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+	row := tx.QueryRowContext(ctx, `
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
+        FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ? FOR UPDATE`, consumerID, tenor)
+	return scanConsumerLimit(row)
+}
+
+func (r *consumerLimitRepo) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.ConsumerLimit, error) {
+	row := tx.QueryRowContext(ctx, `
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
+        FROM consumer_limits WHERE id = ? FOR UPDATE`, id)
+	return scanConsumerLimit(row)
+}
+
+func (r *consumerLimitRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerLimit, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
+        FROM consumer_limits WHERE consumer_id = ? ORDER BY tenor_month`, consumerID)
+	if err != nil {
 		return nil, err
 	}
-	return &cl, nil
+	defer rows.Close()
+
+	var res []*entity.ConsumerLimit
+	for rows.Next() {
+		cl, err := scanConsumerLimit(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cl)
+	}
+	return res, rows.Err()
+}
+
+// ListConsumerIDs pages through the consumers that have limits, in ID order,
+// starting after afterID.
+func (r *consumerLimitRepo) ListConsumerIDs(ctx context.Context, afterID uint64, limit int) ([]uint64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DISTINCT consumer_id FROM consumer_limits WHERE consumer_id > ? ORDER BY consumer_id LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListConsumerIDsBehindPolicy pages, like ListConsumerIDs, through the
+// consumers with a limit that policy version did not set. Overridden limits
+// are not counted; they keep their override until staff clear it.
+func (r *consumerLimitRepo) ListConsumerIDsBehindPolicy(ctx context.Context, version uint32, afterID uint64, limit int) ([]uint64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT DISTINCT consumer_id FROM consumer_limits
+        WHERE consumer_id > ? AND override_request_id IS NULL AND (policy_version IS NULL OR policy_version <> ?)
+        ORDER BY consumer_id LIMIT ?`, afterID, version, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// HasOutstanding reports whether any limit of the consumer still carries
+// unpaid principal, i.e. the consumer has an open contract. The limits are
+// locked so no purchase can slip in before the caller commits.
+func (r *consumerLimitRepo) HasOutstanding(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT used_limit FROM consumer_limits WHERE consumer_id = ? FOR UPDATE`, consumerID)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	outstanding := false
+	for rows.Next() {
+		var used float64
+		if err := rows.Scan(&used); err != nil {
+			return false, err
+		}
+		if used > 0 {
+			outstanding = true
+		}
+	}
+	return outstanding, rows.Err()
+}
+
+// AddUsedLimit adds amount to the used limit in a single conditional
+// statement and reports whether it did. Nothing changes when the limit is
+// missing or inactive or amount does not fit next to what is used and held,
+// so concurrent callers can never push used_limit past max_limit.
+func (r *consumerLimitRepo) AddUsedLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE consumer_limits SET used_limit = used_limit + ?, updated_at = ?
+		WHERE consumer_id = ? AND tenor_month = ? AND active = 1 AND used_limit + held_limit + ? <= max_limit`,
+		amount, time.Now().UTC(), consumerID, tenor, amount,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
 }
 
-func (r *consumerLimitRepo) UpdateUsedLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, newUsed float64) error {
-	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET used_limit = ?, updated_at = ? WHERE consumer_id = ? AND tenor_month = ?`, newUsed, time.Now().UTC(), consumerID, tenor)
+// AddHeldLimit reserves amount for a limit hold under the same conditions as
+// AddUsedLimit and reports whether it did.
+func (r *consumerLimitRepo) AddHeldLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE consumer_limits SET held_limit = held_limit + ?, updated_at = ?
+		WHERE consumer_id = ? AND tenor_month = ? AND active = 1 AND used_limit + held_limit + ? <= max_limit`,
+		amount, time.Now().UTC(), consumerID, tenor, amount,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseHeldLimit gives a held amount back to the available limit.
+func (r *consumerLimitRepo) ReleaseHeldLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET held_limit = held_limit - ?, updated_at = ? WHERE id = ?`,
+		amount, time.Now().UTC(), id)
+	return err
+}
+
+// ConvertHeldLimit moves a held amount to the used limit. The amount already
+// counted against max_limit, so this cannot overdraw the limit.
+func (r *consumerLimitRepo) ConvertHeldLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE consumer_limits SET held_limit = held_limit - ?, used_limit = used_limit + ?, updated_at = ?
+		WHERE id = ?`,
+		amount, amount, time.Now().UTC(), id)
+	return err
+}
+
+// ReleaseUsedLimit gives repaid credit back to the limit. Callers lock the
+// row first and never release more than is used.
+func (r *consumerLimitRepo) ReleaseUsedLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET used_limit = used_limit - ?, updated_at = ? WHERE id = ?`,
+		amount, time.Now().UTC(), id)
+	return err
+}
+
+// UpdateMaxLimit sets a recomputed max limit and the policy version that
+// produced it.
+func (r *consumerLimitRepo) UpdateMaxLimit(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, policyVersion uint32) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET max_limit = ?, policy_version = ?, updated_at = ? WHERE id = ?`,
+		maxLimit, policyVersion, time.Now().UTC(), id)
+	return err
+}
+
+// OverrideMaxLimit sets a max limit approved by staff and remembers the
+// override request it came from.
+func (r *consumerLimitRepo) OverrideMaxLimit(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, overrideID uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET max_limit = ?, override_request_id = ?, updated_at = ? WHERE id = ?`,
+		maxLimit, overrideID, time.Now().UTC(), id)
+	return err
+}
+
+// ClearOverride hands the limit back to recalculation. The max limit is left
+// as it is until the next recalculation sets it.
+func (r *consumerLimitRepo) ClearOverride(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET override_request_id = NULL, updated_at = ? WHERE id = ?`,
+		time.Now().UTC(), id)
 	return err
 }
+
+// ActivateByConsumer makes every limit of the consumer usable for purchases.
+func (r *consumerLimitRepo) ActivateByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET active = 1, updated_at = ? WHERE consumer_id = ?`, time.Now().UTC(), consumerID)
+	return err
+}
+
+func scanConsumerLimit(row rowScanner) (*entity.ConsumerLimit, error) {
+	var cl entity.ConsumerLimit
+	var policyVersion, overrideID sql.NullInt64
+	if err := row.Scan(&cl.ID, &cl.ConsumerID, &cl.TenorMonth, &cl.MaxLimit, &cl.UsedLimit, &cl.HeldLimit, &cl.Active, &policyVersion, &overrideID, &cl.CreatedAt, &cl.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if policyVersion.Valid {
+		v := uint32(policyVersion.Int64)
+		cl.PolicyVersion = &v
+	}
+	if overrideID.Valid {
+		id := uint64(overrideID.Int64)
+		cl.OverrideID = &id
+	}
+	return &cl, nil
+}
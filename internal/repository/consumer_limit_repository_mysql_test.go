@@ -0,0 +1,106 @@
+//go:build mysql
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run with: MYSQL_TEST_DSN='user:pass@tcp(127.0.0.1:3306)/scratch' go test -tags mysql ./internal/repository/
+// The DSN must point at a throwaway database; the test creates and drops its
+// own consumer_limits table there.
+func setupMySQLConsumerLimitRepo(t *testing.T) (*sql.DB, ConsumerLimitRepository) {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN is not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(50)
+
+	_, err = db.Exec(`DROP TABLE IF EXISTS consumer_limits`)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		CREATE TABLE consumer_limits (
+			id bigint unsigned NOT NULL AUTO_INCREMENT,
+			consumer_id bigint unsigned NOT NULL,
+			tenor_month tinyint unsigned NOT NULL,
+			max_limit decimal(15,2) NOT NULL,
+			used_limit decimal(15,2) NOT NULL DEFAULT '0.00',
+			held_limit decimal(15,2) NOT NULL DEFAULT '0.00',
+			active tinyint(1) NOT NULL DEFAULT 1,
+			created_at timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY uk_consumer_tenor (consumer_id, tenor_month)
+		) ENGINE=InnoDB`)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS consumer_limits`)
+		db.Close()
+	})
+	return db, NewConsumerLimitRepo(db)
+}
+
+func TestConsumerLimitRepo_AddUsedLimit_ConcurrentMySQL(t *testing.T) {
+	db, repo := setupMySQLConsumerLimitRepo(t)
+	ctx := context.Background()
+
+	_, err := db.Exec(`INSERT INTO consumer_limits (consumer_id, tenor_month, max_limit) VALUES (1, 3, 1000)`)
+	require.NoError(t, err)
+
+	const workers = 200
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		granted int
+		errs    []error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := addUsedLimitTx(ctx, db, repo, 30)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			if ok {
+				granted++
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Empty(t, errs)
+	assert.Equal(t, 33, granted)
+
+	var used float64
+	require.NoError(t, db.QueryRow(`SELECT used_limit FROM consumer_limits WHERE consumer_id = 1 AND tenor_month = 3`).Scan(&used))
+	assert.Equal(t, 990.0, used)
+}
+
+func addUsedLimitTx(ctx context.Context, db *sql.DB, repo ConsumerLimitRepository, amount float64) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	ok, err := repo.AddUsedLimit(ctx, tx, 1, 3, amount)
+	if err != nil {
+		return false, err
+	}
+	return ok, tx.Commit()
+}
@@ -35,11 +35,11 @@ func TestConsumerLimitRepo_GetByConsumerAndTenor_Success(t *testing.T) {
 	now := time.Now()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "consumer_id", "tenor_month", "max_limit", "used_limit", "created_at", "updated_at",
-	}).AddRow(1, 10, 3, 10000000.0, 2000000.0, now, now)
+		"id", "consumer_id", "tenor_month", "max_limit", "used_limit", "held_limit", "active", "policy_version", "override_request_id", "created_at", "updated_at",
+	}).AddRow(1, 10, 3, 10000000.0, 2000000.0, 500000.0, true, 1, nil, now, now)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT id, consumer_id, tenor_month, max_limit, used_limit, created_at, updated_at
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
         FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ?`)).
 		WithArgs(uint64(10), uint8(3)).
 		WillReturnRows(rows)
@@ -52,6 +52,9 @@ func TestConsumerLimitRepo_GetByConsumerAndTenor_Success(t *testing.T) {
 	assert.Equal(t, uint8(3), cl.TenorMonth)
 	assert.Equal(t, 10000000.0, cl.MaxLimit)
 	assert.Equal(t, 2000000.0, cl.UsedLimit)
+	assert.Equal(t, 500000.0, cl.HeldLimit)
+	assert.True(t, cl.Active)
+	assert.Equal(t, uint32(1), *cl.PolicyVersion)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -63,7 +66,7 @@ func TestConsumerLimitRepo_GetByConsumerAndTenor_NotFound(t *testing.T) {
 	ctx := context.Background()
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT id, consumer_id, tenor_month, max_limit, used_limit, created_at, updated_at
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
         FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ?`)).
 		WithArgs(uint64(99), uint8(6)).
 		WillReturnError(sql.ErrNoRows)
@@ -77,48 +80,298 @@ func TestConsumerLimitRepo_GetByConsumerAndTenor_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestConsumerLimitRepo_UpdateUsedLimit_Success(t *testing.T) {
+func TestConsumerLimitRepo_AddUsedLimit_Success(t *testing.T) {
 	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
 	defer cleanup()
 
 	ctx := context.Background()
 
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(
-		`UPDATE consumer_limits SET used_limit = ?, updated_at = ? WHERE consumer_id = ? AND tenor_month = ?`,
-	)).
-		WithArgs(3000000.0, sqlmock.AnyArg(), uint64(10), uint8(3)).
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumer_limits SET used_limit = used_limit + ?, updated_at = ?
+		WHERE consumer_id = ? AND tenor_month = ? AND active = 1 AND used_limit + held_limit + ? <= max_limit`)).
+		WithArgs(3000000.0, sqlmock.AnyArg(), uint64(10), uint8(3), 3000000.0).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
 	tx, _ := db.Begin()
 
-	err := repo.UpdateUsedLimit(ctx, tx, 10, 3, 3000000.0)
+	added, err := repo.AddUsedLimit(ctx, tx, 10, 3, 3000000.0)
 	assert.NoError(t, err)
+	assert.True(t, added)
 
 	tx.Commit()
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestConsumerLimitRepo_UpdateUsedLimit_Error(t *testing.T) {
+func TestConsumerLimitRepo_AddUsedLimit_DoesNotFit(t *testing.T) {
 	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
 	defer cleanup()
 
 	ctx := context.Background()
 
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(
-		`UPDATE consumer_limits SET used_limit = ?, updated_at = ? WHERE consumer_id = ? AND tenor_month = ?`,
-	)).
-		WithArgs(3000000.0, sqlmock.AnyArg(), uint64(10), uint8(3)).
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET used_limit = used_limit + ?`)).
+		WithArgs(3000000.0, sqlmock.AnyArg(), uint64(10), uint8(3), 3000000.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	tx, _ := db.Begin()
+
+	added, err := repo.AddUsedLimit(ctx, tx, 10, 3, 3000000.0)
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	tx.Rollback()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_AddUsedLimit_Error(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET used_limit = used_limit + ?`)).
+		WithArgs(3000000.0, sqlmock.AnyArg(), uint64(10), uint8(3), 3000000.0).
 		WillReturnError(sql.ErrConnDone)
 	mock.ExpectRollback()
 
 	tx, _ := db.Begin()
 
-	err := repo.UpdateUsedLimit(ctx, tx, 10, 3, 3000000.0)
+	_, err := repo.AddUsedLimit(ctx, tx, 10, 3, 3000000.0)
 	assert.Error(t, err)
 
 	tx.Rollback()
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestConsumerLimitRepo_AddHeldLimit(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumer_limits SET held_limit = held_limit + ?, updated_at = ?
+		WHERE consumer_id = ? AND tenor_month = ? AND active = 1 AND used_limit + held_limit + ? <= max_limit`)).
+		WithArgs(750000.0, sqlmock.AnyArg(), uint64(10), uint8(3), 750000.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET held_limit = held_limit + ?`)).
+		WithArgs(9000000.0, sqlmock.AnyArg(), uint64(10), uint8(3), 9000000.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	held, err := repo.AddHeldLimit(context.Background(), tx, 10, 3, 750000.0)
+	assert.NoError(t, err)
+	assert.True(t, held)
+
+	held, err = repo.AddHeldLimit(context.Background(), tx, 10, 3, 9000000.0)
+	assert.NoError(t, err)
+	assert.False(t, held)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ReleaseAndConvertHeldLimit(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET held_limit = held_limit - ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(750000.0, sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumer_limits SET held_limit = held_limit - ?, used_limit = used_limit + ?, updated_at = ?
+		WHERE id = ?`)).
+		WithArgs(500000.0, 500000.0, sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.ReleaseHeldLimit(context.Background(), tx, 4, 750000.0))
+	assert.NoError(t, repo.ConvertHeldLimit(context.Background(), tx, 4, 500000.0))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ReleaseUsedLimit(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM consumer_limits WHERE id = ? FOR UPDATE`)).
+		WithArgs(uint64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "consumer_id", "tenor_month", "max_limit", "used_limit", "held_limit", "active", "policy_version", "override_request_id", "created_at", "updated_at",
+		}).AddRow(4, 10, 3, 6000000.0, 1500000.0, 0.0, true, 1, nil, now, now))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET used_limit = used_limit - ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(500000.0, sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	cl, err := repo.GetByIDForUpdate(context.Background(), tx, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 1500000.0, cl.UsedLimit)
+	assert.NoError(t, repo.ReleaseUsedLimit(context.Background(), tx, 4, 500000.0))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_UpdateMaxLimit(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET max_limit = ?, policy_version = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(7200000.0, uint32(2), sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.UpdateMaxLimit(context.Background(), tx, 4, 7200000.0, 2))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_OverrideMaxLimit(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET max_limit = ?, override_request_id = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(9000000.0, uint64(7), sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.OverrideMaxLimit(context.Background(), tx, 4, 9000000.0, 7))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ClearOverride(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET override_request_id = NULL, updated_at = ? WHERE id = ?`)).
+		WithArgs(sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.ClearOverride(context.Background(), tx, 4))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ListConsumerIDs(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT consumer_id FROM consumer_limits WHERE consumer_id > ? ORDER BY consumer_id LIMIT ?`)).
+		WithArgs(uint64(17), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"consumer_id"}).AddRow(18).AddRow(21))
+
+	ids, err := repo.ListConsumerIDs(context.Background(), 17, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{18, 21}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ListConsumerIDsBehindPolicy(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT consumer_id FROM consumer_limits
+        WHERE consumer_id > ? AND override_request_id IS NULL AND (policy_version IS NULL OR policy_version <> ?)
+        ORDER BY consumer_id LIMIT ?`)).
+		WithArgs(uint64(0), uint32(3), 200).
+		WillReturnRows(sqlmock.NewRows([]string{"consumer_id"}).AddRow(17))
+
+	ids, err := repo.ListConsumerIDsBehindPolicy(context.Background(), 3, 0, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{17}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ActivateByConsumer(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumer_limits SET active = 1, updated_at = ? WHERE consumer_id = ?`)).
+		WithArgs(sqlmock.AnyArg(), uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.ActivateByConsumer(context.Background(), tx, 10)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_ListByConsumer(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "consumer_id", "tenor_month", "max_limit", "used_limit", "held_limit", "active", "policy_version", "override_request_id", "created_at", "updated_at",
+	}).
+		AddRow(1, 10, 1, 2000000.0, 0.0, 0.0, true, nil, nil, now, now).
+		AddRow(2, 10, 3, 6000000.0, 1500000.0, 0.0, true, 2, 7, now, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+        SELECT id, consumer_id, tenor_month, max_limit, used_limit, held_limit, active, policy_version, override_request_id, created_at, updated_at
+        FROM consumer_limits WHERE consumer_id = ? ORDER BY tenor_month`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	limits, err := repo.ListByConsumer(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, limits, 2)
+	assert.Equal(t, uint8(3), limits[1].TenorMonth)
+	assert.Equal(t, 1500000.0, limits[1].UsedLimit)
+	assert.Nil(t, limits[0].PolicyVersion)
+	assert.Nil(t, limits[0].OverrideID)
+	assert.Equal(t, uint64(7), *limits[1].OverrideID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerLimitRepo_HasOutstanding(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerLimitMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT used_limit FROM consumer_limits WHERE consumer_id = ? FOR UPDATE`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"used_limit"}).AddRow(0.0).AddRow(1500000.0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT used_limit FROM consumer_limits WHERE consumer_id = ? FOR UPDATE`)).
+		WithArgs(uint64(11)).
+		WillReturnRows(sqlmock.NewRows([]string{"used_limit"}).AddRow(0.0).AddRow(0.0))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	open, err := repo.HasOutstanding(context.Background(), tx, 10)
+	assert.NoError(t, err)
+	assert.True(t, open)
+
+	open, err = repo.HasOutstanding(context.Background(), tx, 11)
+	assert.NoError(t, err)
+	assert.False(t, open)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
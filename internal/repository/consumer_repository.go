@@ -3,37 +3,77 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"time"
 
 	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
 )
 
 type ConsumerRepository interface {
 	Create(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error)
+	FindByID(ctx context.Context, id uint64) (*entity.Consumer, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.Consumer, error)
+	Update(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error
+	ExistsByNIK(ctx context.Context, tx *sql.Tx, nik string) (bool, error)
+	UpdateKYCStatus(ctx context.Context, tx *sql.Tx, id uint64, status entity.KYCStatus) error
+	ListByKYCStatus(ctx context.Context, status entity.KYCStatus) ([]*entity.Consumer, error)
+	ListIDsForReencryption(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error)
+	FindStatus(ctx context.Context, id uint64) (entity.ConsumerStatus, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id uint64, status entity.ConsumerStatus, reason string) error
+	Anonymise(ctx context.Context, tx *sql.Tx, id uint64) error
+	UpdateRiskGrade(ctx context.Context, tx *sql.Tx, id uint64, grade entity.RiskGrade) error
 }
 
-type consumerRepo struct{}
+// consumerRepo encrypts NIK, legal name, birth date and salary with a per-row
+// data key wrapped by the keyring (see utils.Keyring). Rows written before
+// encryption have an empty pii_key and are read as plaintext until the
+// re-encryption command moves them to the current key.
+type consumerRepo struct {
+	db   *sql.DB
+	keys *utils.Keyring
+}
+
+func NewConsumerRepo(db *sql.DB, keys *utils.Keyring) ConsumerRepository {
+	return &consumerRepo{db, keys}
+}
+
+const consumerColumns = `id, nik, full_name, legal_name, birth_place, birth_date, ktp_document_id, selfie_document_id, salary, kyc_status, risk_grade,
+		status, status_reason, status_changed_at, erased_at, pii_key`
 
-func NewConsumerRepo() ConsumerRepository {
-	return &consumerRepo{}
+// sealedPII holds the column values of the encrypted fields. nikIndex is NULL
+// for an empty NIK, since the column is unique.
+type sealedPII struct {
+	nik, legalName, birthDate, salary string
+	nikIndex                          sql.NullString
+	dataKey, keyVersion               string
 }
+
 func (r *consumerRepo) Create(
 	ctx context.Context,
 	tx *sql.Tx,
 	c *entity.Consumer,
 ) (uint64, error) {
+	p, err := r.seal(c)
+	if err != nil {
+		return 0, err
+	}
 
 	res, err := tx.ExecContext(ctx, `
 		INSERT INTO consumers
-		(nik, full_name, legal_name, birth_place, birth_date,ktp_photo, selfie_photo, salary)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		c.NIK,
+		(nik, nik_index, full_name, legal_name, birth_place, birth_date, ktp_document_id, selfie_document_id, salary, pii_key, key_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.nik,
+		p.nikIndex,
 		c.FullName,
-		c.LegalName,
+		p.legalName,
 		c.BirthPlace,
-		c.BirthDate,
-		c.KTPPhoto,
-		c.SelfiePhoto,
-		c.Salary,
+		p.birthDate,
+		c.KTPDocumentID,
+		c.SelfieDocumentID,
+		p.salary,
+		p.dataKey,
+		p.keyVersion,
 	)
 	if err != nil {
 		return 0, err
@@ -46,3 +86,200 @@ func (r *consumerRepo) Create(
 
 	return uint64(lastID), nil
 }
+
+func (r *consumerRepo) FindByID(ctx context.Context, id uint64) (*entity.Consumer, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+consumerColumns+`
+		FROM consumers WHERE id = ?`, id)
+	return r.scan(row)
+}
+
+func (r *consumerRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.Consumer, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+consumerColumns+`
+		FROM consumers WHERE id = ? FOR UPDATE`, id)
+	return r.scan(row)
+}
+
+// Update rewrites the row under a fresh data key wrapped with the current key
+// version.
+func (r *consumerRepo) Update(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error {
+	p, err := r.seal(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE consumers
+		SET nik = ?, nik_index = ?, full_name = ?, legal_name = ?, birth_place = ?, birth_date = ?,
+		    ktp_document_id = ?, selfie_document_id = ?, salary = ?, pii_key = ?, key_version = ?
+		WHERE id = ?`,
+		p.nik, p.nikIndex, c.FullName, p.legalName, c.BirthPlace, p.birthDate,
+		c.KTPDocumentID, c.SelfieDocumentID, p.salary, p.dataKey, p.keyVersion, c.ID,
+	)
+	return err
+}
+
+// ExistsByNIK looks the NIK up by its blind index, and by value among rows not
+// yet encrypted.
+func (r *consumerRepo) ExistsByNIK(ctx context.Context, tx *sql.Tx, nik string) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM consumers WHERE nik_index = ? OR (pii_key = '' AND nik = ?))`,
+		r.keys.BlindIndex(nik), nik,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (r *consumerRepo) UpdateKYCStatus(ctx context.Context, tx *sql.Tx, id uint64, status entity.KYCStatus) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumers SET kyc_status = ? WHERE id = ?`, status, id)
+	return err
+}
+
+// FindStatus reads only the status, for checks on every request that do not
+// need the decrypted profile.
+func (r *consumerRepo) FindStatus(ctx context.Context, id uint64) (entity.ConsumerStatus, error) {
+	var status entity.ConsumerStatus
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM consumers WHERE id = ?`, id).Scan(&status)
+	return status, err
+}
+
+func (r *consumerRepo) UpdateStatus(ctx context.Context, tx *sql.Tx, id uint64, status entity.ConsumerStatus, reason string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE consumers SET status = ?, status_reason = ?, status_changed_at = ? WHERE id = ?`,
+		status, reason, time.Now().UTC(), id,
+	)
+	return err
+}
+
+func (r *consumerRepo) UpdateRiskGrade(ctx context.Context, tx *sql.Tx, id uint64, grade entity.RiskGrade) error {
+	_, err := tx.ExecContext(ctx, `UPDATE consumers SET risk_grade = ? WHERE id = ?`, grade, id)
+	return err
+}
+
+// Anonymise blanks every personal field of the consumer, including the legacy
+// ktp_photo and selfie_photo values of consumers registered before document
+// uploads. The row itself stays because limits and transactions still
+// reference it.
+func (r *consumerRepo) Anonymise(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE consumers SET nik = '', nik_index = NULL, full_name = '', legal_name = '', birth_place = '', birth_date = '',
+		       ktp_photo = '', selfie_photo = '', ktp_document_id = '', selfie_document_id = '',
+		       salary = '0', pii_key = '', key_version = ?, erased_at = ?
+		WHERE id = ?`,
+		r.keys.Version(), time.Now().UTC(), id,
+	)
+	return err
+}
+
+func (r *consumerRepo) ListByKYCStatus(ctx context.Context, status entity.KYCStatus) ([]*entity.Consumer, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+consumerColumns+`
+		FROM consumers WHERE kyc_status = ? ORDER BY id`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.Consumer
+	for rows.Next() {
+		c, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// ListIDsForReencryption locks up to limit rows that are still plaintext or
+// wrapped with an older key version. Erased consumers hold nothing to encrypt
+// and are skipped whatever their key version.
+func (r *consumerRepo) ListIDsForReencryption(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM consumers WHERE key_version <> ? AND erased_at IS NULL ORDER BY id LIMIT ? FOR UPDATE`,
+		r.keys.Version(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *consumerRepo) seal(c *entity.Consumer) (*sealedPII, error) {
+	dek, wrapped, err := r.keys.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &sealedPII{dataKey: wrapped, keyVersion: r.keys.Version()}
+	if c.NIK != "" {
+		p.nikIndex = sql.NullString{String: r.keys.BlindIndex(c.NIK), Valid: true}
+	}
+	for _, f := range []struct {
+		dst   *string
+		label string
+		value string
+	}{
+		{&p.nik, "nik", c.NIK},
+		{&p.legalName, "legal_name", c.LegalName},
+		{&p.birthDate, "birth_date", c.BirthDate},
+		{&p.salary, "salary", strconv.FormatFloat(c.Salary, 'f', -1, 64)},
+	} {
+		if *f.dst, err = utils.SealString(dek, f.label, f.value); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (r *consumerRepo) scan(row rowScanner) (*entity.Consumer, error) {
+	var c entity.Consumer
+	var salary, dataKey string
+	var statusChangedAt, erasedAt sql.NullTime
+	err := row.Scan(&c.ID, &c.NIK, &c.FullName, &c.LegalName, &c.BirthPlace, &c.BirthDate, &c.KTPDocumentID, &c.SelfieDocumentID, &salary, &c.KYCStatus, &c.RiskGrade,
+		&c.Status, &c.StatusReason, &statusChangedAt, &erasedAt, &dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if statusChangedAt.Valid {
+		c.StatusChangedAt = &statusChangedAt.Time
+	}
+	if erasedAt.Valid {
+		c.ErasedAt = &erasedAt.Time
+	}
+
+	if dataKey != "" {
+		dek, err := r.keys.UnwrapDataKey(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range []struct {
+			dst   *string
+			label string
+		}{
+			{&c.NIK, "nik"},
+			{&c.LegalName, "legal_name"},
+			{&c.BirthDate, "birth_date"},
+			{&salary, "salary"},
+		} {
+			if *f.dst, err = utils.OpenString(dek, f.label, *f.dst); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if c.Salary, err = strconv.ParseFloat(salary, 64); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -3,23 +3,36 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
 )
 
+func testKeyring(t *testing.T) *utils.Keyring {
+	keys, err := utils.NewKeyring("k2", map[string][]byte{
+		"k1": []byte("old-pii-key-0123456789abcdef0123"),
+		"k2": []byte("new-pii-key-0123456789abcdef0123"),
+	}, []byte("pii-index-key-0123456789abcdef01"))
+	require.NoError(t, err)
+	return keys
+}
+
 func setupConsumerMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, ConsumerRepository, func()) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to open sqlmock db: %v", err)
 	}
 
-	repo := NewConsumerRepo()
+	repo := NewConsumerRepo(db, testKeyring(t))
 
 	cleanup := func() {
 		db.Close()
@@ -28,6 +41,39 @@ func setupConsumerMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, ConsumerReposi
 	return db, mock, repo, cleanup
 }
 
+// sealedArg matches a ciphertext argument that does not reveal plain.
+type sealedArg struct {
+	plain string
+}
+
+func (a sealedArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && s != "" && !strings.Contains(s, a.plain)
+}
+
+// sealedConsumerRow encrypts c the way consumerRepo stores it, under key
+// version k1.
+func sealedConsumerRow(t *testing.T, c *entity.Consumer) []driver.Value {
+	old, err := utils.NewKeyring("k1", map[string][]byte{"k1": []byte("old-pii-key-0123456789abcdef0123")}, []byte("pii-index-key-0123456789abcdef01"))
+	require.NoError(t, err)
+	dek, wrapped, err := old.NewDataKey()
+	require.NoError(t, err)
+
+	seal := func(label, v string) string {
+		s, err := utils.SealString(dek, label, v)
+		require.NoError(t, err)
+		return s
+	}
+	return []driver.Value{
+		c.ID, seal("nik", c.NIK), c.FullName, seal("legal_name", c.LegalName), c.BirthPlace, seal("birth_date", c.BirthDate),
+		c.KTPDocumentID, c.SelfieDocumentID, seal("salary", "5000000"), string(c.KYCStatus), string(c.RiskGrade),
+		string(c.Status), c.StatusReason, c.StatusChangedAt, c.ErasedAt, wrapped,
+	}
+}
+
+var consumerRowColumns = []string{"id", "nik", "full_name", "legal_name", "birth_place", "birth_date", "ktp_document_id", "selfie_document_id", "salary", "kyc_status", "risk_grade",
+	"status", "status_reason", "status_changed_at", "erased_at", "pii_key"}
+
 func TestConsumerRepo_Create_Success(t *testing.T) {
 	db, mock, repo, cleanup := setupConsumerMockDB(t)
 	defer cleanup()
@@ -37,30 +83,33 @@ func TestConsumerRepo_Create_Success(t *testing.T) {
 	now := time.Now()
 
 	consumer := &entity.Consumer{
-		NIK:         "3173010101010001",
-		FullName:    "Budi Santoso",
-		LegalName:   "BUDI SANTOSO",
-		BirthPlace:  "Jakarta",
-		BirthDate:   now.Format(time.RFC3339),
-		KTPPhoto:    "ktp.jpg",
-		SelfiePhoto: "selfie.jpg",
-		Salary:      5000000,
+		NIK:              "3173010101010001",
+		FullName:         "Budi Santoso",
+		LegalName:        "BUDI SANTOSO",
+		BirthPlace:       "Jakarta",
+		BirthDate:        now.Format(time.RFC3339),
+		KTPDocumentID:    "doc_ktp1",
+		SelfieDocumentID: "doc_selfie1",
+		Salary:           5000000,
 	}
 
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`
 		INSERT INTO consumers
-		(nik, full_name, legal_name, birth_place, birth_date,ktp_photo, selfie_photo, salary)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)).
+		(nik, nik_index, full_name, legal_name, birth_place, birth_date, ktp_document_id, selfie_document_id, salary, pii_key, key_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)).
 		WithArgs(
-			consumer.NIK,
+			sealedArg{consumer.NIK},
+			testKeyring(t).BlindIndex(consumer.NIK),
 			consumer.FullName,
-			consumer.LegalName,
+			sealedArg{consumer.LegalName},
 			consumer.BirthPlace,
-			consumer.BirthDate,
-			consumer.KTPPhoto,
-			consumer.SelfiePhoto,
-			consumer.Salary,
+			sealedArg{consumer.BirthDate},
+			consumer.KTPDocumentID,
+			consumer.SelfieDocumentID,
+			sealedArg{"5000000"},
+			sqlmock.AnyArg(),
+			"k2",
 		).
 		WillReturnResult(sqlmock.NewResult(10, 1)) // ID = 10
 	mock.ExpectCommit()
@@ -83,31 +132,18 @@ func TestConsumerRepo_Create_ExecError(t *testing.T) {
 	ctx := context.Background()
 
 	consumer := &entity.Consumer{
-		NIK:         "duplicate-nik",
-		FullName:    "Test",
-		LegalName:   "TEST",
-		BirthPlace:  "Bandung",
-		BirthDate:   time.Now().Format(time.RFC3339),
-		KTPPhoto:    "ktp.jpg",
-		SelfiePhoto: "selfie.jpg",
-		Salary:      4000000,
+		NIK:              "duplicate-nik",
+		FullName:         "Test",
+		LegalName:        "TEST",
+		BirthPlace:       "Bandung",
+		BirthDate:        time.Now().Format(time.RFC3339),
+		KTPDocumentID:    "doc_ktp1",
+		SelfieDocumentID: "doc_selfie1",
+		Salary:           4000000,
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`
-		INSERT INTO consumers
-		(nik, full_name, legal_name, birth_place, birth_date,ktp_photo, selfie_photo, salary)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)).
-		WithArgs(
-			consumer.NIK,
-			consumer.FullName,
-			consumer.LegalName,
-			consumer.BirthPlace,
-			consumer.BirthDate,
-			consumer.KTPPhoto,
-			consumer.SelfiePhoto,
-			consumer.Salary,
-		).
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO consumers`)).
 		WillReturnError(sql.ErrConnDone)
 	mock.ExpectRollback()
 
@@ -129,31 +165,18 @@ func TestConsumerRepo_Create_LastInsertIdError(t *testing.T) {
 	ctx := context.Background()
 
 	consumer := &entity.Consumer{
-		NIK:         "3173010101010002",
-		FullName:    "Andi",
-		LegalName:   "ANDI",
-		BirthPlace:  "Surabaya",
-		BirthDate:   time.Now().Format(time.RFC3339),
-		KTPPhoto:    "ktp2.jpg",
-		SelfiePhoto: "selfie2.jpg",
-		Salary:      6000000,
+		NIK:              "3173010101010002",
+		FullName:         "Andi",
+		LegalName:        "ANDI",
+		BirthPlace:       "Surabaya",
+		BirthDate:        time.Now().Format(time.RFC3339),
+		KTPDocumentID:    "doc_ktp2",
+		SelfieDocumentID: "doc_selfie2",
+		Salary:           6000000,
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`
-		INSERT INTO consumers
-		(nik, full_name, legal_name, birth_place, birth_date,ktp_photo, selfie_photo, salary)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)).
-		WithArgs(
-			consumer.NIK,
-			consumer.FullName,
-			consumer.LegalName,
-			consumer.BirthPlace,
-			consumer.BirthDate,
-			consumer.KTPPhoto,
-			consumer.SelfiePhoto,
-			consumer.Salary,
-		).
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO consumers`)).
 		WillReturnResult(sqlmock.NewErrorResult(sql.ErrNoRows))
 	mock.ExpectRollback()
 
@@ -167,3 +190,212 @@ func TestConsumerRepo_Create_LastInsertIdError(t *testing.T) {
 	tx.Rollback()
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestConsumerRepo_FindByID(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	stored := &entity.Consumer{ID: 10, NIK: "3173010101900001", FullName: "Budi", LegalName: "BUDI SANTOSO", BirthPlace: "Jakarta", BirthDate: "1990-01-01", KTPDocumentID: "doc_ktp1", SelfieDocumentID: "doc_selfie1", KYCStatus: entity.KYCPendingReview, Status: entity.ConsumerActive}
+	rows := sqlmock.NewRows(consumerRowColumns).AddRow(sealedConsumerRow(t, stored)...)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, nik, full_name, legal_name, birth_place, birth_date, ktp_document_id, selfie_document_id, salary, kyc_status, risk_grade,
+		status, status_reason, status_changed_at, erased_at, pii_key
+		FROM consumers WHERE id = ?`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	c, err := repo.FindByID(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "3173010101900001", c.NIK)
+	assert.Equal(t, "BUDI SANTOSO", c.LegalName)
+	assert.Equal(t, "1990-01-01", c.BirthDate)
+	assert.Equal(t, float64(5000000), c.Salary)
+	assert.Equal(t, entity.KYCPendingReview, c.KYCStatus)
+	assert.Equal(t, entity.ConsumerActive, c.Status)
+	assert.Nil(t, c.StatusChangedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_FindByID_Plaintext(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows(consumerRowColumns).
+		AddRow(10, "3173010101900001", "Budi", "BUDI SANTOSO", "Jakarta", "1990-01-01", "", "", "5000000.00", "APPROVED", "B",
+			"SUSPENDED", "chargeback under investigation", time.Now(), nil, "")
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM consumers WHERE id = ?`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	c, err := repo.FindByID(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "3173010101900001", c.NIK)
+	assert.Equal(t, float64(5000000), c.Salary)
+	assert.Equal(t, entity.RiskGradeB, c.RiskGrade)
+	assert.Equal(t, entity.ConsumerSuspended, c.Status)
+	assert.NotNil(t, c.StatusChangedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_Update(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	c := &entity.Consumer{ID: 10, NIK: "3173010101900001", FullName: "Budi", LegalName: "BUDI SANTOSO", BirthPlace: "Jakarta", BirthDate: "1990-01-01", KTPDocumentID: "doc_ktp1", SelfieDocumentID: "doc_selfie1", Salary: 7000000}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumers
+		SET nik = ?, nik_index = ?, full_name = ?, legal_name = ?, birth_place = ?, birth_date = ?,
+		    ktp_document_id = ?, selfie_document_id = ?, salary = ?, pii_key = ?, key_version = ?
+		WHERE id = ?`)).
+		WithArgs(sealedArg{c.NIK}, testKeyring(t).BlindIndex(c.NIK), c.FullName, sealedArg{c.LegalName}, c.BirthPlace, sealedArg{c.BirthDate},
+			c.KTPDocumentID, c.SelfieDocumentID, sealedArg{"7000000"}, sqlmock.AnyArg(), "k2", c.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Update(context.Background(), tx, c)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// An empty NIK must not take a blind index, or a second such row would
+// collide on the unique nik_index.
+func TestConsumerRepo_Update_EmptyNIKHasNoIndex(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumers`)).
+		WithArgs(sqlmock.AnyArg(), nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "k2", uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Update(context.Background(), tx, &entity.Consumer{ID: 10})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_ExistsByNIK(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT EXISTS(SELECT 1 FROM consumers WHERE nik_index = ? OR (pii_key = '' AND nik = ?))`)).
+		WithArgs(testKeyring(t).BlindIndex("3173010101900001"), "3173010101900001").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	exists, err := repo.ExistsByNIK(context.Background(), tx, "3173010101900001")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_ListIDsForReencryption(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id FROM consumers WHERE key_version <> ? AND erased_at IS NULL ORDER BY id LIMIT ? FOR UPDATE`)).
+		WithArgs("k2", 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(7))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	ids, err := repo.ListIDsForReencryption(context.Background(), tx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 7}, ids)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_FindStatus(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT status FROM consumers WHERE id = ?`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("BLACKLISTED"))
+
+	status, err := repo.FindStatus(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ConsumerBlacklisted, status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_UpdateStatus(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumers SET status = ?, status_reason = ?, status_changed_at = ? WHERE id = ?`)).
+		WithArgs(entity.ConsumerSuspended, "chargeback under investigation", sqlmock.AnyArg(), uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.UpdateStatus(context.Background(), tx, 10, entity.ConsumerSuspended, "chargeback under investigation")
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_Anonymise(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE consumers SET nik = '', nik_index = NULL, full_name = '', legal_name = '', birth_place = '', birth_date = '',
+		       ktp_photo = '', selfie_photo = '', ktp_document_id = '', selfie_document_id = '',
+		       salary = '0', pii_key = '', key_version = ?, erased_at = ?
+		WHERE id = ?`)).
+		WithArgs("k2", sqlmock.AnyArg(), uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Anonymise(context.Background(), tx, 10)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerRepo_UpdateRiskGrade(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE consumers SET risk_grade = ? WHERE id = ?`)).
+		WithArgs(entity.RiskGradeC, uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.UpdateRiskGrade(context.Background(), tx, 10, entity.RiskGradeC)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type ConsumerStatusChangeRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, sc *entity.ConsumerStatusChange) error
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerStatusChange, error)
+}
+
+type consumerStatusChangeRepo struct {
+	db *sql.DB
+}
+
+func NewConsumerStatusChangeRepo(db *sql.DB) ConsumerStatusChangeRepository {
+	return &consumerStatusChangeRepo{db}
+}
+
+func (r *consumerStatusChangeRepo) Create(ctx context.Context, tx *sql.Tx, sc *entity.ConsumerStatusChange) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO consumer_status_changes (consumer_id, from_status, to_status, reason, changed_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		sc.ConsumerID, sc.FromStatus, sc.ToStatus, sc.Reason, sc.ChangedBy, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *consumerStatusChangeRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerStatusChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, consumer_id, from_status, to_status, reason, changed_by, created_at
+		FROM consumer_status_changes WHERE consumer_id = ? ORDER BY id`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.ConsumerStatusChange
+	for rows.Next() {
+		var sc entity.ConsumerStatusChange
+		if err := rows.Scan(&sc.ID, &sc.ConsumerID, &sc.FromStatus, &sc.ToStatus, &sc.Reason, &sc.ChangedBy, &sc.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &sc)
+	}
+	return res, rows.Err()
+}
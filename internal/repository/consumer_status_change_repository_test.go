@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupConsumerStatusChangeMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, ConsumerStatusChangeRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewConsumerStatusChangeRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestConsumerStatusChangeRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerStatusChangeMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO consumer_status_changes (consumer_id, from_status, to_status, reason, changed_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(10), entity.ConsumerActive, entity.ConsumerBlacklisted, "fraudulent documents", uint64(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(context.Background(), tx, &entity.ConsumerStatusChange{
+		ConsumerID: 10,
+		FromStatus: entity.ConsumerActive,
+		ToStatus:   entity.ConsumerBlacklisted,
+		Reason:     "fraudulent documents",
+		ChangedBy:  2,
+	})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerStatusChangeRepo_ListByConsumer(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerStatusChangeMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "from_status", "to_status", "reason", "changed_by", "created_at"}).
+		AddRow(1, 10, "ACTIVE", "SUSPENDED", "chargeback under investigation", 2, now).
+		AddRow(2, 10, "SUSPENDED", "ACTIVE", "chargeback resolved", 2, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, consumer_id, from_status, to_status, reason, changed_by, created_at
+		FROM consumer_status_changes WHERE consumer_id = ? ORDER BY id`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	res, err := repo.ListByConsumer(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, entity.ConsumerSuspended, res[0].ToStatus)
+	assert.Equal(t, "chargeback resolved", res[1].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
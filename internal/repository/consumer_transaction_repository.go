@@ -11,6 +11,11 @@ import (
 type ConsumerTransactionRepository interface {
 	Create(ctx context.Context, tx *sql.Tx, t *entity.Transaction) (uint64, error)
 	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Transaction, error)
+	FindByID(ctx context.Context, id uint64) (*entity.Transaction, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.Transaction, error)
+	UpdateRepayment(ctx context.Context, tx *sql.Tx, t *entity.Transaction) error
+	Summary(ctx context.Context, from, to time.Time) ([]*entity.TransactionSummary, error)
+	HasOpenByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error)
 }
 
 type consumerTransactionRepo struct {
@@ -38,9 +43,12 @@ func (r *consumerTransactionRepo) Create(ctx context.Context, tx *sql.Tx, t *ent
 	return uint64(last), nil
 }
 
+const transactionColumns = `id, contract_no, consumer_id, consumer_limit_id, asset_id, tenor_month, otr, admin_fee, jumlah_bunga, jumlah_cicilan, status,
+        amount_paid, principal_paid, closed_at, created_at`
+
 func (r *consumerTransactionRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Transaction, error) {
 	rows, err := r.db.QueryContext(ctx, `
-        SELECT id, contract_no, consumer_id, consumer_limit_id, asset_id, tenor_month, otr, admin_fee, jumlah_bunga, jumlah_cicilan, status, created_at
+        SELECT `+transactionColumns+`
         FROM consumer_transactions WHERE consumer_id = ? ORDER BY created_at DESC`, consumerID)
 	if err != nil {
 		return nil, err
@@ -49,11 +57,77 @@ func (r *consumerTransactionRepo) ListByConsumer(ctx context.Context, consumerID
 
 	var res []*entity.Transaction
 	for rows.Next() {
-		var t entity.Transaction
-		if err := rows.Scan(&t.ID, &t.ContractNo, &t.ConsumerID, &t.ConsumerLimitID, &t.AssetID, &t.TenorMonth, &t.OTR, &t.AdminFee, &t.JumlahBunga, &t.JumlahCicilan, &t.Status, &t.CreatedAt); err != nil {
+		t, err := scanTransaction(rows)
+		if err != nil {
 			return nil, err
 		}
-		res = append(res, &t)
+		res = append(res, t)
 	}
 	return res, nil
 }
+
+// HasOpenByConsumer reports whether the consumer has a contract that is
+// neither closed nor failed.
+func (r *consumerTransactionRepo) HasOpenByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+	var open bool
+	err := tx.QueryRowContext(ctx, `
+        SELECT EXISTS(SELECT 1 FROM consumer_transactions WHERE consumer_id = ? AND status NOT IN (?, ?))`,
+		consumerID, entity.TransactionClosed, entity.TransactionFailed,
+	).Scan(&open)
+	return open, err
+}
+
+func (r *consumerTransactionRepo) FindByID(ctx context.Context, id uint64) (*entity.Transaction, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+transactionColumns+` FROM consumer_transactions WHERE id = ?`, id)
+	return scanTransaction(row)
+}
+
+func (r *consumerTransactionRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.Transaction, error) {
+	row := tx.QueryRowContext(ctx, `SELECT `+transactionColumns+` FROM consumer_transactions WHERE id = ? FOR UPDATE`, id)
+	return scanTransaction(row)
+}
+
+// UpdateRepayment stores the repayment totals, status and closing time of t.
+func (r *consumerTransactionRepo) UpdateRepayment(ctx context.Context, tx *sql.Tx, t *entity.Transaction) error {
+	_, err := tx.ExecContext(ctx, `
+        UPDATE consumer_transactions SET amount_paid = ?, principal_paid = ?, status = ?, closed_at = ?
+        WHERE id = ?`,
+		t.AmountPaid, t.PrincipalPaid, t.Status, t.ClosedAt, t.ID,
+	)
+	return err
+}
+
+func (r *consumerTransactionRepo) Summary(ctx context.Context, from, to time.Time) ([]*entity.TransactionSummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT status, tenor_month, COUNT(*), COALESCE(SUM(otr), 0)
+        FROM consumer_transactions WHERE created_at >= ? AND created_at < ?
+        GROUP BY status, tenor_month ORDER BY status, tenor_month`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.TransactionSummary
+	for rows.Next() {
+		var s entity.TransactionSummary
+		if err := rows.Scan(&s.Status, &s.TenorMonth, &s.Count, &s.TotalOTR); err != nil {
+			return nil, err
+		}
+		res = append(res, &s)
+	}
+	return res, nil
+}
+
+func scanTransaction(row rowScanner) (*entity.Transaction, error) {
+	var t entity.Transaction
+	var closedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.ContractNo, &t.ConsumerID, &t.ConsumerLimitID, &t.AssetID, &t.TenorMonth, &t.OTR, &t.AdminFee, &t.JumlahBunga, &t.JumlahCicilan, &t.Status,
+		&t.AmountPaid, &t.PrincipalPaid, &closedAt, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		t.ClosedAt = &closedAt.Time
+	}
+	return &t, nil
+}
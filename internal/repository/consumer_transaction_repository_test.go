@@ -115,12 +115,12 @@ func TestConsumerTransactionRepo_ListByConsumer_Success(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"id", "contract_no", "consumer_id", "consumer_limit_id", "asset_id", "tenor_month", "otr", "admin_fee", "jumlah_bunga", "jumlah_cicilan", "status", "created_at"}).
-		AddRow(1, "C-1-1", 1, 2, 3, 3, 1000, 50, 20, 340, "SUCCESS", now).
-		AddRow(2, "C-1-2", 1, 2, 4, 6, 1500, 75, 30, 435, "SUCCESS", now)
+	rows := sqlmock.NewRows([]string{"id", "contract_no", "consumer_id", "consumer_limit_id", "asset_id", "tenor_month", "otr", "admin_fee", "jumlah_bunga", "jumlah_cicilan", "status",
+		"amount_paid", "principal_paid", "closed_at", "created_at"}).
+		AddRow(1, "C-1-1", 1, 2, 3, 3, 1000, 50, 20, 340, "SUCCESS", 340, 317, nil, now).
+		AddRow(2, "C-1-2", 1, 2, 4, 6, 1500, 75, 30, 435, "CLOSED", 1605, 1500, now, now)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT id, contract_no, consumer_id, consumer_limit_id, asset_id, tenor_month, otr, admin_fee, jumlah_bunga, jumlah_cicilan, status, created_at
         FROM consumer_transactions WHERE consumer_id = ? ORDER BY created_at DESC`)).
 		WithArgs(uint64(1)).
 		WillReturnRows(rows)
@@ -129,6 +129,9 @@ func TestConsumerTransactionRepo_ListByConsumer_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, res, 2)
 	assert.Equal(t, uint64(1), res[0].ID)
+	assert.Equal(t, int64(317), res[0].PrincipalPaid)
+	assert.Nil(t, res[0].ClosedAt)
+	assert.NotNil(t, res[1].ClosedAt)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -140,7 +143,6 @@ func TestConsumerTransactionRepo_ListByConsumer_QueryError(t *testing.T) {
 	ctx := context.Background()
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT id, contract_no, consumer_id, consumer_limit_id, asset_id, tenor_month, otr, admin_fee, jumlah_bunga, jumlah_cicilan, status, created_at
         FROM consumer_transactions WHERE consumer_id = ? ORDER BY created_at DESC`)).
 		WithArgs(uint64(99)).
 		WillReturnError(sql.ErrConnDone)
@@ -151,3 +153,95 @@ func TestConsumerTransactionRepo_ListByConsumer_QueryError(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestConsumerTransactionRepo_FindByIDForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerTransactionMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM consumer_transactions WHERE id = ? FOR UPDATE`)).
+		WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contract_no", "consumer_id", "consumer_limit_id", "asset_id", "tenor_month", "otr", "admin_fee", "jumlah_bunga", "jumlah_cicilan", "status",
+			"amount_paid", "principal_paid", "closed_at", "created_at"}).
+			AddRow(1, "C-1-1", 1, 2, 3, 3, 1000, 50, 60, 370, "SUCCESS", 0, 0, nil, now))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	tr, err := repo.FindByIDForUpdate(context.Background(), tx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1110), tr.Total())
+	assert.Equal(t, int64(1110), tr.Outstanding())
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerTransactionRepo_UpdateRepayment(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerTransactionMockDB(t)
+	defer cleanup()
+
+	closed := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+        UPDATE consumer_transactions SET amount_paid = ?, principal_paid = ?, status = ?, closed_at = ?
+        WHERE id = ?`)).
+		WithArgs(int64(1110), int64(1000), "CLOSED", &closed, uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.UpdateRepayment(context.Background(), tx, &entity.Transaction{ID: 1, AmountPaid: 1110, PrincipalPaid: 1000, Status: "CLOSED", ClosedAt: &closed})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerTransactionRepo_Summary(t *testing.T) {
+	_, mock, repo, cleanup := setupConsumerTransactionMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	from := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	rows := sqlmock.NewRows([]string{"status", "tenor_month", "count", "total_otr"}).
+		AddRow("FAILED", 3, 1, 500000).
+		AddRow("SUCCESS", 3, 2, 2000000)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+        SELECT status, tenor_month, COUNT(*), COALESCE(SUM(otr), 0)
+        FROM consumer_transactions WHERE created_at >= ? AND created_at < ?
+        GROUP BY status, tenor_month ORDER BY status, tenor_month`)).
+		WithArgs(from, to).
+		WillReturnRows(rows)
+
+	res, err := repo.Summary(ctx, from, to)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "SUCCESS", res[1].Status)
+	assert.Equal(t, int64(2000000), res[1].TotalOTR)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumerTransactionRepo_HasOpenByConsumer(t *testing.T) {
+	db, mock, repo, cleanup := setupConsumerTransactionMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+        SELECT EXISTS(SELECT 1 FROM consumer_transactions WHERE consumer_id = ? AND status NOT IN (?, ?))`)).
+		WithArgs(uint64(10), entity.TransactionClosed, entity.TransactionFailed).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	open, err := repo.HasOpenByConsumer(context.Background(), tx, 10)
+	assert.NoError(t, err)
+	assert.True(t, open)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
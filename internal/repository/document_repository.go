@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type DocumentRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, d *entity.Document) error
+	FindByID(ctx context.Context, id string) (*entity.Document, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id string) (*entity.Document, error)
+	Claim(ctx context.Context, tx *sql.Tx, id string, consumerID uint64) error
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Document, error)
+	DeleteByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error
+	ListUnclaimedForUpdate(ctx context.Context, tx *sql.Tx, before time.Time, limit int) ([]*entity.Document, error)
+	Delete(ctx context.Context, tx *sql.Tx, id string) error
+	ExistsBySHA256(ctx context.Context, tx *sql.Tx, sha256 string) (bool, error)
+}
+
+type documentRepo struct {
+	db *sql.DB
+}
+
+func NewDocumentRepo(db *sql.DB) DocumentRepository {
+	return &documentRepo{db}
+}
+
+func (r *documentRepo) Create(ctx context.Context, tx *sql.Tx, d *entity.Document) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO documents (id, consumer_id, kind, sha256, content_type, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.ID, d.ConsumerID, d.Kind, d.SHA256, d.ContentType, d.Size, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *documentRepo) FindByID(ctx context.Context, id string) (*entity.Document, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, consumer_id, kind, sha256, content_type, size, created_at
+		FROM documents WHERE id = ?`, id)
+	return scanDocument(row)
+}
+
+func (r *documentRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id string) (*entity.Document, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, consumer_id, kind, sha256, content_type, size, created_at
+		FROM documents WHERE id = ? FOR UPDATE`, id)
+	return scanDocument(row)
+}
+
+// Claim attaches an uploaded document to its consumer.
+func (r *documentRepo) Claim(ctx context.Context, tx *sql.Tx, id string, consumerID uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE documents SET consumer_id = ? WHERE id = ?`, consumerID, id)
+	return err
+}
+
+func (r *documentRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Document, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, consumer_id, kind, sha256, content_type, size, created_at
+		FROM documents WHERE consumer_id = ? ORDER BY created_at`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.Document
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (r *documentRepo) DeleteByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM documents WHERE consumer_id = ?`, consumerID)
+	return err
+}
+
+// ListUnclaimedForUpdate locks up to limit documents that no consumer claimed
+// and that were uploaded before the cutoff. Documents another transaction has
+// locked, e.g. one being attached at registration, are skipped.
+func (r *documentRepo) ListUnclaimedForUpdate(ctx context.Context, tx *sql.Tx, before time.Time, limit int) ([]*entity.Document, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, consumer_id, kind, sha256, content_type, size, created_at
+		FROM documents WHERE consumer_id IS NULL AND created_at < ?
+		ORDER BY created_at LIMIT ? FOR UPDATE SKIP LOCKED`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.Document
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (r *documentRepo) Delete(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM documents WHERE id = ?`, id)
+	return err
+}
+
+// ExistsBySHA256 reports whether any document still points at the blob, which
+// is shared when the same file was uploaded more than once.
+func (r *documentRepo) ExistsBySHA256(ctx context.Context, tx *sql.Tx, sha256 string) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM documents WHERE sha256 = ?`, sha256).Scan(&n)
+	return n > 0, err
+}
+
+func scanDocument(row rowScanner) (*entity.Document, error) {
+	var d entity.Document
+	var consumerID sql.NullInt64
+	if err := row.Scan(&d.ID, &consumerID, &d.Kind, &d.SHA256, &d.ContentType, &d.Size, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	if consumerID.Valid {
+		id := uint64(consumerID.Int64)
+		d.ConsumerID = &id
+	}
+	return &d, nil
+}
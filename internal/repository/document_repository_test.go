@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupDocumentMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, DocumentRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewDocumentRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestDocumentRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupDocumentMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO documents (id, consumer_id, kind, sha256, content_type, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)).
+		WithArgs("doc_1", nil, entity.DocumentKTP, "abc123", "image/jpeg", int64(2048), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(context.Background(), tx, &entity.Document{
+		ID:          "doc_1",
+		Kind:        entity.DocumentKTP,
+		SHA256:      "abc123",
+		ContentType: "image/jpeg",
+		Size:        2048,
+	})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentRepo_FindByID(t *testing.T) {
+	_, mock, repo, cleanup := setupDocumentMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "kind", "sha256", "content_type", "size", "created_at"}).
+		AddRow("doc_1", 10, "SELFIE", "abc123", "image/png", 4096, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, consumer_id, kind, sha256, content_type, size, created_at
+		FROM documents WHERE id = ?`)).
+		WithArgs("doc_1").
+		WillReturnRows(rows)
+
+	d, err := repo.FindByID(context.Background(), "doc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.DocumentSelfie, d.Kind)
+	assert.Equal(t, uint64(10), *d.ConsumerID)
+	assert.Equal(t, int64(4096), d.Size)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentRepo_FindByID_Unclaimed(t *testing.T) {
+	_, mock, repo, cleanup := setupDocumentMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "kind", "sha256", "content_type", "size", "created_at"}).
+		AddRow("doc_1", nil, "KTP", "abc123", "image/jpeg", 2048, time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM documents WHERE id = ?`)).
+		WithArgs("doc_1").
+		WillReturnRows(rows)
+
+	d, err := repo.FindByID(context.Background(), "doc_1")
+	assert.NoError(t, err)
+	assert.Nil(t, d.ConsumerID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentRepo_Claim(t *testing.T) {
+	db, mock, repo, cleanup := setupDocumentMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE documents SET consumer_id = ? WHERE id = ?`)).
+		WithArgs(uint64(10), "doc_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.Claim(context.Background(), tx, "doc_1", 10))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentRepo_DeleteByConsumer(t *testing.T) {
+	db, mock, repo, cleanup := setupDocumentMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM documents WHERE consumer_id = ?`)).
+		WithArgs(uint64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM documents WHERE sha256 = ?`)).
+		WithArgs("abc123").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.DeleteByConsumer(context.Background(), tx, 10)
+	assert.NoError(t, err)
+
+	shared, err := repo.ExistsBySHA256(context.Background(), tx, "abc123")
+	assert.NoError(t, err)
+	assert.False(t, shared)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentRepo_ListUnclaimedForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupDocumentMockDB(t)
+	defer cleanup()
+
+	before := time.Now().Add(-24 * time.Hour)
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "kind", "sha256", "content_type", "size", "created_at"}).
+		AddRow("doc_1", nil, "KTP", "abc123", "image/jpeg", 2048, before.Add(-time.Hour))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM documents WHERE consumer_id IS NULL AND created_at < ?
+		ORDER BY created_at LIMIT ? FOR UPDATE SKIP LOCKED`)).
+		WithArgs(before, 100).
+		WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM documents WHERE id = ?`)).
+		WithArgs("doc_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	docs, err := repo.ListUnclaimedForUpdate(context.Background(), tx, before, 100)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+	assert.Nil(t, docs[0].ConsumerID)
+
+	err = repo.Delete(context.Background(), tx, docs[0].ID)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type KYCReviewRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, r *entity.KYCReview) error
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.KYCReview, error)
+}
+
+type kycReviewRepo struct {
+	db *sql.DB
+}
+
+func NewKYCReviewRepo(db *sql.DB) KYCReviewRepository {
+	return &kycReviewRepo{db}
+}
+
+func (r *kycReviewRepo) Create(ctx context.Context, tx *sql.Tx, kr *entity.KYCReview) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO kyc_reviews (consumer_id, from_status, to_status, note, reviewed_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		kr.ConsumerID, kr.FromStatus, kr.ToStatus, kr.Note, kr.ReviewedBy, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *kycReviewRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.KYCReview, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, consumer_id, from_status, to_status, note, reviewed_by, created_at
+		FROM kyc_reviews WHERE consumer_id = ? ORDER BY id`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.KYCReview
+	for rows.Next() {
+		var kr entity.KYCReview
+		var reviewedBy sql.NullInt64
+		if err := rows.Scan(&kr.ID, &kr.ConsumerID, &kr.FromStatus, &kr.ToStatus, &kr.Note, &reviewedBy, &kr.CreatedAt); err != nil {
+			return nil, err
+		}
+		if reviewedBy.Valid {
+			id := uint64(reviewedBy.Int64)
+			kr.ReviewedBy = &id
+		}
+		res = append(res, &kr)
+	}
+	return res, rows.Err()
+}
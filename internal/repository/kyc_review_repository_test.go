@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupKYCReviewMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, KYCReviewRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewKYCReviewRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestKYCReviewRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupKYCReviewMockDB(t)
+	defer cleanup()
+
+	staffID := uint64(2)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO kyc_reviews (consumer_id, from_status, to_status, note, reviewed_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(10), entity.KYCPendingReview, entity.KYCApproved, "documents match", staffID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(context.Background(), tx, &entity.KYCReview{
+		ConsumerID: 10,
+		FromStatus: entity.KYCPendingReview,
+		ToStatus:   entity.KYCApproved,
+		Note:       "documents match",
+		ReviewedBy: &staffID,
+	})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestKYCReviewRepo_ListByConsumer(t *testing.T) {
+	_, mock, repo, cleanup := setupKYCReviewMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "from_status", "to_status", "note", "reviewed_by", "created_at"}).
+		AddRow(1, 10, "PENDING_REVIEW", "NEEDS_RESUBMISSION", "selfie is blurry", 2, now).
+		AddRow(2, 10, "NEEDS_RESUBMISSION", "PENDING_REVIEW", "", nil, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, consumer_id, from_status, to_status, note, reviewed_by, created_at
+		FROM kyc_reviews WHERE consumer_id = ? ORDER BY id`)).
+		WithArgs(uint64(10)).
+		WillReturnRows(rows)
+
+	list, err := repo.ListByConsumer(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, uint64(2), *list[0].ReviewedBy)
+	assert.Nil(t, list[1].ReviewedBy)
+	assert.Equal(t, entity.KYCPendingReview, list[1].ToStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
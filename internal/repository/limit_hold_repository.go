@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type LimitHoldRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, h *entity.LimitHold) (uint64, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.LimitHold, error)
+	ListExpiredForUpdate(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*entity.LimitHold, error)
+	Resolve(ctx context.Context, tx *sql.Tx, id uint64, status entity.LimitHoldStatus, transactionID *uint64) error
+	HasActiveByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error)
+}
+
+type limitHoldRepo struct {
+	db *sql.DB
+}
+
+func NewLimitHoldRepo(db *sql.DB) LimitHoldRepository {
+	return &limitHoldRepo{db}
+}
+
+const limitHoldColumns = `id, consumer_id, consumer_limit_id, tenor_month, asset_id, merchant_id, amount, status,
+		transaction_id, expires_at, created_at, updated_at`
+
+func (r *limitHoldRepo) Create(ctx context.Context, tx *sql.Tx, h *entity.LimitHold) (uint64, error) {
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO limit_holds (consumer_id, consumer_limit_id, tenor_month, asset_id, merchant_id, amount, status,
+			expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.ConsumerID, h.ConsumerLimitID, h.TenorMonth, h.AssetID, h.MerchantID, h.Amount, entity.LimitHoldActive,
+		h.ExpiresAt, now, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *limitHoldRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.LimitHold, error) {
+	row := tx.QueryRowContext(ctx, `SELECT `+limitHoldColumns+` FROM limit_holds WHERE id = ? FOR UPDATE`, id)
+	return scanLimitHold(row)
+}
+
+// ListExpiredForUpdate locks up to limit HELD holds that expired by now.
+// Holds another transaction has locked, e.g. one being confirmed, are
+// skipped rather than waited for.
+func (r *limitHoldRepo) ListExpiredForUpdate(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*entity.LimitHold, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+limitHoldColumns+`
+		FROM limit_holds WHERE status = ? AND expires_at <= ?
+		ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`, entity.LimitHoldActive, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.LimitHold
+	for rows.Next() {
+		h, err := scanLimitHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, h)
+	}
+	return res, rows.Err()
+}
+
+// Resolve moves a hold out of HELD. transactionID is the contract a
+// confirmed hold became and nil otherwise.
+func (r *limitHoldRepo) Resolve(ctx context.Context, tx *sql.Tx, id uint64, status entity.LimitHoldStatus, transactionID *uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE limit_holds SET status = ?, transaction_id = ?, updated_at = ? WHERE id = ?`,
+		status, transactionID, time.Now().UTC(), id)
+	return err
+}
+
+// HasActiveByConsumer reports whether the consumer has a hold still in HELD.
+func (r *limitHoldRepo) HasActiveByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+	var held bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM limit_holds WHERE consumer_id = ? AND status = ?)`,
+		consumerID, entity.LimitHoldActive).Scan(&held)
+	return held, err
+}
+
+func scanLimitHold(row rowScanner) (*entity.LimitHold, error) {
+	var h entity.LimitHold
+	var merchantID, transactionID sql.NullInt64
+	err := row.Scan(&h.ID, &h.ConsumerID, &h.ConsumerLimitID, &h.TenorMonth, &h.AssetID, &merchantID, &h.Amount, &h.Status,
+		&transactionID, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if merchantID.Valid {
+		v := uint64(merchantID.Int64)
+		h.MerchantID = &v
+	}
+	if transactionID.Valid {
+		v := uint64(transactionID.Int64)
+		h.TransactionID = &v
+	}
+	return &h, nil
+}
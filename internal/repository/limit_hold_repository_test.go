@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupLimitHoldMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, LimitHoldRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewLimitHoldRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+var limitHoldRowColumns = []string{"id", "consumer_id", "consumer_limit_id", "tenor_month", "asset_id", "merchant_id", "amount", "status",
+	"transaction_id", "expires_at", "created_at", "updated_at"}
+
+func TestLimitHoldRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitHoldMockDB(t)
+	defer cleanup()
+
+	merchantID := uint64(7)
+	expires := time.Now().Add(15 * time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO limit_holds`)).
+		WithArgs(uint64(17), uint64(4), uint8(6), uint64(2), &merchantID, 500000.0, entity.LimitHoldActive,
+			expires, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.Create(context.Background(), tx, &entity.LimitHold{
+		ConsumerID:      17,
+		ConsumerLimitID: 4,
+		TenorMonth:      6,
+		AssetID:         2,
+		MerchantID:      &merchantID,
+		Amount:          500000,
+		ExpiresAt:       expires,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitHoldRepo_FindByIDForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitHoldMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_holds WHERE id = ? FOR UPDATE`)).
+		WithArgs(uint64(12)).
+		WillReturnRows(sqlmock.NewRows(limitHoldRowColumns).
+			AddRow(12, 17, 4, 6, 2, 7, 500000.0, "CONFIRMED", 42, now, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_holds WHERE id = ? FOR UPDATE`)).
+		WithArgs(uint64(13)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	h, err := repo.FindByIDForUpdate(context.Background(), tx, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LimitHoldConfirmed, h.Status)
+	assert.Equal(t, uint64(7), *h.MerchantID)
+	assert.Equal(t, uint64(42), *h.TransactionID)
+
+	_, err = repo.FindByIDForUpdate(context.Background(), tx, 13)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitHoldRepo_ListExpiredForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitHoldMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE status = ? AND expires_at <= ?
+		ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`)).
+		WithArgs(entity.LimitHoldActive, now, 100).
+		WillReturnRows(sqlmock.NewRows(limitHoldRowColumns).
+			AddRow(12, 17, 4, 6, 2, nil, 500000.0, "HELD", nil, now, now, now))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	holds, err := repo.ListExpiredForUpdate(context.Background(), tx, now, 100)
+	assert.NoError(t, err)
+	assert.Len(t, holds, 1)
+	assert.Nil(t, holds[0].MerchantID)
+	assert.Nil(t, holds[0].TransactionID)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitHoldRepo_Resolve(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitHoldMockDB(t)
+	defer cleanup()
+
+	txID := uint64(42)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE limit_holds SET status = ?, transaction_id = ?, updated_at = ? WHERE id = ?`)).
+		WithArgs(entity.LimitHoldConfirmed, &txID, sqlmock.AnyArg(), uint64(12)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Resolve(context.Background(), tx, 12, entity.LimitHoldConfirmed, &txID)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitHoldRepo_HasActiveByConsumer(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitHoldMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM limit_holds WHERE consumer_id = ? AND status = ?)`)).
+		WithArgs(uint64(10), entity.LimitHoldActive).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	held, err := repo.HasActiveByConsumer(context.Background(), tx, 10)
+	assert.NoError(t, err)
+	assert.False(t, held)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+// LimitLedgerRepository keeps the append-only history of limit movements.
+// Entries are only ever inserted.
+type LimitLedgerRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, e *entity.LimitLedgerEntry) error
+	ListByLimit(ctx context.Context, consumerLimitID uint64) ([]*entity.LimitLedgerEntry, error)
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitLedgerEntry, error)
+	Mismatches(ctx context.Context) ([]*entity.LimitMismatch, error)
+}
+
+type limitLedgerRepo struct {
+	db *sql.DB
+}
+
+func NewLimitLedgerRepo(db *sql.DB) LimitLedgerRepository {
+	return &limitLedgerRepo{db}
+}
+
+func (r *limitLedgerRepo) Create(ctx context.Context, tx *sql.Tx, e *entity.LimitLedgerEntry) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO limit_ledger (consumer_limit_id, consumer_id, tenor_month, kind, amount, used_before, used_after,
+			max_before, max_after, source_type, source_id, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ConsumerLimitID, e.ConsumerID, e.TenorMonth, e.Kind, e.Amount, e.UsedBefore, e.UsedAfter,
+		e.MaxBefore, e.MaxAfter, e.SourceType, e.SourceID, e.Reason, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *limitLedgerRepo) ListByLimit(ctx context.Context, consumerLimitID uint64) ([]*entity.LimitLedgerEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, consumer_limit_id, consumer_id, tenor_month, kind, amount, used_before, used_after,
+			max_before, max_after, source_type, source_id, reason, created_at
+		FROM limit_ledger WHERE consumer_limit_id = ? ORDER BY id`, consumerLimitID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLedgerEntries(rows)
+}
+
+// ListByConsumer returns the ledger of every limit the consumer has, in the
+// order it was written.
+func (r *limitLedgerRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitLedgerEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, consumer_limit_id, consumer_id, tenor_month, kind, amount, used_before, used_after,
+			max_before, max_after, source_type, source_id, reason, created_at
+		FROM limit_ledger WHERE consumer_id = ? ORDER BY id`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLedgerEntries(rows)
+}
+
+func scanLedgerEntries(rows *sql.Rows) ([]*entity.LimitLedgerEntry, error) {
+	defer rows.Close()
+
+	var res []*entity.LimitLedgerEntry
+	for rows.Next() {
+		var e entity.LimitLedgerEntry
+		err := rows.Scan(&e.ID, &e.ConsumerLimitID, &e.ConsumerID, &e.TenorMonth, &e.Kind, &e.Amount, &e.UsedBefore, &e.UsedAfter,
+			&e.MaxBefore, &e.MaxAfter, &e.SourceType, &e.SourceID, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &e)
+	}
+	return res, rows.Err()
+}
+
+// Mismatches returns every limit whose used_limit is not the sum of its
+// ledger amounts. An empty result means the ledger reconciles.
+func (r *limitLedgerRepo) Mismatches(ctx context.Context) ([]*entity.LimitMismatch, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT cl.id, cl.consumer_id, cl.tenor_month, cl.used_limit, COALESCE(SUM(l.amount), 0) AS ledger_sum
+		FROM consumer_limits cl LEFT JOIN limit_ledger l ON l.consumer_limit_id = cl.id
+		GROUP BY cl.id, cl.consumer_id, cl.tenor_month, cl.used_limit
+		HAVING cl.used_limit <> ledger_sum
+		ORDER BY cl.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.LimitMismatch
+	for rows.Next() {
+		var m entity.LimitMismatch
+		if err := rows.Scan(&m.ConsumerLimitID, &m.ConsumerID, &m.TenorMonth, &m.UsedLimit, &m.LedgerSum); err != nil {
+			return nil, err
+		}
+		res = append(res, &m)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupLimitLedgerMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, LimitLedgerRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewLimitLedgerRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestLimitLedgerRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitLedgerMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO limit_ledger`)).
+		WithArgs(uint64(4), uint64(17), uint8(6), entity.LimitDebit, 500000.0, 1000000.0, 1500000.0,
+			6000000.0, 6000000.0, entity.LimitSourceTransaction, "42", "purchase C-17-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(context.Background(), tx, &entity.LimitLedgerEntry{
+		ConsumerLimitID: 4,
+		ConsumerID:      17,
+		TenorMonth:      6,
+		Kind:            entity.LimitDebit,
+		Amount:          500000,
+		UsedBefore:      1000000,
+		UsedAfter:       1500000,
+		MaxBefore:       6000000,
+		MaxAfter:        6000000,
+		SourceType:      entity.LimitSourceTransaction,
+		SourceID:        "42",
+		Reason:          "purchase C-17-1",
+	})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitLedgerRepo_ListByLimit(t *testing.T) {
+	_, mock, repo, cleanup := setupLimitLedgerMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "consumer_limit_id", "consumer_id", "tenor_month", "kind", "amount", "used_before", "used_after",
+		"max_before", "max_after", "source_type", "source_id", "reason", "created_at"}).
+		AddRow(1, 4, 17, 6, "ADJUSTMENT", 1000000.0, 0.0, 1000000.0, 6000000.0, 6000000.0, "JOB", "migration", "opening balance", now).
+		AddRow(2, 4, 17, 6, "DEBIT", 500000.0, 1000000.0, 1500000.0, 6000000.0, 6000000.0, "TRANSACTION", "42", "", now)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_ledger WHERE consumer_limit_id = ? ORDER BY id`)).
+		WithArgs(uint64(4)).
+		WillReturnRows(rows)
+
+	entries, err := repo.ListByLimit(context.Background(), 4)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, entity.LimitAdjustment, entries[0].Kind)
+	assert.Equal(t, entity.LimitSourceTransaction, entries[1].SourceType)
+	assert.Equal(t, 1500000.0, entries[1].UsedAfter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitLedgerRepo_ListByConsumer(t *testing.T) {
+	_, mock, repo, cleanup := setupLimitLedgerMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "consumer_limit_id", "consumer_id", "tenor_month", "kind", "amount", "used_before", "used_after",
+		"max_before", "max_after", "source_type", "source_id", "reason", "created_at"}).
+		AddRow(1, 4, 17, 6, "DEBIT", 500000.0, 0.0, 500000.0, 6000000.0, 6000000.0, "TRANSACTION", "42", "", time.Now()).
+		AddRow(2, 5, 17, 3, "RELEASE", -200000.0, 300000.0, 100000.0, 3000000.0, 3000000.0, "TRANSACTION", "43", "", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_ledger WHERE consumer_id = ? ORDER BY id`)).
+		WithArgs(uint64(17)).
+		WillReturnRows(rows)
+
+	entries, err := repo.ListByConsumer(context.Background(), 17)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, uint64(5), entries[1].ConsumerLimitID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitLedgerRepo_Mismatches(t *testing.T) {
+	_, mock, repo, cleanup := setupLimitLedgerMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "tenor_month", "used_limit", "ledger_sum"}).
+		AddRow(3, 17, 3, 12000000.0, 11000000.0)
+	mock.ExpectQuery(regexp.QuoteMeta(`HAVING cl.used_limit <> ledger_sum`)).
+		WillReturnRows(rows)
+
+	mismatches, err := repo.Mismatches(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, uint64(3), mismatches[0].ConsumerLimitID)
+	assert.Equal(t, 11000000.0, mismatches[0].LedgerSum)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type LimitOverrideRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, o *entity.LimitOverrideRequest) (uint64, error)
+	FindPendingByLimit(ctx context.Context, consumerLimitID uint64) (*entity.LimitOverrideRequest, error)
+	FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.LimitOverrideRequest, error)
+	Review(ctx context.Context, tx *sql.Tx, id uint64, status entity.LimitOverrideStatus, staffUserID uint64, note string) error
+	ListByStatus(ctx context.Context, status entity.LimitOverrideStatus) ([]*entity.LimitOverrideRequest, error)
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitOverrideRequest, error)
+}
+
+type limitOverrideRepo struct {
+	db *sql.DB
+}
+
+func NewLimitOverrideRepo(db *sql.DB) LimitOverrideRepository {
+	return &limitOverrideRepo{db}
+}
+
+const limitOverrideColumns = `id, consumer_limit_id, consumer_id, tenor_month, current_max_limit, new_max_limit, justification,
+		       status, requested_by, reviewed_by, review_note, reviewed_at, created_at`
+
+func (r *limitOverrideRepo) Create(ctx context.Context, tx *sql.Tx, o *entity.LimitOverrideRequest) (uint64, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO limit_override_requests
+		(consumer_limit_id, consumer_id, tenor_month, current_max_limit, new_max_limit, justification, status, requested_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		o.ConsumerLimitID, o.ConsumerID, o.TenorMonth, o.CurrentMaxLimit, o.NewMaxLimit, o.Justification,
+		entity.LimitOverridePending, o.RequestedBy, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *limitOverrideRepo) FindPendingByLimit(ctx context.Context, consumerLimitID uint64) (*entity.LimitOverrideRequest, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+limitOverrideColumns+`
+		FROM limit_override_requests WHERE consumer_limit_id = ? AND status = ?
+		ORDER BY id DESC LIMIT 1`, consumerLimitID, entity.LimitOverridePending)
+	return scanLimitOverride(row)
+}
+
+func (r *limitOverrideRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.LimitOverrideRequest, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+limitOverrideColumns+`
+		FROM limit_override_requests WHERE id = ? FOR UPDATE`, id)
+	return scanLimitOverride(row)
+}
+
+func (r *limitOverrideRepo) Review(ctx context.Context, tx *sql.Tx, id uint64, status entity.LimitOverrideStatus, staffUserID uint64, note string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE limit_override_requests SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ?
+		WHERE id = ?`,
+		status, staffUserID, note, time.Now().UTC(), id,
+	)
+	return err
+}
+
+// ListByStatus returns the override requests with the given status, oldest
+// first, e.g. the queue of requests waiting for a checker.
+func (r *limitOverrideRepo) ListByStatus(ctx context.Context, status entity.LimitOverrideStatus) ([]*entity.LimitOverrideRequest, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+limitOverrideColumns+`
+		FROM limit_override_requests WHERE status = ? ORDER BY id`, status)
+	if err != nil {
+		return nil, err
+	}
+	return scanLimitOverrides(rows)
+}
+
+func (r *limitOverrideRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitOverrideRequest, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+limitOverrideColumns+`
+		FROM limit_override_requests WHERE consumer_id = ? ORDER BY id`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLimitOverrides(rows)
+}
+
+func scanLimitOverrides(rows *sql.Rows) ([]*entity.LimitOverrideRequest, error) {
+	defer rows.Close()
+
+	var res []*entity.LimitOverrideRequest
+	for rows.Next() {
+		o, err := scanLimitOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, o)
+	}
+	return res, rows.Err()
+}
+
+func scanLimitOverride(row rowScanner) (*entity.LimitOverrideRequest, error) {
+	var o entity.LimitOverrideRequest
+	var reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	err := row.Scan(&o.ID, &o.ConsumerLimitID, &o.ConsumerID, &o.TenorMonth, &o.CurrentMaxLimit, &o.NewMaxLimit, &o.Justification,
+		&o.Status, &o.RequestedBy, &reviewedBy, &o.ReviewNote, &reviewedAt, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewedBy.Valid {
+		id := uint64(reviewedBy.Int64)
+		o.ReviewedBy = &id
+	}
+	if reviewedAt.Valid {
+		o.ReviewedAt = &reviewedAt.Time
+	}
+	return &o, nil
+}
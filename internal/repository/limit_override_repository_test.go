@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupLimitOverrideMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, LimitOverrideRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewLimitOverrideRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+var limitOverrideRowColumns = []string{"id", "consumer_limit_id", "consumer_id", "tenor_month", "current_max_limit", "new_max_limit", "justification",
+	"status", "requested_by", "reviewed_by", "review_note", "reviewed_at", "created_at"}
+
+func TestLimitOverrideRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitOverrideMockDB(t)
+	defer cleanup()
+
+	o := &entity.LimitOverrideRequest{
+		ConsumerLimitID: 4,
+		ConsumerID:      10,
+		TenorMonth:      3,
+		CurrentMaxLimit: 6000000,
+		NewMaxLimit:     9000000,
+		Justification:   "salary slip shows bonus income",
+		RequestedBy:     2,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO limit_override_requests
+		(consumer_limit_id, consumer_id, tenor_month, current_max_limit, new_max_limit, justification, status, requested_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(4), uint64(10), uint8(3), 6000000.0, 9000000.0, "salary slip shows bonus income",
+			entity.LimitOverridePending, uint64(2), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.Create(context.Background(), tx, o)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverrideRepo_FindByIDForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitOverrideMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(limitOverrideRowColumns).
+		AddRow(5, 4, 10, 3, 6000000.0, 9000000.0, "bonus income", "APPROVED", 2, 3, "ok", now, now)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_override_requests WHERE id = ? FOR UPDATE`)).
+		WithArgs(uint64(5)).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	o, err := repo.FindByIDForUpdate(context.Background(), tx, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LimitOverrideApproved, o.Status)
+	assert.Equal(t, uint64(2), o.RequestedBy)
+	assert.Equal(t, uint64(3), *o.ReviewedBy)
+	assert.NotNil(t, o.ReviewedAt)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverrideRepo_FindPendingByLimit(t *testing.T) {
+	_, mock, repo, cleanup := setupLimitOverrideMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_override_requests WHERE consumer_limit_id = ? AND status = ?`)).
+		WithArgs(uint64(4), entity.LimitOverridePending).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.FindPendingByLimit(context.Background(), 4)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverrideRepo_Review(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitOverrideMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE limit_override_requests SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ?
+		WHERE id = ?`)).
+		WithArgs(entity.LimitOverrideRejected, uint64(3), "no evidence", sqlmock.AnyArg(), uint64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Review(context.Background(), tx, 5, entity.LimitOverrideRejected, 3, "no evidence")
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverrideRepo_ListByStatus(t *testing.T) {
+	_, mock, repo, cleanup := setupLimitOverrideMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(limitOverrideRowColumns).
+		AddRow(5, 4, 10, 3, 6000000.0, 9000000.0, "bonus income", "PENDING", 2, nil, "", nil, now).
+		AddRow(6, 9, 11, 1, 1000000.0, 500000.0, "late payments", "PENDING", 2, nil, "", nil, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM limit_override_requests WHERE status = ? ORDER BY id`)).
+		WithArgs(entity.LimitOverridePending).
+		WillReturnRows(rows)
+
+	list, err := repo.ListByStatus(context.Background(), entity.LimitOverridePending)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Nil(t, list[0].ReviewedBy)
+	assert.Nil(t, list[0].ReviewedAt)
+	assert.Equal(t, 500000.0, list[1].NewMaxLimit)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type LimitPolicyRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, p *entity.LimitPolicy) (uint32, error)
+	FindByVersion(ctx context.Context, version uint32) (*entity.LimitPolicy, error)
+	FindByVersionForUpdate(ctx context.Context, tx *sql.Tx, version uint32) (*entity.LimitPolicy, error)
+	FindActive(ctx context.Context) (*entity.LimitPolicy, error)
+	List(ctx context.Context) ([]*entity.LimitPolicy, error)
+	Publish(ctx context.Context, tx *sql.Tx, version uint32, staffUserID uint64) error
+}
+
+type limitPolicyRepo struct {
+	db *sql.DB
+}
+
+func NewLimitPolicyRepo(db *sql.DB) LimitPolicyRepository {
+	return &limitPolicyRepo{db}
+}
+
+const limitPolicyColumns = `id, version, rules, note, created_by, created_at, published_by, published_at`
+
+// Create stores the policy under the next version number. Locking the
+// highest version keeps two concurrent drafts from taking the same number.
+func (r *limitPolicyRepo) Create(ctx context.Context, tx *sql.Tx, p *entity.LimitPolicy) (uint32, error) {
+	var version uint32
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM limit_policies FOR UPDATE`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	rules, err := json.Marshal(p.Rules)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO limit_policies (version, rules, note, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		version, rules, p.Note, p.CreatedBy, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func (r *limitPolicyRepo) FindByVersion(ctx context.Context, version uint32) (*entity.LimitPolicy, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+limitPolicyColumns+` FROM limit_policies WHERE version = ?`, version)
+	return scanLimitPolicy(row)
+}
+
+func (r *limitPolicyRepo) FindByVersionForUpdate(ctx context.Context, tx *sql.Tx, version uint32) (*entity.LimitPolicy, error) {
+	row := tx.QueryRowContext(ctx, `SELECT `+limitPolicyColumns+` FROM limit_policies WHERE version = ? FOR UPDATE`, version)
+	return scanLimitPolicy(row)
+}
+
+// FindActive returns the most recently published policy.
+func (r *limitPolicyRepo) FindActive(ctx context.Context) (*entity.LimitPolicy, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+limitPolicyColumns+` FROM limit_policies
+		WHERE published_at IS NOT NULL ORDER BY published_at DESC, version DESC LIMIT 1`)
+	return scanLimitPolicy(row)
+}
+
+func (r *limitPolicyRepo) List(ctx context.Context) ([]*entity.LimitPolicy, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+limitPolicyColumns+` FROM limit_policies ORDER BY version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.LimitPolicy
+	for rows.Next() {
+		p, err := scanLimitPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func (r *limitPolicyRepo) Publish(ctx context.Context, tx *sql.Tx, version uint32, staffUserID uint64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE limit_policies SET published_by = ?, published_at = ? WHERE version = ? AND published_at IS NULL`,
+		staffUserID, time.Now().UTC(), version,
+	)
+	return err
+}
+
+func scanLimitPolicy(row rowScanner) (*entity.LimitPolicy, error) {
+	var p entity.LimitPolicy
+	var rules []byte
+	var createdBy, publishedBy sql.NullInt64
+	var publishedAt sql.NullTime
+	err := row.Scan(&p.ID, &p.Version, &rules, &p.Note, &createdBy, &p.CreatedAt, &publishedBy, &publishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rules, &p.Rules); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		id := uint64(createdBy.Int64)
+		p.CreatedBy = &id
+	}
+	if publishedBy.Valid {
+		id := uint64(publishedBy.Int64)
+		p.PublishedBy = &id
+	}
+	if publishedAt.Valid {
+		p.PublishedAt = &publishedAt.Time
+	}
+	return &p, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupLimitPolicyMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, LimitPolicyRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewLimitPolicyRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+const testLimitRules = `{"salary_bands":[{"min_salary":0,"ratio":0.4}],"tenors":[{"tenor":1,"multiplier":1,"floor":0,"cap":0},{"tenor":6,"multiplier":6,"floor":0,"cap":25000000}],"risk_adjustments":{"E":0.5}}`
+
+func TestLimitPolicyRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitPolicyMockDB(t)
+	defer cleanup()
+
+	staffID := uint64(2)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(version), 0) + 1 FROM limit_policies FOR UPDATE`)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO limit_policies (version, rules, note, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)`)).
+		WithArgs(uint32(3), []byte(testLimitRules), "cap 6 month tenor", &staffID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	version, err := repo.Create(context.Background(), tx, &entity.LimitPolicy{
+		Rules: entity.LimitRules{
+			SalaryBands:     []entity.SalaryBand{{MinSalary: 0, Ratio: 0.4}},
+			Tenors:          []entity.TenorRule{{Tenor: 1, Multiplier: 1}, {Tenor: 6, Multiplier: 6, Cap: 25000000}},
+			RiskAdjustments: map[entity.RiskGrade]float64{entity.RiskGradeE: 0.5},
+		},
+		Note:      "cap 6 month tenor",
+		CreatedBy: &staffID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), version)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitPolicyRepo_FindActive(t *testing.T) {
+	_, mock, repo, cleanup := setupLimitPolicyMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "version", "rules", "note", "created_by", "created_at", "published_by", "published_at"}).
+		AddRow(2, 2, testLimitRules, "", 1, now, 1, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, version, rules, note, created_by, created_at, published_by, published_at FROM limit_policies
+		WHERE published_at IS NOT NULL ORDER BY published_at DESC, version DESC LIMIT 1`)).
+		WillReturnRows(rows)
+
+	p, err := repo.FindActive(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), p.Version)
+	assert.True(t, p.Published())
+	assert.Len(t, p.Rules.Tenors, 2)
+	assert.Equal(t, 25000000.0, p.Rules.Tenors[1].Cap)
+	assert.Equal(t, 0.5, p.Rules.RiskAdjustments[entity.RiskGradeE])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitPolicyRepo_Publish(t *testing.T) {
+	db, mock, repo, cleanup := setupLimitPolicyMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE limit_policies SET published_by = ?, published_at = ? WHERE version = ? AND published_at IS NULL`)).
+		WithArgs(uint64(2), sqlmock.AnyArg(), uint32(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Publish(context.Background(), tx, 3, 2)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
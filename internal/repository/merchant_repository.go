@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type MerchantRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, m *entity.Merchant) (uint64, error)
+	FindByID(ctx context.Context, id uint64) (*entity.Merchant, error)
+	CreateAPIKey(ctx context.Context, tx *sql.Tx, k *entity.MerchantAPIKey) (uint64, error)
+	FindAPIKeyByKeyID(ctx context.Context, keyID string) (*entity.MerchantAPIKey, error)
+	RevokeAPIKey(ctx context.Context, tx *sql.Tx, merchantID uint64, keyID string) (bool, error)
+	TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, at time.Time) error
+	RememberSignature(ctx context.Context, tx *sql.Tx, apiKeyID uint64, signatureHash string, expiresAt time.Time) (bool, error)
+	PurgeSignatures(ctx context.Context, tx *sql.Tx, apiKeyID uint64, before time.Time) error
+}
+
+type merchantRepo struct {
+	db *sql.DB
+}
+
+func NewMerchantRepo(db *sql.DB) MerchantRepository {
+	return &merchantRepo{db}
+}
+
+func (r *merchantRepo) Create(ctx context.Context, tx *sql.Tx, m *entity.Merchant) (uint64, error) {
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO merchants (code, name, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		m.Code, m.Name, m.Active, now, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *merchantRepo) FindByID(ctx context.Context, id uint64) (*entity.Merchant, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, code, name, active, created_at, updated_at
+		FROM merchants WHERE id = ?`, id)
+
+	var m entity.Merchant
+	if err := row.Scan(&m.ID, &m.Code, &m.Name, &m.Active, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *merchantRepo) CreateAPIKey(ctx context.Context, tx *sql.Tx, k *entity.MerchantAPIKey) (uint64, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO merchant_api_keys (merchant_id, key_id, secret_hash, created_at)
+		VALUES (?, ?, ?, ?)`,
+		k.MerchantID, k.KeyID, k.SecretHash, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *merchantRepo) FindAPIKeyByKeyID(ctx context.Context, keyID string) (*entity.MerchantAPIKey, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, merchant_id, key_id, secret_hash, last_used_at, revoked_at, created_at
+		FROM merchant_api_keys WHERE key_id = ?`, keyID)
+
+	var k entity.MerchantAPIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.MerchantID, &k.KeyID, &k.SecretHash, &lastUsedAt, &revokedAt, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
+
+// RevokeAPIKey revokes an active key of the merchant and reports whether one
+// was found.
+func (r *merchantRepo) RevokeAPIKey(ctx context.Context, tx *sql.Tx, merchantID uint64, keyID string) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE merchant_api_keys SET revoked_at = ?
+		WHERE merchant_id = ? AND key_id = ? AND revoked_at IS NULL`,
+		time.Now().UTC(), merchantID, keyID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *merchantRepo) TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `UPDATE merchant_api_keys SET last_used_at = ? WHERE id = ?`, at, id)
+	return err
+}
+
+// RememberSignature records a request signature until it expires and reports
+// false when the same signature was already seen, i.e. the request is a replay.
+func (r *merchantRepo) RememberSignature(ctx context.Context, tx *sql.Tx, apiKeyID uint64, signatureHash string, expiresAt time.Time) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT IGNORE INTO merchant_request_signatures (api_key_id, signature_hash, expires_at)
+		VALUES (?, ?, ?)`,
+		apiKeyID, signatureHash, expiresAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *merchantRepo) PurgeSignatures(ctx context.Context, tx *sql.Tx, apiKeyID uint64, before time.Time) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM merchant_request_signatures WHERE api_key_id = ? AND expires_at < ?`, apiKeyID, before)
+	return err
+}
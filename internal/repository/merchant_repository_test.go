@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupMerchantMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, MerchantRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewMerchantRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestMerchantRepo_CreateAPIKey(t *testing.T) {
+	db, mock, repo, cleanup := setupMerchantMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO merchant_api_keys (merchant_id, key_id, secret_hash, created_at)
+		VALUES (?, ?, ?, ?)`)).
+		WithArgs(uint64(7), "mk_abc", "hash", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.CreateAPIKey(context.Background(), tx, &entity.MerchantAPIKey{MerchantID: 7, KeyID: "mk_abc", SecretHash: "hash"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchantRepo_FindAPIKeyByKeyID(t *testing.T) {
+	_, mock, repo, cleanup := setupMerchantMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "merchant_id", "key_id", "secret_hash", "last_used_at", "revoked_at", "created_at"}).
+		AddRow(4, 7, "mk_abc", "hash", nil, now, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, merchant_id, key_id, secret_hash, last_used_at, revoked_at, created_at
+		FROM merchant_api_keys WHERE key_id = ?`)).
+		WithArgs("mk_abc").
+		WillReturnRows(rows)
+
+	k, err := repo.FindAPIKeyByKeyID(context.Background(), "mk_abc")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), k.MerchantID)
+	assert.Nil(t, k.LastUsedAt)
+	assert.True(t, k.Revoked())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchantRepo_RememberSignature(t *testing.T) {
+	db, mock, repo, cleanup := setupMerchantMockDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta(`
+		INSERT IGNORE INTO merchant_request_signatures (api_key_id, signature_hash, expires_at)
+		VALUES (?, ?, ?)`)
+	expires := time.Now().Add(5 * time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(uint64(4), "sig", expires).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(query).WithArgs(uint64(4), "sig", expires).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	fresh, err := repo.RememberSignature(context.Background(), tx, 4, "sig", expires)
+	assert.NoError(t, err)
+	assert.True(t, fresh)
+
+	fresh, err = repo.RememberSignature(context.Background(), tx, 4, "sig", expires)
+	assert.NoError(t, err)
+	assert.False(t, fresh)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchantRepo_RevokeAPIKey(t *testing.T) {
+	db, mock, repo, cleanup := setupMerchantMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		UPDATE merchant_api_keys SET revoked_at = ?
+		WHERE merchant_id = ? AND key_id = ? AND revoked_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), uint64(7), "mk_abc").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	found, err := repo.RevokeAPIKey(context.Background(), tx, 7, "mk_abc")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
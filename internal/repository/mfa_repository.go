@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type MFARepository interface {
+	FindBySubject(ctx context.Context, subjectType string, subjectID uint64) (*entity.MFACredential, error)
+	FindBySubjectForUpdate(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64) (*entity.MFACredential, error)
+	Save(ctx context.Context, tx *sql.Tx, c *entity.MFACredential) error
+	Confirm(ctx context.Context, tx *sql.Tx, id uint64, step int64) error
+	UpdateLastUsedStep(ctx context.Context, tx *sql.Tx, id uint64, step int64) error
+	Delete(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64) error
+	ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64, hashes []string) error
+	UseRecoveryCode(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64, hash string) (bool, error)
+}
+
+type mfaRepo struct {
+	db *sql.DB
+}
+
+func NewMFARepo(db *sql.DB) MFARepository {
+	return &mfaRepo{db}
+}
+
+func (r *mfaRepo) FindBySubject(ctx context.Context, subjectType string, subjectID uint64) (*entity.MFACredential, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, subject_type, subject_id, secret, confirmed_at, last_used_step, created_at
+		FROM mfa_credentials WHERE subject_type = ? AND subject_id = ?`, subjectType, subjectID)
+	return scanMFACredential(row)
+}
+
+func (r *mfaRepo) FindBySubjectForUpdate(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64) (*entity.MFACredential, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, subject_type, subject_id, secret, confirmed_at, last_used_step, created_at
+		FROM mfa_credentials WHERE subject_type = ? AND subject_id = ? FOR UPDATE`, subjectType, subjectID)
+	return scanMFACredential(row)
+}
+
+// Save stores a new, unconfirmed secret, replacing any earlier enrollment
+// attempt for the same subject.
+func (r *mfaRepo) Save(ctx context.Context, tx *sql.Tx, c *entity.MFACredential) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO mfa_credentials (subject_type, subject_id, secret, created_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), confirmed_at = NULL, last_used_step = 0, created_at = VALUES(created_at)`,
+		c.SubjectType, c.SubjectID, c.Secret, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *mfaRepo) Confirm(ctx context.Context, tx *sql.Tx, id uint64, step int64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE mfa_credentials SET confirmed_at = ?, last_used_step = ? WHERE id = ?`, time.Now().UTC(), step, id)
+	return err
+}
+
+func (r *mfaRepo) UpdateLastUsedStep(ctx context.Context, tx *sql.Tx, id uint64, step int64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE mfa_credentials SET last_used_step = ? WHERE id = ?`, step, id)
+	return err
+}
+
+func (r *mfaRepo) Delete(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE subject_type = ? AND subject_id = ?`, subjectType, subjectID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM mfa_credentials WHERE subject_type = ? AND subject_id = ?`, subjectType, subjectID)
+	return err
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE subject_type = ? AND subject_id = ?`, subjectType, subjectID); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, h := range hashes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO mfa_recovery_codes (subject_type, subject_id, code_hash, created_at)
+			VALUES (?, ?, ?, ?)`,
+			subjectType, subjectID, h, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode burns a matching unused code and reports whether one existed.
+func (r *mfaRepo) UseRecoveryCode(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64, hash string) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = ?
+		WHERE subject_type = ? AND subject_id = ? AND code_hash = ? AND used_at IS NULL`,
+		time.Now().UTC(), subjectType, subjectID, hash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func scanMFACredential(row rowScanner) (*entity.MFACredential, error) {
+	var c entity.MFACredential
+	var confirmedAt sql.NullTime
+	err := row.Scan(&c.ID, &c.SubjectType, &c.SubjectID, &c.Secret, &confirmedAt, &c.LastUsedStep, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		c.ConfirmedAt = &confirmedAt.Time
+	}
+	return &c, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMFAMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, MFARepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewMFARepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestMFARepo_FindBySubject(t *testing.T) {
+	_, mock, repo, cleanup := setupMFAMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "subject_type", "subject_id", "secret", "confirmed_at", "last_used_step", "created_at"}).
+		AddRow(1, "staff", 3, "SECRET", now, 42, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, subject_type, subject_id, secret, confirmed_at, last_used_step, created_at
+		FROM mfa_credentials WHERE subject_type = ? AND subject_id = ?`)).
+		WithArgs("staff", uint64(3)).
+		WillReturnRows(rows)
+
+	c, err := repo.FindBySubject(context.Background(), "staff", 3)
+	assert.NoError(t, err)
+	assert.True(t, c.Confirmed())
+	assert.Equal(t, int64(42), c.LastUsedStep)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMFARepo_UseRecoveryCode(t *testing.T) {
+	db, mock, repo, cleanup := setupMFAMockDB(t)
+	defer cleanup()
+
+	query := regexp.QuoteMeta(`
+		UPDATE mfa_recovery_codes SET used_at = ?
+		WHERE subject_type = ? AND subject_id = ? AND code_hash = ? AND used_at IS NULL`)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), "consumer", uint64(5), "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), "consumer", uint64(5), "hash").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	ok, err := repo.UseRecoveryCode(context.Background(), tx, "consumer", 5, "hash")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = repo.UseRecoveryCode(context.Background(), tx, "consumer", 5, "hash")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type PurchaseAuthorizationRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, a *entity.PurchaseAuthorization) (uint64, error)
+	FindByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*entity.PurchaseAuthorization, error)
+	MarkUsed(ctx context.Context, tx *sql.Tx, id uint64) error
+}
+
+type purchaseAuthorizationRepo struct {
+	db *sql.DB
+}
+
+func NewPurchaseAuthorizationRepo(db *sql.DB) PurchaseAuthorizationRepository {
+	return &purchaseAuthorizationRepo{db}
+}
+
+func (r *purchaseAuthorizationRepo) Create(ctx context.Context, tx *sql.Tx, a *entity.PurchaseAuthorization) (uint64, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO purchase_authorizations (consumer_id, merchant_id, asset_id, tenor_month, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.ConsumerID, a.MerchantID, a.AssetID, a.TenorMonth, a.TokenHash, a.ExpiresAt, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *purchaseAuthorizationRepo) FindByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*entity.PurchaseAuthorization, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, consumer_id, merchant_id, asset_id, tenor_month, token_hash, expires_at, used_at, created_at
+		FROM purchase_authorizations WHERE token_hash = ? FOR UPDATE`, hash)
+
+	var a entity.PurchaseAuthorization
+	var usedAt sql.NullTime
+	err := row.Scan(&a.ID, &a.ConsumerID, &a.MerchantID, &a.AssetID, &a.TenorMonth, &a.TokenHash, &a.ExpiresAt, &usedAt, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		a.UsedAt = &usedAt.Time
+	}
+	return &a, nil
+}
+
+func (r *purchaseAuthorizationRepo) MarkUsed(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE purchase_authorizations SET used_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	return err
+}
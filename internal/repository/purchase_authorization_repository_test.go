@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupPurchaseAuthorizationMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, PurchaseAuthorizationRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewPurchaseAuthorizationRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestPurchaseAuthorizationRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupPurchaseAuthorizationMockDB(t)
+	defer cleanup()
+
+	expires := time.Now().Add(10 * time.Minute)
+	a := &entity.PurchaseAuthorization{ConsumerID: 1, MerchantID: 7, AssetID: 3, TenorMonth: 6, TokenHash: "hash", ExpiresAt: expires}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO purchase_authorizations (consumer_id, merchant_id, asset_id, tenor_month, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(1), uint64(7), uint64(3), uint8(6), "hash", expires, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.Create(context.Background(), tx, a)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurchaseAuthorizationRepo_FindByHashForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupPurchaseAuthorizationMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "consumer_id", "merchant_id", "asset_id", "tenor_month", "token_hash", "expires_at", "used_at", "created_at"}).
+		AddRow(4, 1, 7, 3, 6, "hash", now.Add(time.Minute), nil, now)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM purchase_authorizations WHERE token_hash = ? FOR UPDATE`)).
+		WithArgs("hash").
+		WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE purchase_authorizations SET used_at = ? WHERE id = ?`)).
+		WithArgs(sqlmock.AnyArg(), uint64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	a, err := repo.FindByHashForUpdate(context.Background(), tx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), a.MerchantID)
+	assert.Nil(t, a.UsedAt)
+	assert.True(t, a.Redeemable(now))
+	assert.NoError(t, repo.MarkUsed(context.Background(), tx, a.ID))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, t *entity.RefreshToken) error
+	FindByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error)
+	MarkRotated(ctx context.Context, tx *sql.Tx, id uint64) error
+	RevokeByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error
+	RevokeByFamily(ctx context.Context, tx *sql.Tx, familyID string) error
+	RevokeOthersByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, keepFamilyID string) error
+}
+
+type refreshTokenRepo struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepo(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepo{db}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, tx *sql.Tx, t *entity.RefreshToken) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (auth_user_id, family_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		t.AuthUserID, t.FamilyID, t.TokenHash, t.ExpiresAt, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *refreshTokenRepo) FindByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, auth_user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+		FROM refresh_tokens WHERE token_hash = ? FOR UPDATE`, hash)
+
+	var t entity.RefreshToken
+	var rotatedAt, revokedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.AuthUserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &rotatedAt, &revokedAt, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if rotatedAt.Valid {
+		t.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+func (r *refreshTokenRepo) MarkRotated(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET rotated_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ? WHERE auth_user_id = ? AND revoked_at IS NULL`, time.Now().UTC(), authUserID)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeByFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`, time.Now().UTC(), familyID)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeOthersByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, keepFamilyID string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ? WHERE auth_user_id = ? AND family_id <> ? AND revoked_at IS NULL`, time.Now().UTC(), authUserID, keepFamilyID)
+	return err
+}
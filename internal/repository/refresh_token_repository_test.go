@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupRefreshTokenMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, RefreshTokenRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewRefreshTokenRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestRefreshTokenRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupRefreshTokenMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO refresh_tokens (auth_user_id, family_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(5), "fam", "hash", expires, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(ctx, tx, &entity.RefreshToken{AuthUserID: 5, FamilyID: "fam", TokenHash: "hash", ExpiresAt: expires})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepo_FindByHashForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupRefreshTokenMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, auth_user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+		FROM refresh_tokens WHERE token_hash = ? FOR UPDATE`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "auth_user_id", "family_id", "token_hash", "expires_at", "rotated_at", "revoked_at", "created_at",
+		}).AddRow(3, 5, "fam", "hash", now, now, nil, now))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	rt, err := repo.FindByHashForUpdate(ctx, tx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), rt.ID)
+	assert.NotNil(t, rt.RotatedAt)
+	assert.Nil(t, rt.RevokedAt)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepo_RevokeByAuthUser(t *testing.T) {
+	db, mock, repo, cleanup := setupRefreshTokenMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens SET revoked_at = ? WHERE auth_user_id = ? AND revoked_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), uint64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.RevokeByAuthUser(ctx, tx, 5)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
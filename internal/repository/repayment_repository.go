@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type RepaymentRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, p *entity.Repayment) (uint64, error)
+	ListByTransaction(ctx context.Context, transactionID uint64) ([]*entity.Repayment, error)
+	ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Repayment, error)
+}
+
+type repaymentRepo struct {
+	db *sql.DB
+}
+
+func NewRepaymentRepo(db *sql.DB) RepaymentRepository {
+	return &repaymentRepo{db}
+}
+
+func (r *repaymentRepo) Create(ctx context.Context, tx *sql.Tx, p *entity.Repayment) (uint64, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO repayments (transaction_id, consumer_id, kind, amount, principal, reference, recorded_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.TransactionID, p.ConsumerID, p.Kind, p.Amount, p.Principal, p.Reference, p.RecordedBy, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *repaymentRepo) ListByTransaction(ctx context.Context, transactionID uint64) ([]*entity.Repayment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, transaction_id, consumer_id, kind, amount, principal, reference, recorded_by, created_at
+		FROM repayments WHERE transaction_id = ? ORDER BY id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRepayments(rows)
+}
+
+func (r *repaymentRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Repayment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, transaction_id, consumer_id, kind, amount, principal, reference, recorded_by, created_at
+		FROM repayments WHERE consumer_id = ? ORDER BY id`, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRepayments(rows)
+}
+
+func scanRepayments(rows *sql.Rows) ([]*entity.Repayment, error) {
+	defer rows.Close()
+
+	var res []*entity.Repayment
+	for rows.Next() {
+		var p entity.Repayment
+		var recordedBy sql.NullInt64
+		err := rows.Scan(&p.ID, &p.TransactionID, &p.ConsumerID, &p.Kind, &p.Amount, &p.Principal, &p.Reference, &recordedBy, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if recordedBy.Valid {
+			v := uint64(recordedBy.Int64)
+			p.RecordedBy = &v
+		}
+		res = append(res, &p)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupRepaymentMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, RepaymentRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewRepaymentRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestRepaymentRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupRepaymentMockDB(t)
+	defer cleanup()
+
+	staffID := uint64(3)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO repayments`)).
+		WithArgs(uint64(42), uint64(17), entity.RepaymentInstallment, int64(370000), int64(333333), "VA-001", &staffID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.Create(context.Background(), tx, &entity.Repayment{
+		TransactionID: 42,
+		ConsumerID:    17,
+		Kind:          entity.RepaymentInstallment,
+		Amount:        370000,
+		Principal:     333333,
+		Reference:     "VA-001",
+		RecordedBy:    &staffID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepaymentRepo_ListByTransaction(t *testing.T) {
+	_, mock, repo, cleanup := setupRepaymentMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "transaction_id", "consumer_id", "kind", "amount", "principal", "reference", "recorded_by", "created_at"}).
+		AddRow(5, 42, 17, "INSTALLMENT", 370000, 333333, "VA-001", 3, now).
+		AddRow(6, 42, 17, "SETTLEMENT", 740000, 666667, "", nil, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM repayments WHERE transaction_id = ? ORDER BY id`)).
+		WithArgs(uint64(42)).
+		WillReturnRows(rows)
+
+	list, err := repo.ListByTransaction(context.Background(), 42)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, uint64(3), *list[0].RecordedBy)
+	assert.Nil(t, list[1].RecordedBy)
+	assert.Equal(t, entity.RepaymentSettlement, list[1].Kind)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepaymentRepo_ListByConsumer(t *testing.T) {
+	_, mock, repo, cleanup := setupRepaymentMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "transaction_id", "consumer_id", "kind", "amount", "principal", "reference", "recorded_by", "created_at"}).
+		AddRow(5, 42, 17, "INSTALLMENT", 370000, 333333, "VA-001", 3, time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM repayments WHERE consumer_id = ? ORDER BY id`)).
+		WithArgs(uint64(17)).
+		WillReturnRows(rows)
+
+	list, err := repo.ListByConsumer(context.Background(), 17)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, uint64(42), list[0].TransactionID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, s *entity.Session) error
+	FindByID(ctx context.Context, id string) (*entity.Session, error)
+	ListActiveByAuthUser(ctx context.Context, authUserID uint64) ([]*entity.Session, error)
+	Touch(ctx context.Context, tx *sql.Tx, id string, expiresAt time.Time) error
+	Revoke(ctx context.Context, tx *sql.Tx, id string) error
+	RevokeByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error
+	RevokeOthersByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, keepID string) error
+	RevokeOthersByStaffUser(ctx context.Context, tx *sql.Tx, staffUserID uint64, keepID string) error
+}
+
+type sessionRepo struct {
+	db *sql.DB
+}
+
+func NewSessionRepo(db *sql.DB) SessionRepository {
+	return &sessionRepo{db}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, tx *sql.Tx, s *entity.Session) error {
+	now := time.Now().UTC()
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO sessions (id, auth_user_id, staff_user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, nullableID(s.AuthUserID), nullableID(s.StaffUserID), s.UserAgent, s.IPAddress, now, now, s.ExpiresAt,
+	)
+	return err
+}
+
+func (r *sessionRepo) FindByID(ctx context.Context, id string) (*entity.Session, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, auth_user_id, staff_user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions WHERE id = ?`, id)
+	return scanSession(row)
+}
+
+func (r *sessionRepo) ListActiveByAuthUser(ctx context.Context, authUserID uint64) ([]*entity.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, auth_user_id, staff_user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions WHERE auth_user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_seen_at DESC`, authUserID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*entity.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func (r *sessionRepo) Touch(ctx context.Context, tx *sql.Tx, id string, expiresAt time.Time) error {
+	_, err := tx.ExecContext(ctx, `UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?`, time.Now().UTC(), expiresAt, id)
+	return err
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now().UTC(), id)
+	return err
+}
+
+func (r *sessionRepo) RevokeByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = ? WHERE auth_user_id = ? AND revoked_at IS NULL`, time.Now().UTC(), authUserID)
+	return err
+}
+
+func (r *sessionRepo) RevokeOthersByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, keepID string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = ? WHERE auth_user_id = ? AND id <> ? AND revoked_at IS NULL`, time.Now().UTC(), authUserID, keepID)
+	return err
+}
+
+func (r *sessionRepo) RevokeOthersByStaffUser(ctx context.Context, tx *sql.Tx, staffUserID uint64, keepID string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE sessions SET revoked_at = ? WHERE staff_user_id = ? AND id <> ? AND revoked_at IS NULL`, time.Now().UTC(), staffUserID, keepID)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (*entity.Session, error) {
+	var s entity.Session
+	var authUserID, staffUserID sql.NullInt64
+	var revokedAt sql.NullTime
+	if err := row.Scan(&s.ID, &authUserID, &staffUserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	s.AuthUserID = uint64(authUserID.Int64)
+	s.StaffUserID = uint64(staffUserID.Int64)
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return &s, nil
+}
+
+func nullableID(id uint64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
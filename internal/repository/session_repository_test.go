@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupSessionMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, SessionRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewSessionRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestSessionRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupSessionMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO sessions (id, auth_user_id, staff_user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)).
+		WithArgs("sess", uint64(5), nil, "okhttp/4", "10.0.0.1", sqlmock.AnyArg(), sqlmock.AnyArg(), expires).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(ctx, tx, &entity.Session{ID: "sess", AuthUserID: 5, UserAgent: "okhttp/4", IPAddress: "10.0.0.1", ExpiresAt: expires})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepo_FindByID(t *testing.T) {
+	_, mock, repo, cleanup := setupSessionMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, auth_user_id, staff_user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions WHERE id = ?`)).
+		WithArgs("sess").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "auth_user_id", "staff_user_id", "user_agent", "ip_address", "created_at", "last_seen_at", "expires_at", "revoked_at",
+		}).AddRow("sess", 5, nil, "okhttp/4", "10.0.0.1", now, now, now.Add(time.Hour), now))
+
+	s, err := repo.FindByID(ctx, "sess")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), s.AuthUserID)
+	assert.NotNil(t, s.RevokedAt)
+	assert.False(t, s.Active(now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepo_ListActiveByAuthUser(t *testing.T) {
+	_, mock, repo, cleanup := setupSessionMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, auth_user_id, staff_user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions WHERE auth_user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_seen_at DESC`)).
+		WithArgs(uint64(5), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "auth_user_id", "staff_user_id", "user_agent", "ip_address", "created_at", "last_seen_at", "expires_at", "revoked_at",
+		}).
+			AddRow("a", 5, nil, "okhttp/4", "10.0.0.1", now, now, now.Add(time.Hour), nil).
+			AddRow("b", 5, nil, "Mozilla/5.0", "10.0.0.2", now, now, now.Add(time.Hour), nil))
+
+	list, err := repo.ListActiveByAuthUser(ctx, 5)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.True(t, list[0].Active(now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepo_RevokeByAuthUser(t *testing.T) {
+	db, mock, repo, cleanup := setupSessionMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE sessions SET revoked_at = ? WHERE auth_user_id = ? AND revoked_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), uint64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.RevokeByAuthUser(ctx, tx, 5))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepo_RevokeOthersByAuthUser(t *testing.T) {
+	db, mock, repo, cleanup := setupSessionMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE sessions SET revoked_at = ? WHERE auth_user_id = ? AND id <> ? AND revoked_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), uint64(5), "keep").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	assert.NoError(t, repo.RevokeOthersByAuthUser(ctx, tx, 5, "keep"))
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
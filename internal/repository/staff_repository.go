@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type StaffRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, s *entity.StaffUser) (uint64, error)
+	FindByEmail(ctx context.Context, email string) (*entity.StaffUser, error)
+	FindByID(ctx context.Context, id uint64) (*entity.StaffUser, error)
+	CountByRole(ctx context.Context, tx *sql.Tx, role entity.Role) (int, error)
+	UpdatePassword(ctx context.Context, tx *sql.Tx, id uint64, hash string) error
+}
+
+type staffRepo struct {
+	db *sql.DB
+}
+
+func NewStaffRepo(db *sql.DB) StaffRepository {
+	return &staffRepo{db}
+}
+
+func (r *staffRepo) Create(ctx context.Context, tx *sql.Tx, s *entity.StaffUser) (uint64, error) {
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO staff_users (email, password, full_name, role, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.Email, s.Password, s.FullName, s.Role, s.Active, now, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(lastID), nil
+}
+
+func (r *staffRepo) FindByEmail(ctx context.Context, email string) (*entity.StaffUser, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, email, password, full_name, role, is_active, created_at, updated_at
+		FROM staff_users WHERE email = ?`, email)
+	return scanStaffUser(row)
+}
+
+func (r *staffRepo) FindByID(ctx context.Context, id uint64) (*entity.StaffUser, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, email, password, full_name, role, is_active, created_at, updated_at
+		FROM staff_users WHERE id = ?`, id)
+	return scanStaffUser(row)
+}
+
+func (r *staffRepo) CountByRole(ctx context.Context, tx *sql.Tx, role entity.Role) (int, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM staff_users WHERE role = ? FOR UPDATE`, role).Scan(&n)
+	return n, err
+}
+
+func scanStaffUser(row rowScanner) (*entity.StaffUser, error) {
+	var s entity.StaffUser
+	if err := row.Scan(&s.ID, &s.Email, &s.Password, &s.FullName, &s.Role, &s.Active, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *staffRepo) UpdatePassword(ctx context.Context, tx *sql.Tx, id uint64, hash string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE staff_users SET password = ?, updated_at = ? WHERE id = ?`, hash, time.Now().UTC(), id)
+	return err
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupStaffMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, StaffRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewStaffRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestStaffRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupStaffMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	staff := &entity.StaffUser{Email: "admin@multifinance.id", Password: "hash", FullName: "Admin", Role: entity.RoleAdmin, Active: true}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO staff_users (email, password, full_name, role, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)).
+		WithArgs(staff.Email, staff.Password, staff.FullName, staff.Role, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	id, err := repo.Create(ctx, tx, staff)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), id)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStaffRepo_FindByEmail(t *testing.T) {
+	_, mock, repo, cleanup := setupStaffMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, email, password, full_name, role, is_active, created_at, updated_at
+		FROM staff_users WHERE email = ?`)).
+		WithArgs("admin@multifinance.id").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "password", "full_name", "role", "is_active", "created_at", "updated_at",
+		}).AddRow(3, "admin@multifinance.id", "hash", "Admin", "admin", true, now, now))
+
+	staff, err := repo.FindByEmail(ctx, "admin@multifinance.id")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), staff.ID)
+	assert.Equal(t, entity.RoleAdmin, staff.Role)
+	assert.True(t, staff.Active)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStaffRepo_FindByID_NotFound(t *testing.T) {
+	_, mock, repo, cleanup := setupStaffMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, email, password, full_name, role, is_active, created_at, updated_at
+		FROM staff_users WHERE id = ?`)).
+		WithArgs(uint64(99)).
+		WillReturnError(sql.ErrNoRows)
+
+	staff, err := repo.FindByID(ctx, 99)
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Nil(t, staff)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, tx *sql.Tx, t *entity.UserToken) error
+	FindByHashForUpdate(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error)
+	MarkUsed(ctx context.Context, tx *sql.Tx, id uint64) error
+	InvalidateByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, purpose entity.TokenPurpose) error
+	DeleteByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error
+}
+
+type userTokenRepo struct {
+	db *sql.DB
+}
+
+func NewUserTokenRepo(db *sql.DB) UserTokenRepository {
+	return &userTokenRepo{db}
+}
+
+func (r *userTokenRepo) Create(ctx context.Context, tx *sql.Tx, t *entity.UserToken) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_tokens (auth_user_id, purpose, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		t.AuthUserID, t.Purpose, t.TokenHash, t.ExpiresAt, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *userTokenRepo) FindByHashForUpdate(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error) {
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, auth_user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens WHERE purpose = ? AND token_hash = ? FOR UPDATE`, purpose, hash)
+
+	var t entity.UserToken
+	var usedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.AuthUserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &usedAt, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	return &t, nil
+}
+
+func (r *userTokenRepo) MarkUsed(ctx context.Context, tx *sql.Tx, id uint64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE user_tokens SET used_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	return err
+}
+
+// InvalidateByAuthUser burns every outstanding token of the purpose so only
+// the most recently issued one can be redeemed.
+func (r *userTokenRepo) InvalidateByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, purpose entity.TokenPurpose) error {
+	_, err := tx.ExecContext(ctx, `UPDATE user_tokens SET used_at = ? WHERE auth_user_id = ? AND purpose = ? AND used_at IS NULL`, time.Now().UTC(), authUserID, purpose)
+	return err
+}
+
+// DeleteByAuthUser removes every token of the login, used or not.
+func (r *userTokenRepo) DeleteByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM user_tokens WHERE auth_user_id = ?`, authUserID)
+	return err
+}
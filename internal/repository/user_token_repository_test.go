@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"multifinance-core/internal/domain/entity"
+)
+
+func setupUserTokenMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, UserTokenRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+
+	repo := NewUserTokenRepo(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return db, mock, repo, cleanup
+}
+
+func TestUserTokenRepo_Create(t *testing.T) {
+	db, mock, repo, cleanup := setupUserTokenMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+		INSERT INTO user_tokens (auth_user_id, purpose, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)`)).
+		WithArgs(uint64(5), entity.TokenPurposePasswordReset, "hash", expires, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.Create(ctx, tx, &entity.UserToken{AuthUserID: 5, Purpose: entity.TokenPurposePasswordReset, TokenHash: "hash", ExpiresAt: expires})
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserTokenRepo_FindByHashForUpdate(t *testing.T) {
+	db, mock, repo, cleanup := setupUserTokenMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows([]string{"id", "auth_user_id", "purpose", "token_hash", "expires_at", "used_at", "created_at"}).
+		AddRow(1, 5, "password_reset", "hash", now.Add(time.Hour), now, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, auth_user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens WHERE purpose = ? AND token_hash = ? FOR UPDATE`)).
+		WithArgs(entity.TokenPurposePasswordReset, "hash").
+		WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	tx, _ := db.Begin()
+	token, err := repo.FindByHashForUpdate(ctx, tx, entity.TokenPurposePasswordReset, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), token.AuthUserID)
+	assert.Equal(t, entity.TokenPurposePasswordReset, token.Purpose)
+	assert.NotNil(t, token.UsedAt)
+
+	tx.Rollback()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserTokenRepo_InvalidateByAuthUser(t *testing.T) {
+	db, mock, repo, cleanup := setupUserTokenMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_tokens SET used_at = ? WHERE auth_user_id = ? AND purpose = ? AND used_at IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), uint64(5), entity.TokenPurposePasswordReset).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.InvalidateByAuthUser(context.Background(), tx, 5, entity.TokenPurposePasswordReset)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserTokenRepo_DeleteByAuthUser(t *testing.T) {
+	db, mock, repo, cleanup := setupUserTokenMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_tokens WHERE auth_user_id = ?`)).
+		WithArgs(uint64(50)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	tx, _ := db.Begin()
+	err := repo.DeleteByAuthUser(context.Background(), tx, 50)
+	assert.NoError(t, err)
+
+	tx.Commit()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -11,12 +11,14 @@ type CreateAssetRequest struct {
 	ProductName  string  `json:"product_name" binding:"required"`
 	PriceProduct float64 `json:"price_product" binding:"required"`
 	Seller       string  `json:"seller" binding:"required"`
+	MerchantID   *uint64 `json:"merchant_id"`
 }
 
 type UpdateAssetRequest struct {
 	ProductName  string  `json:"product_name" binding:"required"`
 	PriceProduct float64 `json:"price_product" binding:"required"`
 	Seller       string  `json:"seller" binding:"required"`
+	MerchantID   *uint64 `json:"merchant_id"`
 }
 
 type AssetUsecase struct {
@@ -39,6 +41,7 @@ func (u *AssetUsecase) Create(ctx context.Context, req CreateAssetRequest) (uint
 		ProductName:  req.ProductName,
 		PriceProduct: req.PriceProduct,
 		Seller:       req.Seller,
+		MerchantID:   req.MerchantID,
 	}
 
 	id, err := u.repo.Create(ctx, tx, a)
@@ -72,6 +75,7 @@ func (u *AssetUsecase) Update(ctx context.Context, id uint64, req UpdateAssetReq
 		ProductName:  req.ProductName,
 		PriceProduct: req.PriceProduct,
 		Seller:       req.Seller,
+		MerchantID:   req.MerchantID,
 	}
 
 	if err := u.repo.Update(ctx, tx, a); err != nil {
@@ -14,11 +14,12 @@ import (
 )
 
 type mockAssetRepo struct {
-	createFn func(ctx context.Context, tx *sql.Tx, a *entity.Asset) (uint64, error)
-	getFn    func(ctx context.Context, id uint64) (*entity.Asset, error)
-	listFn   func(ctx context.Context) ([]*entity.Asset, error)
-	updateFn func(ctx context.Context, tx *sql.Tx, a *entity.Asset) error
-	deleteFn func(ctx context.Context, tx *sql.Tx, id uint64) error
+	createFn  func(ctx context.Context, tx *sql.Tx, a *entity.Asset) (uint64, error)
+	getFn     func(ctx context.Context, id uint64) (*entity.Asset, error)
+	listFn    func(ctx context.Context) ([]*entity.Asset, error)
+	byMerchFn func(ctx context.Context, merchantID uint64) ([]*entity.Asset, error)
+	updateFn  func(ctx context.Context, tx *sql.Tx, a *entity.Asset) error
+	deleteFn  func(ctx context.Context, tx *sql.Tx, id uint64) error
 }
 
 func (m *mockAssetRepo) Create(ctx context.Context, tx *sql.Tx, a *entity.Asset) (uint64, error) {
@@ -39,6 +40,12 @@ func (m *mockAssetRepo) List(ctx context.Context) ([]*entity.Asset, error) {
 	}
 	return nil, nil
 }
+func (m *mockAssetRepo) ListByMerchant(ctx context.Context, merchantID uint64) ([]*entity.Asset, error) {
+	if m.byMerchFn != nil {
+		return m.byMerchFn(ctx, merchantID)
+	}
+	return nil, nil
+}
 func (m *mockAssetRepo) Update(ctx context.Context, tx *sql.Tx, a *entity.Asset) error {
 	if m.updateFn != nil {
 		return m.updateFn(ctx, tx, a)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"multifinance-core/internal/domain/entity"
@@ -12,34 +14,79 @@ import (
 )
 
 type RegisterRequest struct {
-	NIK         string  `json:"nik" binding:"required"`
-	FullName    string  `json:"full_name" binding:"required"`
-	LegalName   string  `json:"legal_name" binding:"required"`
-	BirthPlace  string  `json:"birth_place" binding:"required"`
-	BirthDate   string  `json:"birth_date" binding:"required"`
-	Salary      float64 `json:"salary" binding:"required"`
-	Email       string  `json:"email" binding:"required,email"`
-	KTPPhoto    string  `json:"ktp_photo" binding:"required"`
-	SelfiePhoto string  `json:"selfie_photo" binding:"required"`
-	Password    string  `json:"password" binding:"required"`
+	NIK              string  `json:"nik" binding:"required"`
+	FullName         string  `json:"full_name" binding:"required"`
+	LegalName        string  `json:"legal_name" binding:"required"`
+	BirthPlace       string  `json:"birth_place" binding:"required"`
+	BirthDate        string  `json:"birth_date" binding:"required"`
+	Salary           float64 `json:"salary" binding:"required"`
+	Email            string  `json:"email" binding:"required,email"`
+	KTPDocumentID    string  `json:"ktp_document_id" binding:"required"`
+	SelfieDocumentID string  `json:"selfie_document_id" binding:"required"`
+	Password         string  `json:"password" binding:"required"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// LoginResult holds either a token pair or, when a second factor is needed,
+// an MFA token to send back together with the code.
+type LoginResult struct {
+	*TokenPair
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string   `json:"mfa_token,omitempty"`
+	MFAExpiresIn          int64    `json:"mfa_expires_in,omitempty"`
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
 type AuthUsecase struct {
-	db           *sql.DB
-	consumerRepo repository.ConsumerRepository
-	authRepo     repository.AuthRepository
+	db               *sql.DB
+	consumerRepo     repository.ConsumerRepository
+	authRepo         repository.AuthRepository
+	docRepo          repository.DocumentRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	sessionRepo      repository.SessionRepository
+	tokens           *utils.TokenManager
+	refreshTTL       time.Duration
+	guard            *LoginGuard
+	policy           utils.PasswordPolicy
+	mfa              *MFAUsecase
+	limitPolicyRepo  repository.LimitPolicyRepository
 }
 
-func NewAuthUsecase(db *sql.DB, c repository.ConsumerRepository, a repository.AuthRepository) *AuthUsecase {
-	return &AuthUsecase{db, c, a}
+func NewAuthUsecase(db *sql.DB, c repository.ConsumerRepository, a repository.AuthRepository, d repository.DocumentRepository, rt repository.RefreshTokenRepository, s repository.SessionRepository, tokens *utils.TokenManager, refreshTTL time.Duration, guard *LoginGuard, policy utils.PasswordPolicy, mfa *MFAUsecase, lp repository.LimitPolicyRepository) *AuthUsecase {
+	return &AuthUsecase{db, c, a, d, rt, s, tokens, refreshTTL, guard, policy, mfa, lp}
 }
 
 func (u *AuthUsecase) Register(ctx context.Context, req RegisterRequest) error {
+	if err := u.policy.Validate(req.Password); err != nil {
+		return err
+	}
+	req.NIK = strings.TrimSpace(req.NIK)
+	if err := checkIdentity(req.NIK, req.BirthDate); err != nil {
+		return err
+	}
+
 	tx, err := u.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
@@ -49,21 +96,35 @@ func (u *AuthUsecase) Register(ctx context.Context, req RegisterRequest) error {
 	defer tx.Rollback()
 
 	consumer := &entity.Consumer{
-		NIK:         req.NIK,
-		FullName:    req.FullName,
-		LegalName:   req.LegalName,
-		BirthPlace:  req.BirthPlace,
-		BirthDate:   req.BirthDate,
-		KTPPhoto:    req.KTPPhoto,
-		SelfiePhoto: req.SelfiePhoto,
-		Salary:      req.Salary,
+		NIK:              req.NIK,
+		FullName:         req.FullName,
+		LegalName:        req.LegalName,
+		BirthPlace:       req.BirthPlace,
+		BirthDate:        req.BirthDate,
+		KTPDocumentID:    req.KTPDocumentID,
+		SelfieDocumentID: req.SelfieDocumentID,
+		Salary:           req.Salary,
 	}
 
+	if err := ensureNIKAvailable(ctx, u.consumerRepo, tx, req.NIK); err != nil {
+		return err
+	}
 	consumerID, err := u.consumerRepo.Create(ctx, tx, consumer)
+	if isDuplicateEntry(err) {
+		return ErrNIKAlreadyRegistered
+	}
 	if err != nil {
 		return err
 	}
 
+	// Photos are uploaded before registration and claimed here.
+	if err := attachDocument(ctx, u.docRepo, tx, consumerID, req.KTPDocumentID, entity.DocumentKTP); err != nil {
+		return err
+	}
+	if err := attachDocument(ctx, u.docRepo, tx, consumerID, req.SelfieDocumentID, entity.DocumentSelfie); err != nil {
+		return err
+	}
+
 	hash, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return err
@@ -78,14 +139,18 @@ func (u *AuthUsecase) Register(ctx context.Context, req RegisterRequest) error {
 		return err
 	}
 
-	tenors := []uint8{1, 2, 3, 6}
+	// Limits stay inactive until the consumer's KYC case is approved. New
+	// consumers are ungraded, so no risk adjustment applies yet.
+	version, limits, err := activeLimits(ctx, u.limitPolicyRepo, req.Salary, "")
+	if err != nil {
+		return err
+	}
 	now := time.Now().UTC()
-	for _, t := range tenors {
-		maxLimit := req.Salary * 0.4 * float64(t)
+	for _, l := range limits {
 		_, err := tx.ExecContext(ctx, `
-			INSERT INTO consumer_limits (consumer_id, tenor_month, max_limit, used_limit, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?)`,
-			consumerID, t, maxLimit, 0.0, now, now,
+			INSERT INTO consumer_limits (consumer_id, tenor_month, max_limit, used_limit, active, policy_version, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			consumerID, l.Tenor, l.MaxLimit, 0.0, false, version, now, now,
 		)
 		if err != nil {
 			return err
@@ -95,16 +160,220 @@ func (u *AuthUsecase) Register(ctx context.Context, req RegisterRequest) error {
 	return tx.Commit()
 }
 
-func (u *AuthUsecase) Login(ctx context.Context, req LoginRequest) (string, error) {
+// Login checks the password. Users with MFA enabled get an MFA token instead
+// of a token pair and finish with LoginMFA.
+func (u *AuthUsecase) Login(ctx context.Context, req LoginRequest) (*LoginResult, error) {
+	if err := u.guard.Allow(utils.SubjectConsumer, req.Email, req.IPAddress); err != nil {
+		return nil, err
+	}
+
 	user, err := u.authRepo.FindByEmail(ctx, req.Email)
+	if err == sql.ErrNoRows {
+		u.guard.Fail(utils.SubjectConsumer, req.Email, req.IPAddress)
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if err := utils.ComparePassword(user.Password, req.Password); err != nil {
-		return "", errors.New("invalid credentials")
+		u.guard.Fail(utils.SubjectConsumer, req.Email, req.IPAddress)
+		return nil, ErrInvalidCredentials
+	}
+	if err := checkCanLogin(ctx, u.consumerRepo, user.ConsumerID); err != nil {
+		return nil, err
 	}
 
-	token := req.Email + ":" + time.Now().UTC().Format(time.RFC3339)
-	return token, nil
+	subject := ConsumerMFASubject(user)
+	enabled, err := u.mfa.Enabled(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		res, err := u.mfa.Challenge(subject, user.ConsumerID)
+		if err != nil {
+			return nil, err
+		}
+		res.MFARequired = true
+		return res, nil
+	}
+
+	u.guard.Succeed(utils.SubjectConsumer, req.Email)
+	return u.startSession(ctx, user, req.UserAgent, req.IPAddress)
+}
+
+// LoginMFA completes a login with a TOTP or recovery code. Wrong codes count
+// as failed logins for the lockout.
+func (u *AuthUsecase) LoginMFA(ctx context.Context, req MFALoginRequest) (*LoginResult, error) {
+	id, err := u.mfa.ParseChallenge(req.MFAToken, utils.SubjectConsumer)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.authRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanLogin(ctx, u.consumerRepo, user.ConsumerID); err != nil {
+		return nil, err
+	}
+
+	if err := u.guard.Allow(utils.SubjectConsumer, user.Email, req.IPAddress); err != nil {
+		return nil, err
+	}
+	if err := u.mfa.Verify(ctx, ConsumerMFASubject(user), req.Code); err != nil {
+		if err == ErrInvalidMFACode {
+			u.guard.Fail(utils.SubjectConsumer, user.Email, req.IPAddress)
+		}
+		return nil, err
+	}
+	u.guard.Succeed(utils.SubjectConsumer, user.Email)
+
+	return u.startSession(ctx, user, req.UserAgent, req.IPAddress)
+}
+
+func (u *AuthUsecase) startSession(ctx context.Context, user *entity.AuthUser, userAgent, ip string) (*LoginResult, error) {
+	sessionID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = u.sessionRepo.Create(ctx, tx, &entity.Session{
+		ID:         sessionID,
+		AuthUserID: user.ID,
+		UserAgent:  truncate(userAgent, 255),
+		IPAddress:  ip,
+		ExpiresAt:  time.Now().UTC().Add(u.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pair, err := u.issueTokenPair(ctx, tx, user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: pair}, nil
+}
+
+// Unlock clears the failed-login counters of an account locked by LoginGuard.
+// subject says whether it is a consumer or a staff account.
+func (u *AuthUsecase) Unlock(subject, email string) {
+	u.guard.Unlock(subject, email)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Each refresh token
+// can be used once; presenting one that was already rotated means it leaked,
+// so every refresh token and session of that user is revoked.
+func (u *AuthUsecase) Refresh(ctx context.Context, req RefreshRequest) (*TokenPair, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rt, err := u.refreshTokenRepo.FindByHashForUpdate(ctx, tx, utils.HashToken(req.RefreshToken))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rt.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if rt.RotatedAt != nil {
+		if err := u.refreshTokenRepo.RevokeByAuthUser(ctx, tx, rt.AuthUserID); err != nil {
+			return nil, err
+		}
+		if err := u.sessionRepo.RevokeByAuthUser(ctx, tx, rt.AuthUserID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if !time.Now().UTC().Before(rt.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := u.authRepo.FindByID(ctx, rt.AuthUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanLogin(ctx, u.consumerRepo, user.ConsumerID); err != nil {
+		return nil, err
+	}
+
+	if err := u.refreshTokenRepo.MarkRotated(ctx, tx, rt.ID); err != nil {
+		return nil, err
+	}
+	if err := u.sessionRepo.Touch(ctx, tx, rt.FamilyID, time.Now().UTC().Add(u.refreshTTL)); err != nil {
+		return nil, err
+	}
+
+	pair, err := u.issueTokenPair(ctx, tx, user, rt.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// issueTokenPair signs an access token for the session and stores a fresh
+// refresh token in the same family; the session ID doubles as the family ID.
+func (u *AuthUsecase) issueTokenPair(ctx context.Context, tx *sql.Tx, user *entity.AuthUser, sessionID string) (*TokenPair, error) {
+	access, claims, err := u.tokens.Issue(utils.TokenClaims{
+		Subject:     strconv.FormatUint(user.ID, 10),
+		SubjectType: utils.SubjectConsumer,
+		ConsumerID:  user.ConsumerID,
+		SessionID:   sessionID,
+		Role:        string(entity.RoleConsumer),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.refreshTokenRepo.Create(ctx, tx, &entity.RefreshToken{
+		AuthUserID: user.ID,
+		FamilyID:   sessionID,
+		TokenHash:  utils.HashToken(refresh),
+		ExpiresAt:  time.Now().UTC().Add(u.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    claims.ExpiresAt - claims.IssuedAt,
+	}, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
 }
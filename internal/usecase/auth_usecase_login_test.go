@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRefreshTokenRepo struct {
+	createFn       func(ctx context.Context, tx *sql.Tx, t *entity.RefreshToken) error
+	findFn         func(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error)
+	rotateFn       func(ctx context.Context, tx *sql.Tx, id uint64) error
+	revokeFn       func(ctx context.Context, tx *sql.Tx, authUserID uint64) error
+	revokeFamilyFn func(ctx context.Context, tx *sql.Tx, familyID string) error
+	revokeOthersFn func(ctx context.Context, tx *sql.Tx, authUserID uint64, keepFamilyID string) error
+	created        []*entity.RefreshToken
+}
+
+func (m *mockRefreshTokenRepo) Create(ctx context.Context, tx *sql.Tx, t *entity.RefreshToken) error {
+	m.created = append(m.created, t)
+	if m.createFn != nil {
+		return m.createFn(ctx, tx, t)
+	}
+	return nil
+}
+func (m *mockRefreshTokenRepo) FindByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error) {
+	if m.findFn != nil {
+		return m.findFn(ctx, tx, hash)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockRefreshTokenRepo) MarkRotated(ctx context.Context, tx *sql.Tx, id uint64) error {
+	if m.rotateFn != nil {
+		return m.rotateFn(ctx, tx, id)
+	}
+	return nil
+}
+func (m *mockRefreshTokenRepo) RevokeByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+	if m.revokeFn != nil {
+		return m.revokeFn(ctx, tx, authUserID)
+	}
+	return nil
+}
+func (m *mockRefreshTokenRepo) RevokeByFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	if m.revokeFamilyFn != nil {
+		return m.revokeFamilyFn(ctx, tx, familyID)
+	}
+	return nil
+}
+func (m *mockRefreshTokenRepo) RevokeOthersByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, keepFamilyID string) error {
+	if m.revokeOthersFn != nil {
+		return m.revokeOthersFn(ctx, tx, authUserID, keepFamilyID)
+	}
+	return nil
+}
+
+func newTestTokenManager() *utils.TokenManager {
+	return utils.NewTokenManager("multifinance-core", "k1", map[string][]byte{"k1": []byte("test-secret")}, time.Hour)
+}
+
+func TestLogin_IssuesSignedTokenPair(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	hash, err := utils.HashPassword("secret123")
+	require.NoError(t, err)
+
+	authRepo := &mockAuthRepoForRegister{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 5, ConsumerID: 17, Email: email, Password: hash}, nil
+		},
+	}
+	refreshRepo := &mockRefreshTokenRepo{}
+	var session *entity.Session
+	sessionRepo := &mockSessionRepo{
+		createFn: func(ctx context.Context, tx *sql.Tx, s *entity.Session) error {
+			session = s
+			return nil
+		},
+	}
+	tokens := newTestTokenManager()
+	u := NewAuthUsecase(db, &mockConsumerRepo{}, authRepo, &mockDocumentRepo{}, refreshRepo, sessionRepo, tokens, time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, newTestMFA(&mockMFARepo{}), nil)
+
+	pair, err := u.Login(context.Background(), LoginRequest{Email: "budi@mail.com", Password: "secret123", UserAgent: "okhttp/4", IPAddress: "10.0.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, "Bearer", pair.TokenType)
+	require.NotEmpty(t, pair.RefreshToken)
+
+	claims, err := tokens.Parse(pair.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "5", claims.Subject)
+	require.Equal(t, uint64(17), claims.ConsumerID)
+	require.Equal(t, "consumer", claims.Role)
+
+	require.NotNil(t, session)
+	require.Equal(t, session.ID, claims.SessionID)
+	require.Equal(t, "okhttp/4", session.UserAgent)
+	require.Equal(t, "10.0.0.1", session.IPAddress)
+
+	require.Len(t, refreshRepo.created, 1)
+	require.Equal(t, session.ID, refreshRepo.created[0].FamilyID)
+	require.Equal(t, uint64(5), refreshRepo.created[0].AuthUserID)
+	require.Equal(t, utils.HashToken(pair.RefreshToken), refreshRepo.created[0].TokenHash)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	hash, err := utils.HashPassword("secret123")
+	require.NoError(t, err)
+
+	authRepo := &mockAuthRepoForRegister{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 5, ConsumerID: 17, Email: email, Password: hash}, nil
+		},
+	}
+	u := NewAuthUsecase(nil, &mockConsumerRepo{}, authRepo, &mockDocumentRepo{}, &mockRefreshTokenRepo{}, &mockSessionRepo{}, newTestTokenManager(), time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, newTestMFA(&mockMFARepo{}), nil)
+
+	pair, err := u.Login(context.Background(), LoginRequest{Email: "budi@mail.com", Password: "wrong"})
+	require.Error(t, err)
+	require.Nil(t, pair)
+}
+
+func TestLogin_LockedAfterRepeatedFailures(t *testing.T) {
+	hash, err := utils.HashPassword("secret123")
+	require.NoError(t, err)
+
+	lookups := 0
+	authRepo := &mockAuthRepoForRegister{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.AuthUser, error) {
+			lookups++
+			return &entity.AuthUser{ID: 5, ConsumerID: 17, Email: email, Password: hash}, nil
+		},
+	}
+	u := NewAuthUsecase(nil, &mockConsumerRepo{}, authRepo, &mockDocumentRepo{}, &mockRefreshTokenRepo{}, &mockSessionRepo{}, newTestTokenManager(), time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, newTestMFA(&mockMFARepo{}), nil)
+
+	req := LoginRequest{Email: "budi@mail.com", Password: "wrong", IPAddress: "10.0.0.1"}
+	for i := 0; i < 3; i++ {
+		_, err := u.Login(context.Background(), req)
+		require.Equal(t, ErrInvalidCredentials, err)
+	}
+
+	req.Password = "secret123"
+	_, err = u.Login(context.Background(), req)
+	require.ErrorIs(t, err, ErrLoginLocked)
+	require.Equal(t, 3, lookups)
+}
+
+func TestLogin_BlacklistedConsumer(t *testing.T) {
+	hash, err := utils.HashPassword("secret123")
+	require.NoError(t, err)
+
+	authRepo := &mockAuthRepoForRegister{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 5, ConsumerID: 17, Email: email, Password: hash}, nil
+		},
+	}
+	consumerRepo := &mockConsumerRepo{
+		statusFn: func(ctx context.Context, id uint64) (entity.ConsumerStatus, error) {
+			require.Equal(t, uint64(17), id)
+			return entity.ConsumerBlacklisted, nil
+		},
+	}
+	u := NewAuthUsecase(nil, consumerRepo, authRepo, &mockDocumentRepo{}, &mockRefreshTokenRepo{}, &mockSessionRepo{}, newTestTokenManager(), time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, newTestMFA(&mockMFARepo{}), nil)
+
+	_, err = u.Login(context.Background(), LoginRequest{Email: "budi@mail.com", Password: "secret123"})
+	require.ErrorIs(t, err, ErrAccountDisabled)
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func refreshAuthRepo() *mockAuthRepoForRegister {
+	return &mockAuthRepoForRegister{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: id, ConsumerID: 17, Email: "budi@mail.com"}, nil
+		},
+	}
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var rotated uint64
+	refreshRepo := &mockRefreshTokenRepo{
+		findFn: func(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error) {
+			require.Equal(t, utils.HashToken("old-refresh"), hash)
+			return &entity.RefreshToken{ID: 3, AuthUserID: 5, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		rotateFn: func(ctx context.Context, tx *sql.Tx, id uint64) error {
+			rotated = id
+			return nil
+		},
+	}
+
+	var touched string
+	sessionRepo := &mockSessionRepo{
+		touchFn: func(ctx context.Context, tx *sql.Tx, id string, expiresAt time.Time) error {
+			touched = id
+			return nil
+		},
+	}
+
+	u := NewAuthUsecase(db, &mockConsumerRepo{}, refreshAuthRepo(), &mockDocumentRepo{}, refreshRepo, sessionRepo, newTestTokenManager(), time.Hour, nil, utils.DefaultPasswordPolicy, nil, nil)
+	pair, err := u.Refresh(context.Background(), RefreshRequest{RefreshToken: "old-refresh"})
+	require.NoError(t, err)
+	require.Equal(t, "fam", touched)
+	require.NotEqual(t, "old-refresh", pair.RefreshToken)
+	require.Equal(t, uint64(3), rotated)
+	require.Len(t, refreshRepo.created, 1)
+	require.Equal(t, "fam", refreshRepo.created[0].FamilyID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	rotatedAt := time.Now().Add(-time.Minute)
+	var revoked uint64
+	refreshRepo := &mockRefreshTokenRepo{
+		findFn: func(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error) {
+			return &entity.RefreshToken{ID: 3, AuthUserID: 5, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour), RotatedAt: &rotatedAt}, nil
+		},
+		revokeFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			revoked = authUserID
+			return nil
+		},
+	}
+
+	var sessionsRevoked uint64
+	sessionRepo := &mockSessionRepo{
+		revokeByAuthUserFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			sessionsRevoked = authUserID
+			return nil
+		},
+	}
+
+	u := NewAuthUsecase(db, &mockConsumerRepo{}, refreshAuthRepo(), &mockDocumentRepo{}, refreshRepo, sessionRepo, newTestTokenManager(), time.Hour, nil, utils.DefaultPasswordPolicy, nil, nil)
+	pair, err := u.Refresh(context.Background(), RefreshRequest{RefreshToken: "old-refresh"})
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+	require.Nil(t, pair)
+	require.Equal(t, uint64(5), revoked)
+	require.Equal(t, uint64(5), sessionsRevoked)
+	require.Empty(t, refreshRepo.created)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefresh_ExpiredOrUnknown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	refreshRepo := &mockRefreshTokenRepo{
+		findFn: func(ctx context.Context, tx *sql.Tx, hash string) (*entity.RefreshToken, error) {
+			return &entity.RefreshToken{ID: 3, AuthUserID: 5, ExpiresAt: time.Now().Add(-time.Minute)}, nil
+		},
+	}
+	u := NewAuthUsecase(db, &mockConsumerRepo{}, refreshAuthRepo(), &mockDocumentRepo{}, refreshRepo, &mockSessionRepo{}, newTestTokenManager(), time.Hour, nil, utils.DefaultPasswordPolicy, nil, nil)
+	_, err = u.Refresh(context.Background(), RefreshRequest{RefreshToken: "expired"})
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	refreshRepo.findFn = nil
+	_, err = u.Refresh(context.Background(), RefreshRequest{RefreshToken: "unknown"})
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
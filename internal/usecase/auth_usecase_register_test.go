@@ -7,13 +7,25 @@ import (
 	"testing"
 
 	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/require"
 )
 
 type mockConsumerRepo struct {
-	createFn func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error)
+	createFn   func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error)
+	findByIDFn func(ctx context.Context, id uint64) (*entity.Consumer, error)
+	updateFn   func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error
+	existsFn   func(ctx context.Context, tx *sql.Tx, nik string) (bool, error)
+	kycFn      func(ctx context.Context, tx *sql.Tx, id uint64, status entity.KYCStatus) error
+	listKYCFn  func(ctx context.Context, status entity.KYCStatus) ([]*entity.Consumer, error)
+	staleFn    func(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error)
+	statusFn   func(ctx context.Context, id uint64) (entity.ConsumerStatus, error)
+	setStatFn  func(ctx context.Context, tx *sql.Tx, id uint64, status entity.ConsumerStatus, reason string) error
+	eraseFn    func(ctx context.Context, tx *sql.Tx, id uint64) error
+	gradeFn    func(ctx context.Context, tx *sql.Tx, id uint64, grade entity.RiskGrade) error
 }
 
 func (m *mockConsumerRepo) Create(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error) {
@@ -22,9 +34,87 @@ func (m *mockConsumerRepo) Create(ctx context.Context, tx *sql.Tx, c *entity.Con
 	}
 	return 0, nil
 }
+func (m *mockConsumerRepo) FindByID(ctx context.Context, id uint64) (*entity.Consumer, error) {
+	if m.findByIDFn != nil {
+		return m.findByIDFn(ctx, id)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockConsumerRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.Consumer, error) {
+	return m.FindByID(ctx, id)
+}
+func (m *mockConsumerRepo) Update(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error {
+	if m.updateFn != nil {
+		return m.updateFn(ctx, tx, c)
+	}
+	return nil
+}
+
+func (m *mockConsumerRepo) ExistsByNIK(ctx context.Context, tx *sql.Tx, nik string) (bool, error) {
+	if m.existsFn != nil {
+		return m.existsFn(ctx, tx, nik)
+	}
+	return false, nil
+}
+
+func (m *mockConsumerRepo) UpdateKYCStatus(ctx context.Context, tx *sql.Tx, id uint64, status entity.KYCStatus) error {
+	if m.kycFn != nil {
+		return m.kycFn(ctx, tx, id, status)
+	}
+	return nil
+}
+
+func (m *mockConsumerRepo) ListByKYCStatus(ctx context.Context, status entity.KYCStatus) ([]*entity.Consumer, error) {
+	if m.listKYCFn != nil {
+		return m.listKYCFn(ctx, status)
+	}
+	return nil, nil
+}
+
+func (m *mockConsumerRepo) ListIDsForReencryption(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error) {
+	if m.staleFn != nil {
+		return m.staleFn(ctx, tx, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockConsumerRepo) FindStatus(ctx context.Context, id uint64) (entity.ConsumerStatus, error) {
+	if m.statusFn != nil {
+		return m.statusFn(ctx, id)
+	}
+	return entity.ConsumerActive, nil
+}
+
+func (m *mockConsumerRepo) UpdateStatus(ctx context.Context, tx *sql.Tx, id uint64, status entity.ConsumerStatus, reason string) error {
+	if m.setStatFn != nil {
+		return m.setStatFn(ctx, tx, id, status, reason)
+	}
+	return nil
+}
+
+func (m *mockConsumerRepo) Anonymise(ctx context.Context, tx *sql.Tx, id uint64) error {
+	if m.eraseFn != nil {
+		return m.eraseFn(ctx, tx, id)
+	}
+	return nil
+}
+
+func (m *mockConsumerRepo) UpdateRiskGrade(ctx context.Context, tx *sql.Tx, id uint64, grade entity.RiskGrade) error {
+	if m.gradeFn != nil {
+		return m.gradeFn(ctx, tx, id, grade)
+	}
+	return nil
+}
 
 type mockAuthRepoForRegister struct {
-	createFn func(ctx context.Context, tx *sql.Tx, u *entity.AuthUser) error
+	createFn      func(ctx context.Context, tx *sql.Tx, u *entity.AuthUser) error
+	findByEmailFn func(ctx context.Context, email string) (*entity.AuthUser, error)
+	findByIDFn    func(ctx context.Context, id uint64) (*entity.AuthUser, error)
+	updatePwdFn   func(ctx context.Context, tx *sql.Tx, id uint64, hash string) error
+
+	findByConsumerIDFn func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error)
+	markVerifiedFn     func(ctx context.Context, tx *sql.Tx, id uint64) error
+	anonymiseFn        func(ctx context.Context, tx *sql.Tx, id uint64, email string) error
 }
 
 func (m *mockAuthRepoForRegister) Create(ctx context.Context, tx *sql.Tx, u *entity.AuthUser) error {
@@ -34,19 +124,55 @@ func (m *mockAuthRepoForRegister) Create(ctx context.Context, tx *sql.Tx, u *ent
 	return nil
 }
 func (m *mockAuthRepoForRegister) FindByEmail(ctx context.Context, email string) (*entity.AuthUser, error) {
+	if m.findByEmailFn != nil {
+		return m.findByEmailFn(ctx, email)
+	}
 	return nil, sql.ErrNoRows
 }
+func (m *mockAuthRepoForRegister) FindByID(ctx context.Context, id uint64) (*entity.AuthUser, error) {
+	if m.findByIDFn != nil {
+		return m.findByIDFn(ctx, id)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthRepoForRegister) FindByConsumerID(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+	if m.findByConsumerIDFn != nil {
+		return m.findByConsumerIDFn(ctx, consumerID)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthRepoForRegister) MarkVerified(ctx context.Context, tx *sql.Tx, id uint64) error {
+	if m.markVerifiedFn != nil {
+		return m.markVerifiedFn(ctx, tx, id)
+	}
+	return nil
+}
+func (m *mockAuthRepoForRegister) UpdatePassword(ctx context.Context, tx *sql.Tx, id uint64, hash string) error {
+	if m.updatePwdFn != nil {
+		return m.updatePwdFn(ctx, tx, id, hash)
+	}
+	return nil
+}
+func (m *mockAuthRepoForRegister) Anonymise(ctx context.Context, tx *sql.Tx, id uint64, email string) error {
+	if m.anonymiseFn != nil {
+		return m.anonymiseFn(ctx, tx, id, email)
+	}
+	return nil
+}
 
 func TestRegister_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
 	defer db.Close()
 
-	// Expect transaction begin and commit, and 4 inserts into consumer_limits
+	// Expect transaction begin and commit, and one insert into consumer_limits
+	// per tenor of the active policy
 	mock.ExpectBegin()
-	// we expect 4 insert execs for consumer_limits
-	for i := 0; i < 4; i++ {
-		mock.ExpectExec("INSERT INTO consumer_limits").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	for _, want := range []struct {
+		tenor uint8
+		limit float64
+	}{{1, 400}, {6, 2000}} {
+		mock.ExpectExec("INSERT INTO consumer_limits").WithArgs(uint64(77), want.tenor, want.limit, 0.0, false, uint32(4), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 	}
 	mock.ExpectCommit()
 
@@ -54,7 +180,7 @@ func TestRegister_Success(t *testing.T) {
 	consumerRepo := &mockConsumerRepo{
 		createFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error) {
 			require.NotNil(t, tx)
-			require.Equal(t, "08123", c.NIK)
+			require.Equal(t, "3173010101900001", c.NIK)
 			return created, nil
 		},
 	}
@@ -68,23 +194,50 @@ func TestRegister_Success(t *testing.T) {
 		},
 	}
 
-	u := NewAuthUsecase(db, consumerRepo, authRepo)
+	policies := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(4)}}
+
+	docs := testDocuments()
+	u := NewAuthUsecase(db, consumerRepo, authRepo, docs, nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, policies)
 
 	req := RegisterRequest{
-		NIK:         "08123",
-		FullName:    "Test User",
-		LegalName:   "Test User",
-		BirthPlace:  "City",
-		BirthDate:   "1990-01-01",
-		Salary:      1000,
-		Email:       "t@example.com",
-		KTPPhoto:    "ktp.jpg",
-		SelfiePhoto: "selfie.jpg",
-		Password:    "secret",
+		NIK:              " 3173010101900001 ",
+		FullName:         "Test User",
+		LegalName:        "Test User",
+		BirthPlace:       "City",
+		BirthDate:        "1990-01-01",
+		Salary:           1000,
+		Email:            "t@example.com",
+		KTPDocumentID:    "doc_ktp1",
+		SelfieDocumentID: "doc_selfie1",
+		Password:         "Secret123",
 	}
 
 	err = u.Register(context.Background(), req)
 	require.NoError(t, err)
+	require.Equal(t, created, *docs.docs["doc_ktp1"].ConsumerID)
+	require.Equal(t, created, *docs.docs["doc_selfie1"].ConsumerID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegister_NoActivePolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	consumerRepo := &mockConsumerRepo{
+		createFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error) {
+			return 77, nil
+		},
+	}
+
+	u := NewAuthUsecase(db, consumerRepo, &mockAuthRepoForRegister{}, testDocuments(), nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, &mockLimitPolicyRepo{})
+	req := RegisterRequest{NIK: "3173010101900001", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "1990-01-01", Salary: 1000, Email: "e", KTPDocumentID: "doc_ktp1", SelfieDocumentID: "doc_selfie1", Password: "Secret123"}
+
+	err = u.Register(context.Background(), req)
+	require.Equal(t, ErrNoActiveLimitPolicy, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -103,10 +256,85 @@ func TestRegister_ConsumerCreateError_Rollback(t *testing.T) {
 	}
 	authRepo := &mockAuthRepoForRegister{}
 
-	u := NewAuthUsecase(db, consumerRepo, authRepo)
-	req := RegisterRequest{NIK: "x", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "d", Salary: 1, Email: "e", KTPPhoto: "k", SelfiePhoto: "s", Password: "p"}
+	u := NewAuthUsecase(db, consumerRepo, authRepo, &mockDocumentRepo{}, nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, nil)
+	req := RegisterRequest{NIK: "3173010101900001", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "1990-01-01", Salary: 1, Email: "e", KTPDocumentID: "k", SelfieDocumentID: "s", Password: "Secret123"}
 
 	err = u.Register(context.Background(), req)
 	require.Error(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestRegister_WeakPassword(t *testing.T) {
+	u := NewAuthUsecase(nil, &mockConsumerRepo{}, &mockAuthRepoForRegister{}, &mockDocumentRepo{}, nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, nil)
+	req := RegisterRequest{NIK: "x", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "d", Salary: 1, Email: "e", KTPDocumentID: "k", SelfieDocumentID: "s", Password: "a"}
+
+	err := u.Register(context.Background(), req)
+	require.ErrorIs(t, err, utils.ErrWeakPassword)
+}
+
+func TestRegister_RejectsBadNIK(t *testing.T) {
+	u := NewAuthUsecase(nil, &mockConsumerRepo{}, &mockAuthRepoForRegister{}, &mockDocumentRepo{}, nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, nil)
+	req := RegisterRequest{NIK: "08123", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "1990-01-01", Salary: 1, Email: "e", KTPDocumentID: "k", SelfieDocumentID: "s", Password: "Secret123"}
+
+	err := u.Register(context.Background(), req)
+	require.ErrorIs(t, err, utils.ErrInvalidNIK)
+
+	// 41 in the day field marks a woman born on the 1st.
+	req.NIK = "3173014101900001"
+	req.BirthDate = "1990-01-02"
+	err = u.Register(context.Background(), req)
+	require.Equal(t, ErrNIKBirthDateMismatch, err)
+
+	req.BirthDate = "02/01/1990"
+	err = u.Register(context.Background(), req)
+	require.Equal(t, ErrInvalidBirthDate, err)
+}
+
+func TestRegister_DuplicateNIK(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	consumerRepo := &mockConsumerRepo{
+		existsFn: func(ctx context.Context, tx *sql.Tx, nik string) (bool, error) {
+			require.Equal(t, "3173014101900001", nik)
+			return true, nil
+		},
+		createFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error) {
+			t.Fatal("consumer must not be created")
+			return 0, nil
+		},
+	}
+
+	u := NewAuthUsecase(db, consumerRepo, &mockAuthRepoForRegister{}, &mockDocumentRepo{}, nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, nil)
+	req := RegisterRequest{NIK: "3173014101900001", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "1990-01-01", Salary: 1, Email: "e", KTPDocumentID: "k", SelfieDocumentID: "s", Password: "Secret123"}
+
+	err = u.Register(context.Background(), req)
+	require.Equal(t, ErrNIKAlreadyRegistered, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegister_DuplicateKeyOnInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	consumerRepo := &mockConsumerRepo{
+		createFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) (uint64, error) {
+			return 0, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '3173010101900001' for key 'consumers.nik'"}
+		},
+	}
+
+	u := NewAuthUsecase(db, consumerRepo, &mockAuthRepoForRegister{}, &mockDocumentRepo{}, nil, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil, nil)
+	req := RegisterRequest{NIK: "3173010101900001", FullName: "x", LegalName: "x", BirthPlace: "p", BirthDate: "1990-01-01", Salary: 1, Email: "e", KTPDocumentID: "k", SelfieDocumentID: "s", Password: "Secret123"}
+
+	err = u.Register(context.Background(), req)
+	require.Equal(t, ErrNIKAlreadyRegistered, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
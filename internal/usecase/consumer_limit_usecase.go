@@ -5,38 +5,101 @@ import (
 	"database/sql"
 	"errors"
 
+	"multifinance-core/internal/domain/entity"
 	"multifinance-core/internal/repository"
 )
 
+var ErrLimitNotFound = errors.New("no limit for that tenor")
+
+// LimitSummary is a limit as the consumer sees it.
+type LimitSummary struct {
+	Tenor     uint8   `json:"tenor"`
+	MaxLimit  float64 `json:"max_limit"`
+	UsedLimit float64 `json:"used_limit"`
+	HeldLimit float64 `json:"held_limit"`
+	Available float64 `json:"available_limit"`
+	Active    bool    `json:"active"`
+}
+
 type ConsumerLimitUsecase struct {
-	db   *sql.DB
-	repo repository.ConsumerLimitRepository
+	db         *sql.DB
+	repo       repository.ConsumerLimitRepository
+	ledgerRepo repository.LimitLedgerRepository
 }
 
-func NewConsumerLimitUsecase(db *sql.DB, r repository.ConsumerLimitRepository) *ConsumerLimitUsecase {
-	return &ConsumerLimitUsecase{db: db, repo: r}
+func NewConsumerLimitUsecase(db *sql.DB, r repository.ConsumerLimitRepository, l repository.LimitLedgerRepository) *ConsumerLimitUsecase {
+	return &ConsumerLimitUsecase{db: db, repo: r, ledgerRepo: l}
 }
 
-func (u *ConsumerLimitUsecase) IncreaseUsedLimit(ctx context.Context, consumerID uint64, tenor uint8, amount float64) error {
-	tx, err := u.db.BeginTx(ctx, nil)
+func (u *ConsumerLimitUsecase) List(ctx context.Context, consumerID uint64) ([]*LimitSummary, error) {
+	limits, err := u.repo.ListByConsumer(ctx, consumerID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	res := make([]*LimitSummary, 0, len(limits))
+	for _, cl := range limits {
+		res = append(res, limitSummary(cl))
 	}
-	defer tx.Rollback()
+	return res, nil
+}
 
+func (u *ConsumerLimitUsecase) Get(ctx context.Context, consumerID uint64, tenor uint8) (*LimitSummary, error) {
 	cl, err := u.repo.GetByConsumerAndTenor(ctx, consumerID, tenor)
+	if err == sql.ErrNoRows {
+		return nil, ErrLimitNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return limitSummary(cl), nil
+}
 
-	if cl.UsedLimit+amount > cl.MaxLimit {
-		return errors.New("used limit exceeds max limit")
+// History returns every movement of one of the consumer's limits, oldest
+// first.
+func (u *ConsumerLimitUsecase) History(ctx context.Context, consumerID uint64, tenor uint8) ([]*entity.LimitLedgerEntry, error) {
+	cl, err := u.repo.GetByConsumerAndTenor(ctx, consumerID, tenor)
+	if err == sql.ErrNoRows {
+		return nil, ErrLimitNotFound
 	}
+	if err != nil {
+		return nil, err
+	}
+	return u.ledgerRepo.ListByLimit(ctx, cl.ID)
+}
 
-	newUsed := cl.UsedLimit + amount
-	if err := u.repo.UpdateUsedLimit(ctx, tx, consumerID, tenor, newUsed); err != nil {
+// Reconcile returns the limits whose used_limit does not match the sum of
+// their ledger entries. It should always be empty.
+func (u *ConsumerLimitUsecase) Reconcile(ctx context.Context) ([]*entity.LimitMismatch, error) {
+	return u.ledgerRepo.Mismatches(ctx)
+}
+
+// limitRefusal tells why a limit could not take more credit; exceeded is
+// returned when the limit exists and is active but the amount does not fit.
+func limitRefusal(ctx context.Context, repo repository.ConsumerLimitRepository, consumerID uint64, tenor uint8, exceeded error) error {
+	cl, err := repo.GetByConsumerAndTenor(ctx, consumerID, tenor)
+	if err == sql.ErrNoRows {
+		return ErrLimitNotFound
+	}
+	if err != nil {
 		return err
 	}
+	if !cl.Active {
+		return ErrLimitInactive
+	}
+	return exceeded
+}
 
-	return tx.Commit()
+func limitSummary(cl *entity.ConsumerLimit) *LimitSummary {
+	available := cl.MaxLimit - cl.UsedLimit - cl.HeldLimit
+	if available < 0 {
+		available = 0
+	}
+	return &LimitSummary{
+		Tenor:     cl.TenorMonth,
+		MaxLimit:  cl.MaxLimit,
+		UsedLimit: cl.UsedLimit,
+		HeldLimit: cl.HeldLimit,
+		Available: available,
+		Active:    cl.Active,
+	}
 }
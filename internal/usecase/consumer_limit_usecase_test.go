@@ -3,18 +3,29 @@ package usecase
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"sync"
 	"testing"
 
 	"multifinance-core/internal/domain/entity"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
 )
 
 type mockConsumerLimitRepo struct {
-	getFn    func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error)
-	updateFn func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, newUsed float64) error
+	getFn      func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error)
+	addFn      func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error)
+	holdFn     func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error)
+	releaseFn  func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error
+	convertFn  func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error
+	byIDFn     func(ctx context.Context, id uint64) (*entity.ConsumerLimit, error)
+	freeFn     func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error
+	maxFn      func(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, policyVersion uint32) error
+	idsFn      func(ctx context.Context, afterID uint64, limit int) ([]uint64, error)
+	behindFn   func(ctx context.Context, version uint32, afterID uint64, limit int) ([]uint64, error)
+	overrideFn func(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, overrideID uint64) error
+	clearFn    func(ctx context.Context, tx *sql.Tx, id uint64) error
+	activateFn func(ctx context.Context, tx *sql.Tx, consumerID uint64) error
+	listFn     func(ctx context.Context, consumerID uint64) ([]*entity.ConsumerLimit, error)
 }
 
 func (m *mockConsumerLimitRepo) GetByConsumerAndTenor(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
@@ -24,100 +35,194 @@ func (m *mockConsumerLimitRepo) GetByConsumerAndTenor(ctx context.Context, consu
 	return nil, sql.ErrNoRows
 }
 
-func (m *mockConsumerLimitRepo) UpdateUsedLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, newUsed float64) error {
-	if m.updateFn != nil {
-		return m.updateFn(ctx, tx, consumerID, tenor, newUsed)
+func (m *mockConsumerLimitRepo) GetByConsumerAndTenorForUpdate(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+	return m.GetByConsumerAndTenor(ctx, consumerID, tenor)
+}
+
+func (m *mockConsumerLimitRepo) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.ConsumerLimit, error) {
+	if m.byIDFn != nil {
+		return m.byIDFn(ctx, id)
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockConsumerLimitRepo) AddUsedLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+	if m.addFn != nil {
+		return m.addFn(ctx, tx, consumerID, tenor, amount)
+	}
+	return true, nil
+}
+
+func (m *mockConsumerLimitRepo) AddHeldLimit(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+	if m.holdFn != nil {
+		return m.holdFn(ctx, tx, consumerID, tenor, amount)
+	}
+	return true, nil
+}
+
+func (m *mockConsumerLimitRepo) ReleaseHeldLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+	if m.releaseFn != nil {
+		return m.releaseFn(ctx, tx, id, amount)
 	}
 	return nil
 }
 
-func TestIncreaseUsedLimit_Success(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
+func (m *mockConsumerLimitRepo) ConvertHeldLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+	if m.convertFn != nil {
+		return m.convertFn(ctx, tx, id, amount)
+	}
+	return nil
+}
 
-	mock.ExpectBegin()
-	mock.ExpectCommit()
+func (m *mockConsumerLimitRepo) ReleaseUsedLimit(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+	if m.freeFn != nil {
+		return m.freeFn(ctx, tx, id, amount)
+	}
+	return nil
+}
 
-	repo := &mockConsumerLimitRepo{
-		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
-			return &entity.ConsumerLimit{ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 100.0, UsedLimit: 10.0}, nil
-		},
-		updateFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, newUsed float64) error {
-			require.NotNil(t, tx)
-			require.Equal(t, float64(60.0), newUsed)
-			return nil
-		},
+func (m *mockConsumerLimitRepo) ActivateByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+	if m.activateFn != nil {
+		return m.activateFn(ctx, tx, consumerID)
 	}
+	return nil
+}
 
-	u := NewConsumerLimitUsecase(db, repo)
-	err = u.IncreaseUsedLimit(context.Background(), 1, 1, 50.0)
-	require.NoError(t, err)
-	require.NoError(t, mock.ExpectationsWereMet())
+func (m *mockConsumerLimitRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerLimit, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, consumerID)
+	}
+	return nil, nil
 }
 
-func TestIncreaseUsedLimit_ExceedLimit(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
+func (m *mockConsumerLimitRepo) ListConsumerIDs(ctx context.Context, afterID uint64, limit int) ([]uint64, error) {
+	if m.idsFn != nil {
+		return m.idsFn(ctx, afterID, limit)
+	}
+	return nil, nil
+}
 
-	mock.ExpectBegin()
-	mock.ExpectRollback()
+func (m *mockConsumerLimitRepo) ListConsumerIDsBehindPolicy(ctx context.Context, version uint32, afterID uint64, limit int) ([]uint64, error) {
+	if m.behindFn != nil {
+		return m.behindFn(ctx, version, afterID, limit)
+	}
+	return nil, nil
+}
 
-	repo := &mockConsumerLimitRepo{
-		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
-			return &entity.ConsumerLimit{ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 50.0, UsedLimit: 30.0}, nil
-		},
+func (m *mockConsumerLimitRepo) UpdateMaxLimit(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, policyVersion uint32) error {
+	if m.maxFn != nil {
+		return m.maxFn(ctx, tx, id, maxLimit, policyVersion)
 	}
+	return nil
+}
 
-	u := NewConsumerLimitUsecase(db, repo)
-	err = u.IncreaseUsedLimit(context.Background(), 1, 1, 25.0)
-	require.Error(t, err)
-	require.EqualError(t, err, "used limit exceeds max limit")
-	require.NoError(t, mock.ExpectationsWereMet())
+func (m *mockConsumerLimitRepo) OverrideMaxLimit(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, overrideID uint64) error {
+	if m.overrideFn != nil {
+		return m.overrideFn(ctx, tx, id, maxLimit, overrideID)
+	}
+	return nil
 }
 
-func TestIncreaseUsedLimit_GetError_Rollback(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer db.Close()
+func (m *mockConsumerLimitRepo) ClearOverride(ctx context.Context, tx *sql.Tx, id uint64) error {
+	if m.clearFn != nil {
+		return m.clearFn(ctx, tx, id)
+	}
+	return nil
+}
 
-	mock.ExpectBegin()
-	mock.ExpectRollback()
+func (m *mockConsumerLimitRepo) HasOutstanding(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+	limits, err := m.ListByConsumer(ctx, consumerID)
+	if err != nil {
+		return false, err
+	}
+	for _, cl := range limits {
+		if cl.UsedLimit > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-	repo := &mockConsumerLimitRepo{
-		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
-			return nil, errors.New("not found")
-		},
+type mockLimitLedgerRepo struct {
+	mu         sync.Mutex
+	entries    []*entity.LimitLedgerEntry
+	mismatches []*entity.LimitMismatch
+}
+
+func (m *mockLimitLedgerRepo) Create(ctx context.Context, tx *sql.Tx, e *entity.LimitLedgerEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.ID = uint64(len(m.entries) + 1)
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *mockLimitLedgerRepo) ListByLimit(ctx context.Context, consumerLimitID uint64) ([]*entity.LimitLedgerEntry, error) {
+	var res []*entity.LimitLedgerEntry
+	for _, e := range m.entries {
+		if e.ConsumerLimitID == consumerLimitID {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockLimitLedgerRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitLedgerEntry, error) {
+	var res []*entity.LimitLedgerEntry
+	for _, e := range m.entries {
+		if e.ConsumerID == consumerID {
+			res = append(res, e)
+		}
 	}
+	return res, nil
+}
 
-	u := NewConsumerLimitUsecase(db, repo)
-	err = u.IncreaseUsedLimit(context.Background(), 1, 1, 10.0)
-	require.Error(t, err)
-	require.EqualError(t, err, "not found")
-	require.NoError(t, mock.ExpectationsWereMet())
+func (m *mockLimitLedgerRepo) Mismatches(ctx context.Context) ([]*entity.LimitMismatch, error) {
+	return m.mismatches, nil
 }
 
-func TestIncreaseUsedLimit_UpdateError_Rollback(t *testing.T) {
-	db, mock, err := sqlmock.New()
+func TestConsumerLimit_List(t *testing.T) {
+	repo := &mockConsumerLimitRepo{
+		listFn: func(ctx context.Context, consumerID uint64) ([]*entity.ConsumerLimit, error) {
+			return []*entity.ConsumerLimit{
+				{ConsumerID: consumerID, TenorMonth: 1, MaxLimit: 100.0, UsedLimit: 30.0, Active: true},
+				// a limit lowered below what is already used shows nothing available
+				{ConsumerID: consumerID, TenorMonth: 3, MaxLimit: 100.0, UsedLimit: 120.0, Active: true},
+			}, nil
+		},
+	}
+
+	u := NewConsumerLimitUsecase(nil, repo, &mockLimitLedgerRepo{})
+	limits, err := u.List(context.Background(), 1)
 	require.NoError(t, err)
-	defer db.Close()
+	require.Len(t, limits, 2)
+	require.Equal(t, &LimitSummary{Tenor: 1, MaxLimit: 100.0, UsedLimit: 30.0, Available: 70.0, Active: true}, limits[0])
+	require.Equal(t, 0.0, limits[1].Available)
+}
 
-	mock.ExpectBegin()
-	mock.ExpectRollback()
+func TestConsumerLimit_Get_NotFound(t *testing.T) {
+	u := NewConsumerLimitUsecase(nil, &mockConsumerLimitRepo{}, &mockLimitLedgerRepo{})
+	_, err := u.Get(context.Background(), 1, 12)
+	require.Equal(t, ErrLimitNotFound, err)
+}
 
+func TestConsumerLimit_History(t *testing.T) {
 	repo := &mockConsumerLimitRepo{
 		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
-			return &entity.ConsumerLimit{ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 100.0, UsedLimit: 10.0}, nil
-		},
-		updateFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, newUsed float64) error {
-			return errors.New("update failed")
+			return &entity.ConsumerLimit{ID: 4, ConsumerID: consumerID, TenorMonth: tenor}, nil
 		},
 	}
+	ledger := &mockLimitLedgerRepo{entries: []*entity.LimitLedgerEntry{
+		{ID: 1, ConsumerLimitID: 4, Kind: entity.LimitDebit, Amount: 100},
+		{ID: 2, ConsumerLimitID: 5, Kind: entity.LimitDebit, Amount: 50},
+	}}
+
+	u := NewConsumerLimitUsecase(nil, repo, ledger)
+	entries, err := u.History(context.Background(), 1, 3)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, uint64(1), entries[0].ID)
 
-	u := NewConsumerLimitUsecase(db, repo)
-	err = u.IncreaseUsedLimit(context.Background(), 1, 1, 20.0)
-	require.Error(t, err)
-	require.EqualError(t, err, "update failed")
-	require.NoError(t, mock.ExpectationsWereMet())
+	_, err = NewConsumerLimitUsecase(nil, &mockConsumerLimitRepo{}, ledger).History(context.Background(), 1, 12)
+	require.Equal(t, ErrLimitNotFound, err)
 }
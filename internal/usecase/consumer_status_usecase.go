@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrInvalidConsumerStatus = errors.New("invalid consumer status")
+var ErrInvalidStatusTransition = errors.New("consumer cannot move to that status")
+var ErrAccountDisabled = errors.New("account is disabled")
+
+type ChangeConsumerStatusRequest struct {
+	Status entity.ConsumerStatus `json:"status" binding:"required"`
+	Reason string                `json:"reason" binding:"required,max=255"`
+}
+
+// ConsumerStatusUsecase lets staff suspend, blacklist, close and reinstate
+// consumer accounts. Every change is kept with its reason.
+type ConsumerStatusUsecase struct {
+	db               *sql.DB
+	consumerRepo     repository.ConsumerRepository
+	statusRepo       repository.ConsumerStatusChangeRepository
+	authRepo         repository.AuthRepository
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+}
+
+func NewConsumerStatusUsecase(db *sql.DB, c repository.ConsumerRepository, sc repository.ConsumerStatusChangeRepository, a repository.AuthRepository, s repository.SessionRepository, rt repository.RefreshTokenRepository) *ConsumerStatusUsecase {
+	return &ConsumerStatusUsecase{db, c, sc, a, s, rt}
+}
+
+// ChangeStatus moves the consumer to req.Status. Statuses that may not log in
+// also end every session of the consumer, so the change takes effect at once.
+func (u *ConsumerStatusUsecase) ChangeStatus(ctx context.Context, consumerID, staffUserID uint64, req ChangeConsumerStatusRequest) error {
+	if !req.Status.Valid() {
+		return ErrInvalidConsumerStatus
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return ErrConsumerNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !c.Status.CanMoveTo(req.Status) {
+		return ErrInvalidStatusTransition
+	}
+
+	if err := u.consumerRepo.UpdateStatus(ctx, tx, c.ID, req.Status, req.Reason); err != nil {
+		return err
+	}
+	err = u.statusRepo.Create(ctx, tx, &entity.ConsumerStatusChange{
+		ConsumerID: c.ID,
+		FromStatus: c.Status,
+		ToStatus:   req.Status,
+		Reason:     req.Reason,
+		ChangedBy:  staffUserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !req.Status.CanLogin() {
+		user, err := u.authRepo.FindByConsumerID(ctx, c.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if user != nil {
+			if err := u.refreshTokenRepo.RevokeByAuthUser(ctx, tx, user.ID); err != nil {
+				return err
+			}
+			if err := u.sessionRepo.RevokeByAuthUser(ctx, tx, user.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (u *ConsumerStatusUsecase) History(ctx context.Context, consumerID uint64) ([]*entity.ConsumerStatusChange, error) {
+	_, err := u.consumerRepo.FindStatus(ctx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.statusRepo.ListByConsumer(ctx, consumerID)
+}
+
+// checkCanLogin refuses blacklisted and closed consumers.
+func checkCanLogin(ctx context.Context, repo repository.ConsumerRepository, consumerID uint64) error {
+	status, err := repo.FindStatus(ctx, consumerID)
+	if err != nil {
+		return err
+	}
+	if !status.CanLogin() {
+		return ErrAccountDisabled
+	}
+	return nil
+}
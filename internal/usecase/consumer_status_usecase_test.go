@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockConsumerStatusChangeRepo keeps the status history in memory.
+type mockConsumerStatusChangeRepo struct {
+	changes []*entity.ConsumerStatusChange
+}
+
+func (m *mockConsumerStatusChangeRepo) Create(ctx context.Context, tx *sql.Tx, sc *entity.ConsumerStatusChange) error {
+	sc.ID = uint64(len(m.changes) + 1)
+	m.changes = append(m.changes, sc)
+	return nil
+}
+func (m *mockConsumerStatusChangeRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerStatusChange, error) {
+	var out []*entity.ConsumerStatusChange
+	for _, sc := range m.changes {
+		if sc.ConsumerID == consumerID {
+			out = append(out, sc)
+		}
+	}
+	return out, nil
+}
+
+func newTestConsumerStatusUsecase(t *testing.T, consumer *entity.Consumer, changes *mockConsumerStatusChangeRepo, revoked *[]uint64) *ConsumerStatusUsecase {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 5; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+
+	repo := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			if id != consumer.ID {
+				return nil, sql.ErrNoRows
+			}
+			c := *consumer
+			return &c, nil
+		},
+		statusFn: func(ctx context.Context, id uint64) (entity.ConsumerStatus, error) {
+			if id != consumer.ID {
+				return "", sql.ErrNoRows
+			}
+			return consumer.Status, nil
+		},
+		setStatFn: func(ctx context.Context, tx *sql.Tx, id uint64, status entity.ConsumerStatus, reason string) error {
+			consumer.Status = status
+			consumer.StatusReason = reason
+			return nil
+		},
+	}
+	authRepo := &mockAuthRepoForRegister{
+		findByConsumerIDFn: func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 50, ConsumerID: consumerID}, nil
+		},
+	}
+	sessions := &mockSessionRepo{
+		revokeByAuthUserFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			*revoked = append(*revoked, authUserID)
+			return nil
+		},
+	}
+	return NewConsumerStatusUsecase(db, repo, changes, authRepo, sessions, &mockRefreshTokenRepo{})
+}
+
+func TestConsumerStatus_SuspendKeepsSessions(t *testing.T) {
+	consumer := &entity.Consumer{ID: 10, Status: entity.ConsumerActive}
+	changes := &mockConsumerStatusChangeRepo{}
+	var revoked []uint64
+	u := newTestConsumerStatusUsecase(t, consumer, changes, &revoked)
+
+	err := u.ChangeStatus(context.Background(), 10, 2, ChangeConsumerStatusRequest{Status: entity.ConsumerSuspended, Reason: "chargeback under investigation"})
+	require.NoError(t, err)
+	require.Equal(t, entity.ConsumerSuspended, consumer.Status)
+	require.Equal(t, "chargeback under investigation", consumer.StatusReason)
+	require.Empty(t, revoked)
+
+	history, err := u.History(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, entity.ConsumerActive, history[0].FromStatus)
+	require.Equal(t, uint64(2), history[0].ChangedBy)
+}
+
+func TestConsumerStatus_BlacklistRevokesSessions(t *testing.T) {
+	consumer := &entity.Consumer{ID: 10, Status: entity.ConsumerSuspended}
+	var revoked []uint64
+	u := newTestConsumerStatusUsecase(t, consumer, &mockConsumerStatusChangeRepo{}, &revoked)
+
+	err := u.ChangeStatus(context.Background(), 10, 2, ChangeConsumerStatusRequest{Status: entity.ConsumerBlacklisted, Reason: "fraudulent documents"})
+	require.NoError(t, err)
+	require.Equal(t, entity.ConsumerBlacklisted, consumer.Status)
+	require.Equal(t, []uint64{50}, revoked)
+}
+
+func TestConsumerStatus_ClosedIsTerminal(t *testing.T) {
+	consumer := &entity.Consumer{ID: 10, Status: entity.ConsumerClosed}
+	changes := &mockConsumerStatusChangeRepo{}
+	var revoked []uint64
+	u := newTestConsumerStatusUsecase(t, consumer, changes, &revoked)
+
+	err := u.ChangeStatus(context.Background(), 10, 2, ChangeConsumerStatusRequest{Status: entity.ConsumerActive, Reason: "reopen"})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+	require.Empty(t, changes.changes)
+
+	err = u.ChangeStatus(context.Background(), 10, 2, ChangeConsumerStatusRequest{Status: "FROZEN", Reason: "x"})
+	require.ErrorIs(t, err, ErrInvalidConsumerStatus)
+
+	err = u.ChangeStatus(context.Background(), 99, 2, ChangeConsumerStatusRequest{Status: entity.ConsumerActive, Reason: "x"})
+	require.ErrorIs(t, err, ErrConsumerNotFound)
+}
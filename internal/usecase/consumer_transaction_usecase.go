@@ -6,24 +6,48 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"time"
 
 	"multifinance-core/internal/domain/entity"
 	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
 )
 
 var ErrInsufficientLimit = errors.New("insufficient limit")
 var ErrInvalidTenor = errors.New("invalid tenor")
+var ErrLimitInactive = errors.New("credit limit is not active until KYC is approved")
+var ErrConsumerNotActive = errors.New("consumer account cannot make purchases")
+var ErrMerchantAssetNotFound = errors.New("asset not found")
+var ErrPurchaseNotAuthorized = errors.New("purchase is not authorized by the consumer")
+
+type AuthorizePurchaseRequest struct {
+	MerchantID uint64 `json:"merchant_id" binding:"required"`
+	AssetID    uint64 `json:"asset_id" binding:"required"`
+	Tenor      uint8  `json:"tenor" binding:"required"`
+}
+
+// PurchaseAuthorizationToken is returned to the consumer once; they hand the
+// token to the merchant, who sends it with the purchase.
+type PurchaseAuthorizationToken struct {
+	Token     string    `json:"purchase_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
 
 type ConsumerTransactionUsecase struct {
-	db        *sql.DB
-	assetRepo repository.AssetRepository
-	limitRepo repository.ConsumerLimitRepository
-	txRepo    repository.ConsumerTransactionRepository
+	db           *sql.DB
+	assetRepo    repository.AssetRepository
+	limitRepo    repository.ConsumerLimitRepository
+	txRepo       repository.ConsumerTransactionRepository
+	authRepo     repository.AuthRepository
+	consumerRepo repository.ConsumerRepository
+	ledgerRepo   repository.LimitLedgerRepository
+	purchaseAuth repository.PurchaseAuthorizationRepository
+	authTTL      time.Duration
 }
 
-func NewConsumerTransactionUsecase(db *sql.DB, a repository.AssetRepository, l repository.ConsumerLimitRepository, t repository.ConsumerTransactionRepository) *ConsumerTransactionUsecase {
-	return &ConsumerTransactionUsecase{db, a, l, t}
+func NewConsumerTransactionUsecase(db *sql.DB, a repository.AssetRepository, l repository.ConsumerLimitRepository, t repository.ConsumerTransactionRepository, au repository.AuthRepository, c repository.ConsumerRepository, lg repository.LimitLedgerRepository, pa repository.PurchaseAuthorizationRepository, authTTL time.Duration) *ConsumerTransactionUsecase {
+	return &ConsumerTransactionUsecase{db, a, l, t, au, c, lg, pa, authTTL}
 }
 
 func allowedTenor(t uint8) bool {
@@ -31,94 +55,245 @@ func allowedTenor(t uint8) bool {
 }
 
 func (u *ConsumerTransactionUsecase) Purchase(ctx context.Context, consumerID uint64, assetID uint64, tenor uint8) (*entity.Transaction, error) {
+	return u.purchase(ctx, consumerID, assetID, tenor, nil)
+}
+
+// purchase runs a purchase. redeem, when set, runs first inside the
+// purchase transaction, before anything about the consumer is checked, so an
+// authorization is spent with the purchase it allowed and callers without
+// one learn nothing about the consumer.
+func (u *ConsumerTransactionUsecase) purchase(ctx context.Context, consumerID uint64, assetID uint64, tenor uint8, redeem func(ctx context.Context, tx *sql.Tx) error) (*entity.Transaction, error) {
 	if !allowedTenor(tenor) {
 		return nil, ErrInvalidTenor
 	}
 
-	tx, err := u.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, err := u.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	if redeem != nil {
+		if err := redeem(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCanPurchase(ctx, u.authRepo, u.consumerRepo, consumerID); err != nil {
+		return nil, err
+	}
+
 	asset, err := u.assetRepo.GetByID(ctx, assetID)
 	if err != nil {
 		return nil, err
 	}
 
-	row := tx.QueryRowContext(ctx, `SELECT id, consumer_id, tenor_month, max_limit, used_limit FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ? FOR UPDATE`, consumerID, tenor)
-	var clID uint64
-	var cID uint64
-	var tMonth uint8
-	var maxLimit float64
-	var usedLimit float64
-	if err := row.Scan(&clID, &cID, &tMonth, &maxLimit, &usedLimit); err != nil {
+	// The limit is debited in one conditional statement, so concurrent
+	// purchases can never spend past max_limit. The amount is whole rupiah,
+	// the same as the contract's OTR.
+	amount := math.Round(asset.PriceProduct)
+	added, err := u.limitRepo.AddUsedLimit(ctx, tx, consumerID, tenor, amount)
+	if err != nil {
 		return nil, err
 	}
-
-	available := maxLimit - usedLimit
-	price := asset.PriceProduct
-	if price > available {
-
-		tr := &entity.Transaction{
-			ContractNo:      fmt.Sprintf("C-%d-%d", consumerID, time.Now().UTC().UnixNano()),
-			ConsumerID:      consumerID,
-			ConsumerLimitID: clID,
-			AssetID:         assetID,
-			TenorMonth:      tenor,
-			OTR:             int64(math.Round(price)),
-			AdminFee:        int64(math.Round(price * 0.05)),
-			JumlahBunga:     int64(math.Round(price * 0.02 * float64(tenor))),
-			JumlahCicilan:   0,
-			Status:          "FAILED",
-			CreatedAt:       time.Now().UTC(),
+	if !added {
+		if err := limitRefusal(ctx, u.limitRepo, consumerID, tenor, ErrInsufficientLimit); err != ErrInsufficientLimit {
+			return nil, err
 		}
-		id, err := u.txRepo.Create(ctx, tx, tr)
+		// A purchase refused for lack of limit is still recorded, as FAILED.
+		cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, consumerID, tenor)
 		if err != nil {
 			return nil, err
 		}
-		tr.ID = id
-		_ = tx.Commit()
+		tr := newContract(consumerID, cl.ID, assetID, tenor, asset.PriceProduct)
+		tr.JumlahCicilan = 0
+		tr.Status = entity.TransactionFailed
+		if _, err := u.txRepo.Create(ctx, tx, tr); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
 		return nil, ErrInsufficientLimit
 	}
 
+	cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, consumerID, tenor)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := newContract(consumerID, cl.ID, assetID, tenor, asset.PriceProduct)
+	id, err := u.txRepo.Create(ctx, tx, tr)
+	if err != nil {
+		return nil, err
+	}
+	tr.ID = id
+
+	err = u.ledgerRepo.Create(ctx, tx, &entity.LimitLedgerEntry{
+		ConsumerLimitID: cl.ID,
+		ConsumerID:      consumerID,
+		TenorMonth:      tenor,
+		Kind:            entity.LimitDebit,
+		Amount:          amount,
+		UsedBefore:      cl.UsedLimit - amount,
+		UsedAfter:       cl.UsedLimit,
+		MaxBefore:       cl.MaxLimit,
+		MaxAfter:        cl.MaxLimit,
+		SourceType:      entity.LimitSourceTransaction,
+		SourceID:        strconv.FormatUint(id, 10),
+		Reason:          "purchase " + tr.ContractNo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// AuthorizePurchase lets a consumer consent to one purchase of a merchant's
+// asset. The returned token is single use and only valid for that merchant,
+// asset and tenor until it expires.
+func (u *ConsumerTransactionUsecase) AuthorizePurchase(ctx context.Context, consumerID uint64, req AuthorizePurchaseRequest) (*PurchaseAuthorizationToken, error) {
+	if !allowedTenor(req.Tenor) {
+		return nil, ErrInvalidTenor
+	}
+	asset, err := u.assetRepo.GetByID(ctx, req.AssetID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMerchantAssetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if asset.MerchantID == nil || *asset.MerchantID != req.MerchantID {
+		return nil, ErrMerchantAssetNotFound
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(u.authTTL)
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = u.purchaseAuth.Create(ctx, tx, &entity.PurchaseAuthorization{
+		ConsumerID: consumerID,
+		MerchantID: req.MerchantID,
+		AssetID:    req.AssetID,
+		TenorMonth: req.Tenor,
+		TokenHash:  utils.HashToken(token),
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &PurchaseAuthorizationToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// PurchaseForMerchant starts a purchase on behalf of a merchant. The asset
+// must belong to that merchant; other assets are reported as not found. The
+// consumer must have authorized exactly this purchase with AuthorizePurchase;
+// the token is spent in the purchase transaction.
+func (u *ConsumerTransactionUsecase) PurchaseForMerchant(ctx context.Context, merchantID, consumerID, assetID uint64, tenor uint8, token string) (*entity.Transaction, error) {
+	asset, err := u.assetRepo.GetByID(ctx, assetID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMerchantAssetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if asset.MerchantID == nil || *asset.MerchantID != merchantID {
+		return nil, ErrMerchantAssetNotFound
+	}
+
+	redeem := func(ctx context.Context, tx *sql.Tx) error {
+		return redeemPurchaseAuthorization(ctx, u.purchaseAuth, tx, token, merchantID, consumerID, assetID, tenor)
+	}
+
+	tr, err := u.purchase(ctx, consumerID, assetID, tenor, redeem)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	return tr, err
+}
+
+// redeemPurchaseAuthorization spends the consumer's token for exactly this
+// merchant, consumer, asset and tenor. Any mismatch, an unknown token and a
+// spent or expired one are all ErrPurchaseNotAuthorized.
+func redeemPurchaseAuthorization(ctx context.Context, repo repository.PurchaseAuthorizationRepository, tx *sql.Tx, token string, merchantID, consumerID, assetID uint64, tenor uint8) error {
+	a, err := repo.FindByHashForUpdate(ctx, tx, utils.HashToken(token))
+	if err == sql.ErrNoRows {
+		return ErrPurchaseNotAuthorized
+	}
+	if err != nil {
+		return err
+	}
+	if !a.Redeemable(time.Now().UTC()) || a.ConsumerID != consumerID || a.MerchantID != merchantID ||
+		a.AssetID != assetID || a.TenorMonth != tenor {
+		return ErrPurchaseNotAuthorized
+	}
+	return repo.MarkUsed(ctx, tx, a.ID)
+}
+
+// newContract prices a successful purchase of price over tenor months: a 5%
+// admin fee and 2% interest per month on the OTR, paid in equal instalments.
+func newContract(consumerID, consumerLimitID, assetID uint64, tenor uint8, price float64) *entity.Transaction {
 	otr := int64(math.Round(price))
 	admin := int64(math.Round(price * 0.05))
 	bunga := int64(math.Round(price * 0.02 * float64(tenor)))
 	total := float64(otr + admin + bunga)
 	cicilan := int64(math.Round(total / float64(tenor)))
 
-	tr := &entity.Transaction{
+	return &entity.Transaction{
 		ContractNo:      fmt.Sprintf("C-%d-%d", consumerID, time.Now().UTC().UnixNano()),
 		ConsumerID:      consumerID,
-		ConsumerLimitID: clID,
+		ConsumerLimitID: consumerLimitID,
 		AssetID:         assetID,
 		TenorMonth:      tenor,
 		OTR:             otr,
 		AdminFee:        admin,
 		JumlahBunga:     bunga,
 		JumlahCicilan:   cicilan,
-		Status:          "SUCCESS",
+		Status:          entity.TransactionActive,
 		CreatedAt:       time.Now().UTC(),
 	}
+}
 
-	id, err := u.txRepo.Create(ctx, tx, tr)
+// checkCanPurchase refuses consumers who may not take new credit: unverified
+// email addresses and accounts that are not active. Suspended consumers keep
+// their login but land here.
+func checkCanPurchase(ctx context.Context, authRepo repository.AuthRepository, consumerRepo repository.ConsumerRepository, consumerID uint64) error {
+	user, err := authRepo.FindByConsumerID(ctx, consumerID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	tr.ID = id
-
-	newUsed := usedLimit + float64(otr)
-	if _, err := tx.ExecContext(ctx, `UPDATE consumer_limits SET used_limit = ?, updated_at = ? WHERE id = ?`, newUsed, time.Now().UTC(), clID); err != nil {
-		return nil, err
+	if !user.Verified() {
+		return ErrEmailNotVerified
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	status, err := consumerRepo.FindStatus(ctx, consumerID)
+	if err != nil {
+		return err
 	}
-	return tr, nil
+	if !status.CanPurchase() {
+		return ErrConsumerNotActive
+	}
+	return nil
 }
 
 func (u *ConsumerTransactionUsecase) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Transaction, error) {
 	return u.txRepo.ListByConsumer(ctx, consumerID)
 }
+
+func (u *ConsumerTransactionUsecase) Summary(ctx context.Context, from, to time.Time) ([]*entity.TransactionSummary, error) {
+	return u.txRepo.Summary(ctx, from, to)
+}
@@ -5,8 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"math"
-	"regexp"
 	"testing"
+	"time"
 
 	"multifinance-core/internal/domain/entity"
 
@@ -24,13 +24,20 @@ func (m *mockAssetRepoTx) GetByID(ctx context.Context, id uint64) (*entity.Asset
 func (m *mockAssetRepoTx) Create(ctx context.Context, tx *sql.Tx, a *entity.Asset) (uint64, error) {
 	return 0, nil
 }
-func (m *mockAssetRepoTx) List(ctx context.Context) ([]*entity.Asset, error)             { return nil, nil }
+func (m *mockAssetRepoTx) List(ctx context.Context) ([]*entity.Asset, error) { return nil, nil }
+func (m *mockAssetRepoTx) ListByMerchant(ctx context.Context, merchantID uint64) ([]*entity.Asset, error) {
+	return nil, nil
+}
 func (m *mockAssetRepoTx) Update(ctx context.Context, tx *sql.Tx, a *entity.Asset) error { return nil }
 func (m *mockAssetRepoTx) Delete(ctx context.Context, tx *sql.Tx, id uint64) error       { return nil }
 
 type mockTxRepoTx struct {
 	createFn         func(ctx context.Context, tx *sql.Tx, t *entity.Transaction) (uint64, error)
 	listByConsumerFn func(ctx context.Context, consumerID uint64) ([]*entity.Transaction, error)
+	summaryFn        func(ctx context.Context, from, to time.Time) ([]*entity.TransactionSummary, error)
+	findFn           func(ctx context.Context, id uint64) (*entity.Transaction, error)
+	hasOpenFn        func(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error)
+	updated          []entity.Transaction
 }
 
 func (m *mockTxRepoTx) Create(ctx context.Context, tx *sql.Tx, t *entity.Transaction) (uint64, error) {
@@ -40,11 +47,47 @@ func (m *mockTxRepoTx) Create(ctx context.Context, tx *sql.Tx, t *entity.Transac
 func (m *mockTxRepoTx) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Transaction, error) {
 	return m.listByConsumerFn(ctx, consumerID)
 }
+
+func (m *mockTxRepoTx) FindByID(ctx context.Context, id uint64) (*entity.Transaction, error) {
+	if m.findFn != nil {
+		return m.findFn(ctx, id)
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockTxRepoTx) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.Transaction, error) {
+	return m.FindByID(ctx, id)
+}
+
+func (m *mockTxRepoTx) UpdateRepayment(ctx context.Context, tx *sql.Tx, t *entity.Transaction) error {
+	m.updated = append(m.updated, *t)
+	return nil
+}
+
+func (m *mockTxRepoTx) Summary(ctx context.Context, from, to time.Time) ([]*entity.TransactionSummary, error) {
+	return m.summaryFn(ctx, from, to)
+}
+
+func (m *mockTxRepoTx) HasOpenByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+	if m.hasOpenFn != nil {
+		return m.hasOpenFn(ctx, tx, consumerID)
+	}
+	return false, nil
+}
+func verifiedAuthRepo() *mockAuthRepoForRegister {
+	verifiedAt := time.Now().UTC()
+	return &mockAuthRepoForRegister{
+		findByConsumerIDFn: func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 1, ConsumerID: consumerID, VerifiedAt: &verifiedAt}, nil
+		},
+	}
+}
+
 func TestPurchase_InvalidTenor(t *testing.T) {
 	db, _, _ := sqlmock.New()
 	defer db.Close()
 
-	uc := NewConsumerTransactionUsecase(db, nil, nil, nil)
+	uc := NewConsumerTransactionUsecase(db, nil, nil, nil, nil, &mockConsumerRepo{}, &mockLimitLedgerRepo{}, nil, 0)
 
 	_, err := uc.Purchase(context.Background(), 1, 1, 5)
 
@@ -57,16 +100,6 @@ func TestPurchase_InsufficientLimit(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-
-	// SELECT FOR UPDATE
-	rows := sqlmock.NewRows([]string{
-		"id", "consumer_id", "tenor_month", "max_limit", "used_limit",
-	}).AddRow(1, 1, 3, 1000000.0, 900000.0)
-
-	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, consumer_id, tenor_month, max_limit, used_limit FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ? FOR UPDATE`,
-	)).WithArgs(1, 3).WillReturnRows(rows)
-
 	mock.ExpectCommit()
 
 	assetRepo := &mockAssetRepoTx{
@@ -77,6 +110,14 @@ func TestPurchase_InsufficientLimit(t *testing.T) {
 			}, nil
 		},
 	}
+	limitRepo := &mockConsumerLimitRepo{
+		addFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			return false, nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 1, ConsumerID: 1, TenorMonth: 3, MaxLimit: 1000000, UsedLimit: 400000, HeldLimit: 200000, Active: true}, nil
+		},
+	}
 
 	txRepo := &mockTxRepoTx{
 		createFn: func(ctx context.Context, tx *sql.Tx, tr *entity.Transaction) (uint64, error) {
@@ -87,33 +128,22 @@ func TestPurchase_InsufficientLimit(t *testing.T) {
 		},
 	}
 
-	uc := NewConsumerTransactionUsecase(db, assetRepo, nil, txRepo)
+	uc := NewConsumerTransactionUsecase(db, assetRepo, limitRepo, txRepo, verifiedAuthRepo(), &mockConsumerRepo{}, &mockLimitLedgerRepo{}, nil, 0)
 
 	_, err := uc.Purchase(context.Background(), 1, 1, 3)
 
 	if !errors.Is(err, ErrInsufficientLimit) {
 		t.Fatal("expected insufficient limit error")
 	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
 }
 func TestPurchase_Success(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
 	mock.ExpectBegin()
-
-	rows := sqlmock.NewRows([]string{
-		"id", "consumer_id", "tenor_month", "max_limit", "used_limit",
-	}).AddRow(1, 1, 3, 5000000.0, 0.0)
-
-	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, consumer_id, tenor_month, max_limit, used_limit FROM consumer_limits WHERE consumer_id = ? AND tenor_month = ? FOR UPDATE`,
-	)).WithArgs(1, 3).WillReturnRows(rows)
-
-	mock.ExpectExec(regexp.QuoteMeta(
-		`UPDATE consumer_limits SET used_limit = ?, updated_at = ? WHERE id = ?`,
-	)).WithArgs(1000000.0, sqlmock.AnyArg(), 1).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
 	mock.ExpectCommit()
 
 	price := 1000000.0
@@ -126,6 +156,16 @@ func TestPurchase_Success(t *testing.T) {
 			}, nil
 		},
 	}
+	var debited float64
+	limitRepo := &mockConsumerLimitRepo{
+		addFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			debited = amount
+			return true, nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 1, ConsumerID: 1, TenorMonth: 3, MaxLimit: 5000000, UsedLimit: debited, Active: true}, nil
+		},
+	}
 
 	txRepo := &mockTxRepoTx{
 		createFn: func(ctx context.Context, tx *sql.Tx, tr *entity.Transaction) (uint64, error) {
@@ -147,7 +187,8 @@ func TestPurchase_Success(t *testing.T) {
 		},
 	}
 
-	uc := NewConsumerTransactionUsecase(db, assetRepo, nil, txRepo)
+	ledger := &mockLimitLedgerRepo{}
+	uc := NewConsumerTransactionUsecase(db, assetRepo, limitRepo, txRepo, verifiedAuthRepo(), &mockConsumerRepo{}, ledger, nil, 0)
 
 	tr, err := uc.Purchase(context.Background(), 1, 1, 3)
 	if err != nil {
@@ -157,6 +198,139 @@ func TestPurchase_Success(t *testing.T) {
 	if tr.Status != "SUCCESS" {
 		t.Fatal("transaction should success")
 	}
+	if debited != price {
+		t.Fatalf("expected %v debited, got %v", price, debited)
+	}
+	if len(ledger.entries) != 1 {
+		t.Fatalf("expected one ledger entry, got %d", len(ledger.entries))
+	}
+	if e := ledger.entries[0]; e.Amount != price || e.UsedBefore != 0 || e.UsedAfter != price || e.SourceID != "1" {
+		t.Fatalf("unexpected ledger entry %+v", e)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+func TestPurchaseForMerchant_RejectsOtherMerchantsAsset(t *testing.T) {
+	owner := uint64(7)
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: 100, MerchantID: &owner}, nil
+		},
+	}
+
+	uc := NewConsumerTransactionUsecase(nil, assetRepo, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, &mockLimitLedgerRepo{}, nil, 0)
+
+	_, err := uc.PurchaseForMerchant(context.Background(), 8, 1, 1, 3, "token")
+	if !errors.Is(err, ErrMerchantAssetNotFound) {
+		t.Fatal("expected asset not found error")
+	}
+}
+
+type mockPurchaseAuthRepo struct {
+	auths []*entity.PurchaseAuthorization
+	used  []uint64
+}
+
+func (m *mockPurchaseAuthRepo) Create(ctx context.Context, tx *sql.Tx, a *entity.PurchaseAuthorization) (uint64, error) {
+	c := *a
+	c.ID = uint64(len(m.auths) + 1)
+	m.auths = append(m.auths, &c)
+	return c.ID, nil
+}
+
+func (m *mockPurchaseAuthRepo) FindByHashForUpdate(ctx context.Context, tx *sql.Tx, hash string) (*entity.PurchaseAuthorization, error) {
+	for _, a := range m.auths {
+		if a.TokenHash == hash {
+			return a, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockPurchaseAuthRepo) MarkUsed(ctx context.Context, tx *sql.Tx, id uint64) error {
+	m.used = append(m.used, id)
+	return nil
+}
+
+func TestPurchaseForMerchant_RequiresConsumerAuthorization(t *testing.T) {
+	owner := uint64(7)
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: 100, MerchantID: &owner}, nil
+		},
+	}
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	// issuing the token, then two refused purchases
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	auths := &mockPurchaseAuthRepo{}
+	uc := NewConsumerTransactionUsecase(db, assetRepo, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, &mockLimitLedgerRepo{}, auths, time.Minute)
+
+	tok, err := uc.AuthorizePurchase(context.Background(), 1, AuthorizePurchaseRequest{MerchantID: 7, AssetID: 3, Tenor: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another consumer's purchase, and a different asset, are refused
+	if _, err := uc.PurchaseForMerchant(context.Background(), 7, 2, 3, 3, tok.Token); !errors.Is(err, ErrPurchaseNotAuthorized) {
+		t.Fatalf("expected not authorized for another consumer, got %v", err)
+	}
+	if _, err := uc.PurchaseForMerchant(context.Background(), 7, 1, 4, 3, tok.Token); !errors.Is(err, ErrPurchaseNotAuthorized) {
+		t.Fatalf("expected not authorized for another asset, got %v", err)
+	}
+	if len(auths.used) != 0 {
+		t.Fatal("refused purchases must not spend the token")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurchaseForMerchant_SpendsAuthorization(t *testing.T) {
+	owner := uint64(7)
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: 1000000, MerchantID: &owner}, nil
+		},
+	}
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	limitRepo := &mockConsumerLimitRepo{
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 1, ConsumerID: 1, TenorMonth: 3, MaxLimit: 5000000, UsedLimit: 1000000, Active: true}, nil
+		},
+	}
+
+	txRepo := &mockTxRepoTx{
+		createFn: func(ctx context.Context, tx *sql.Tx, tr *entity.Transaction) (uint64, error) { return 1, nil },
+	}
+	auths := &mockPurchaseAuthRepo{}
+	uc := NewConsumerTransactionUsecase(db, assetRepo, limitRepo, txRepo, verifiedAuthRepo(), &mockConsumerRepo{}, &mockLimitLedgerRepo{}, auths, time.Minute)
+
+	tok, err := uc.AuthorizePurchase(context.Background(), 1, AuthorizePurchaseRequest{MerchantID: 7, AssetID: 3, Tenor: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.PurchaseForMerchant(context.Background(), 7, 1, 3, 3, tok.Token); err != nil {
+		t.Fatal(err)
+	}
+	if len(auths.used) != 1 {
+		t.Fatal("expected the token to be spent")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
 }
 func TestListByConsumer(t *testing.T) {
 	expected := []*entity.Transaction{
@@ -169,7 +343,7 @@ func TestListByConsumer(t *testing.T) {
 		},
 	}
 
-	uc := NewConsumerTransactionUsecase(nil, nil, nil, txRepo)
+	uc := NewConsumerTransactionUsecase(nil, nil, nil, txRepo, nil, &mockConsumerRepo{}, &mockLimitLedgerRepo{}, nil, 0)
 
 	result, err := uc.ListByConsumer(context.Background(), 1)
 	if err != nil {
@@ -180,3 +354,76 @@ func TestListByConsumer(t *testing.T) {
 		t.Fatal("should return 1 record")
 	}
 }
+
+func TestPurchase_EmailNotVerified(t *testing.T) {
+	authRepo := &mockAuthRepoForRegister{
+		findByConsumerIDFn: func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 1, ConsumerID: consumerID}, nil
+		},
+	}
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	uc := NewConsumerTransactionUsecase(db, nil, nil, nil, authRepo, &mockConsumerRepo{}, &mockLimitLedgerRepo{}, nil, 0)
+
+	_, err := uc.Purchase(context.Background(), 1, 1, 3)
+
+	if !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("expected email not verified error, got %v", err)
+	}
+}
+
+func TestPurchase_ConsumerSuspended(t *testing.T) {
+	consumerRepo := &mockConsumerRepo{
+		statusFn: func(ctx context.Context, id uint64) (entity.ConsumerStatus, error) {
+			return entity.ConsumerSuspended, nil
+		},
+	}
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	uc := NewConsumerTransactionUsecase(db, nil, nil, nil, verifiedAuthRepo(), consumerRepo, &mockLimitLedgerRepo{}, nil, 0)
+
+	_, err := uc.Purchase(context.Background(), 1, 1, 3)
+
+	if !errors.Is(err, ErrConsumerNotActive) {
+		t.Fatalf("expected consumer not active error, got %v", err)
+	}
+}
+
+func TestPurchase_LimitInactive(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: 1, PriceProduct: 1000000}, nil
+		},
+	}
+	limitRepo := &mockConsumerLimitRepo{
+		addFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			return false, nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 1, ConsumerID: 1, TenorMonth: 3, MaxLimit: 5000000, Active: false}, nil
+		},
+	}
+
+	uc := NewConsumerTransactionUsecase(db, assetRepo, limitRepo, &mockTxRepoTx{}, verifiedAuthRepo(), &mockConsumerRepo{}, &mockLimitLedgerRepo{}, nil, 0)
+
+	_, err := uc.Purchase(context.Background(), 1, 1, 3)
+
+	if !errors.Is(err, ErrLimitInactive) {
+		t.Fatalf("expected inactive limit error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,379 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrConsumerNotFound = errors.New("consumer not found")
+var ErrNothingToUpdate = errors.New("nothing to update")
+var ErrChangeRequestPending = errors.New("a change request is already pending review")
+var ErrChangeRequestNotFound = errors.New("change request not found")
+var ErrChangeRequestReviewed = errors.New("change request already reviewed")
+var ErrInvalidBirthDate = errors.New("birth_date must be a date as YYYY-MM-DD")
+var ErrNIKBirthDateMismatch = errors.New("NIK does not match birth date")
+var ErrNIKAlreadyRegistered = errors.New("NIK already registered")
+
+// mysqlDuplicateEntry is the MySQL error number for a unique key violation.
+const mysqlDuplicateEntry = 1062
+
+// UpdateConsumerRequest is a partial update; omitted fields are left alone.
+// Consumers may change FullName directly, every other field goes to staff
+// review.
+type UpdateConsumerRequest struct {
+	FullName         *string  `json:"full_name" binding:"omitempty,min=1,max=255"`
+	NIK              *string  `json:"nik" binding:"omitempty,min=1"`
+	LegalName        *string  `json:"legal_name" binding:"omitempty,min=1,max=255"`
+	BirthPlace       *string  `json:"birth_place" binding:"omitempty,min=1,max=255"`
+	BirthDate        *string  `json:"birth_date" binding:"omitempty,min=1"`
+	Salary           *float64 `json:"salary" binding:"omitempty,gt=0"`
+	KTPDocumentID    *string  `json:"ktp_document_id" binding:"omitempty,min=1"`
+	SelfieDocumentID *string  `json:"selfie_document_id" binding:"omitempty,min=1"`
+}
+
+type ReviewChangeRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+type ConsumerProfile struct {
+	ID               uint64                        `json:"id"`
+	NIK              string                        `json:"nik"`
+	FullName         string                        `json:"full_name"`
+	LegalName        string                        `json:"legal_name"`
+	BirthPlace       string                        `json:"birth_place"`
+	BirthDate        string                        `json:"birth_date"`
+	Salary           float64                       `json:"salary"`
+	KTPDocumentID    string                        `json:"ktp_document_id"`
+	SelfieDocumentID string                        `json:"selfie_document_id"`
+	Email            string                        `json:"email"`
+	EmailVerified    bool                          `json:"email_verified"`
+	KYCStatus        entity.KYCStatus              `json:"kyc_status"`
+	RiskGrade        entity.RiskGrade              `json:"risk_grade,omitempty"`
+	Status           entity.ConsumerStatus         `json:"status"`
+	StatusReason     string                        `json:"status_reason,omitempty"`
+	StatusChangedAt  *time.Time                    `json:"status_changed_at,omitempty"`
+	PendingChange    *entity.ConsumerChangeRequest `json:"pending_change,omitempty"`
+}
+
+type ConsumerUsecase struct {
+	db           *sql.DB
+	consumerRepo repository.ConsumerRepository
+	authRepo     repository.AuthRepository
+	changeRepo   repository.ConsumerChangeRequestRepository
+	docRepo      repository.DocumentRepository
+	recalc       *LimitRecalcUsecase
+}
+
+func NewConsumerUsecase(db *sql.DB, c repository.ConsumerRepository, a repository.AuthRepository, ch repository.ConsumerChangeRequestRepository, d repository.DocumentRepository, r *LimitRecalcUsecase) *ConsumerUsecase {
+	return &ConsumerUsecase{db, c, a, ch, d, r}
+}
+
+func (u *ConsumerUsecase) Profile(ctx context.Context, consumerID uint64) (*ConsumerProfile, error) {
+	c, err := u.consumerRepo.FindByID(ctx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := consumerProfile(c)
+	user, err := u.authRepo.FindByConsumerID(ctx, consumerID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if user != nil {
+		p.Email = user.Email
+		p.EmailVerified = user.Verified()
+	}
+
+	pending, err := u.changeRepo.FindPendingByConsumer(ctx, consumerID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	p.PendingChange = pending
+	return p, nil
+}
+
+// UpdateProfile applies the consumer's own edits. A new full name is saved at
+// once; salary and identity changes are filed as a change request, and only
+// one may wait for review at a time.
+func (u *ConsumerUsecase) UpdateProfile(ctx context.Context, consumerID uint64, req UpdateConsumerRequest) (*ConsumerProfile, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rename := req.FullName != nil && *req.FullName != c.FullName
+	change := reviewableChanges(c, req)
+	if !rename && change == nil {
+		return nil, ErrNothingToUpdate
+	}
+
+	if change != nil {
+		if err := u.checkChange(ctx, tx, c, change); err != nil {
+			return nil, err
+		}
+		_, err := u.changeRepo.FindPendingByConsumer(ctx, consumerID)
+		if err == nil {
+			return nil, ErrChangeRequestPending
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+		if _, err := u.changeRepo.Create(ctx, tx, change); err != nil {
+			return nil, err
+		}
+	}
+	if rename {
+		c.FullName = *req.FullName
+		if err := u.consumerRepo.Update(ctx, tx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return u.Profile(ctx, consumerID)
+}
+
+// AdminUpdate lets staff correct any field directly. A new salary
+// recalculates the consumer's limits in the same transaction.
+func (u *ConsumerUsecase) AdminUpdate(ctx context.Context, consumerID, staffUserID uint64, req UpdateConsumerRequest) (*ConsumerProfile, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	change := reviewableChanges(c, req)
+	if (req.FullName == nil || *req.FullName == c.FullName) && change == nil {
+		return nil, ErrNothingToUpdate
+	}
+	if req.FullName != nil {
+		c.FullName = *req.FullName
+	}
+	if change != nil {
+		if err := u.checkChange(ctx, tx, c, change); err != nil {
+			return nil, err
+		}
+		change.Apply(c)
+	}
+	if err := u.consumerRepo.Update(ctx, tx, c); err != nil {
+		if isDuplicateEntry(err) {
+			return nil, ErrNIKAlreadyRegistered
+		}
+		return nil, err
+	}
+	if change != nil && change.Salary != nil {
+		if err := u.recalculateLimits(ctx, tx, c, staffUserID, "salary corrected by staff"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return u.Profile(ctx, consumerID)
+}
+
+// ReviewChange approves or rejects a pending change request. Approval writes
+// the requested values to the consumer, and recalculates the limits for a
+// salary change, in the same transaction.
+func (u *ConsumerUsecase) ReviewChange(ctx context.Context, consumerID, requestID, staffUserID uint64, approve bool, note string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cr, err := u.changeRepo.FindByIDForUpdate(ctx, tx, requestID)
+	if err == sql.ErrNoRows || (err == nil && cr.ConsumerID != consumerID) {
+		return ErrChangeRequestNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if cr.Status != entity.ChangeRequestPending {
+		return ErrChangeRequestReviewed
+	}
+
+	status := entity.ChangeRequestRejected
+	if approve {
+		status = entity.ChangeRequestApproved
+
+		c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+		if err != nil {
+			return err
+		}
+		if err := u.checkChange(ctx, tx, c, cr); err != nil {
+			return err
+		}
+		cr.Apply(c)
+		if err := u.consumerRepo.Update(ctx, tx, c); err != nil {
+			if isDuplicateEntry(err) {
+				return ErrNIKAlreadyRegistered
+			}
+			return err
+		}
+		if cr.Salary != nil {
+			if err := u.recalculateLimits(ctx, tx, c, staffUserID, "salary change approved"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := u.changeRepo.Review(ctx, tx, cr.ID, status, staffUserID, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (u *ConsumerUsecase) recalculateLimits(ctx context.Context, tx *sql.Tx, c *entity.Consumer, staffUserID uint64, reason string) error {
+	_, err := u.recalc.recalculate(ctx, tx, c, false, RecalcTrigger{
+		Source:   entity.LimitSourceStaff,
+		SourceID: strconv.FormatUint(staffUserID, 10),
+		Reason:   reason,
+	})
+	return err
+}
+
+// checkChange claims new document IDs for the consumer, validates a NIK or
+// birth date change against the other value and makes sure a new NIK is not
+// held by another consumer.
+func (u *ConsumerUsecase) checkChange(ctx context.Context, tx *sql.Tx, c *entity.Consumer, change *entity.ConsumerChangeRequest) error {
+	if change.KTPDocumentID != nil {
+		if err := attachDocument(ctx, u.docRepo, tx, c.ID, *change.KTPDocumentID, entity.DocumentKTP); err != nil {
+			return err
+		}
+	}
+	if change.SelfieDocumentID != nil {
+		if err := attachDocument(ctx, u.docRepo, tx, c.ID, *change.SelfieDocumentID, entity.DocumentSelfie); err != nil {
+			return err
+		}
+	}
+
+	if change.NIK == nil && change.BirthDate == nil {
+		return nil
+	}
+
+	next := *c
+	change.Apply(&next)
+	if err := checkIdentity(next.NIK, next.BirthDate); err != nil {
+		return err
+	}
+	if change.NIK != nil && *change.NIK != c.NIK {
+		return ensureNIKAvailable(ctx, u.consumerRepo, tx, *change.NIK)
+	}
+	return nil
+}
+
+// checkIdentity validates the NIK layout and that it encodes birthDate.
+func checkIdentity(nik, birthDate string) error {
+	n, err := utils.ParseNIK(nik, time.Now())
+	if err != nil {
+		return err
+	}
+	d, err := utils.ParseBirthDate(birthDate)
+	if err != nil {
+		return ErrInvalidBirthDate
+	}
+	if !n.MatchesBirthDate(d) {
+		return ErrNIKBirthDateMismatch
+	}
+	return nil
+}
+
+func ensureNIKAvailable(ctx context.Context, repo repository.ConsumerRepository, tx *sql.Tx, nik string) error {
+	exists, err := repo.ExistsByNIK(ctx, tx, nik)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrNIKAlreadyRegistered
+	}
+	return nil
+}
+
+// isDuplicateEntry reports a unique key violation, which is how a NIK race
+// between two concurrent writes surfaces.
+func isDuplicateEntry(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == mysqlDuplicateEntry
+}
+
+// reviewableChanges collects the requested salary and identity fields that
+// differ from the current values, or nil when there are none.
+func reviewableChanges(c *entity.Consumer, req UpdateConsumerRequest) *entity.ConsumerChangeRequest {
+	cr := &entity.ConsumerChangeRequest{
+		ConsumerID:       c.ID,
+		NIK:              changedString(req.NIK, c.NIK),
+		LegalName:        changedString(req.LegalName, c.LegalName),
+		BirthPlace:       changedString(req.BirthPlace, c.BirthPlace),
+		BirthDate:        changedString(req.BirthDate, c.BirthDate),
+		KTPDocumentID:    changedString(req.KTPDocumentID, c.KTPDocumentID),
+		SelfieDocumentID: changedString(req.SelfieDocumentID, c.SelfieDocumentID),
+	}
+	if req.Salary != nil && *req.Salary != c.Salary {
+		cr.Salary = req.Salary
+	}
+
+	if cr.NIK == nil && cr.LegalName == nil && cr.BirthPlace == nil && cr.BirthDate == nil &&
+		cr.KTPDocumentID == nil && cr.SelfieDocumentID == nil && cr.Salary == nil {
+		return nil
+	}
+	return cr
+}
+
+func changedString(v *string, current string) *string {
+	if v == nil || *v == current {
+		return nil
+	}
+	return v
+}
+
+func consumerProfile(c *entity.Consumer) *ConsumerProfile {
+	return &ConsumerProfile{
+		ID:               c.ID,
+		NIK:              c.NIK,
+		FullName:         c.FullName,
+		LegalName:        c.LegalName,
+		BirthPlace:       c.BirthPlace,
+		BirthDate:        c.BirthDate,
+		Salary:           c.Salary,
+		KTPDocumentID:    c.KTPDocumentID,
+		SelfieDocumentID: c.SelfieDocumentID,
+		KYCStatus:        c.KYCStatus,
+		RiskGrade:        c.RiskGrade,
+		Status:           c.Status,
+		StatusReason:     c.StatusReason,
+		StatusChangedAt:  c.StatusChangedAt,
+	}
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockChangeRequestRepo keeps change requests in memory.
+type mockChangeRequestRepo struct {
+	requests []*entity.ConsumerChangeRequest
+	stale    []uint64
+	resealed []uint64
+}
+
+func (m *mockChangeRequestRepo) Create(ctx context.Context, tx *sql.Tx, r *entity.ConsumerChangeRequest) (uint64, error) {
+	r.ID = uint64(len(m.requests) + 1)
+	r.Status = entity.ChangeRequestPending
+	m.requests = append(m.requests, r)
+	return r.ID, nil
+}
+func (m *mockChangeRequestRepo) FindPendingByConsumer(ctx context.Context, consumerID uint64) (*entity.ConsumerChangeRequest, error) {
+	for _, r := range m.requests {
+		if r.ConsumerID == consumerID && r.Status == entity.ChangeRequestPending {
+			return r, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockChangeRequestRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.ConsumerChangeRequest, error) {
+	if id == 0 || id > uint64(len(m.requests)) {
+		return nil, sql.ErrNoRows
+	}
+	return m.requests[id-1], nil
+}
+func (m *mockChangeRequestRepo) Review(ctx context.Context, tx *sql.Tx, id uint64, status entity.ChangeRequestStatus, staffUserID uint64, note string) error {
+	r := m.requests[id-1]
+	r.Status = status
+	r.ReviewedBy = &staffUserID
+	r.ReviewNote = note
+	return nil
+}
+
+func (m *mockChangeRequestRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.ConsumerChangeRequest, error) {
+	var out []*entity.ConsumerChangeRequest
+	for _, r := range m.requests {
+		if r.ConsumerID == consumerID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+func (m *mockChangeRequestRepo) AnonymiseByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+	for _, r := range m.requests {
+		if r.ConsumerID == consumerID {
+			r.NIK, r.LegalName, r.BirthPlace, r.BirthDate, r.Salary = nil, nil, nil, nil, nil
+			r.KTPDocumentID, r.SelfieDocumentID = nil, nil
+		}
+	}
+	return nil
+}
+func (m *mockChangeRequestRepo) ListIDsForReencryption(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error) {
+	if len(m.stale) > limit {
+		return m.stale[:limit], nil
+	}
+	return m.stale, nil
+}
+func (m *mockChangeRequestRepo) UpdatePII(ctx context.Context, tx *sql.Tx, r *entity.ConsumerChangeRequest) error {
+	m.resealed = append(m.resealed, r.ID)
+	return nil
+}
+
+func newTestConsumerUsecase(t *testing.T, consumer *entity.Consumer, changes *mockChangeRequestRepo) *ConsumerUsecase {
+	return newTestConsumerUsecaseWithLimits(t, consumer, changes, &mockConsumerLimitRepo{})
+}
+
+func newTestConsumerUsecaseWithLimits(t *testing.T, consumer *entity.Consumer, changes *mockChangeRequestRepo, limits *mockConsumerLimitRepo) *ConsumerUsecase {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 5; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+
+	repo := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			if id != consumer.ID {
+				return nil, sql.ErrNoRows
+			}
+			c := *consumer
+			return &c, nil
+		},
+		updateFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error {
+			*consumer = *c
+			return nil
+		},
+	}
+	policies := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(1)}}
+	recalc := NewLimitRecalcUsecase(db, repo, limits, policies, &mockLimitLedgerRepo{})
+	return NewConsumerUsecase(db, repo, verifiedAuthRepo(), changes, testDocuments(), recalc)
+}
+
+func testConsumer() *entity.Consumer {
+	return &entity.Consumer{ID: 10, NIK: "3173010101900001", FullName: "Budi", LegalName: "BUDI SANTOSO", BirthPlace: "Jakarta", BirthDate: "1990-01-01", Salary: 5000000}
+}
+
+func TestUpdateProfile_SalaryNeedsReview(t *testing.T) {
+	consumer := testConsumer()
+	changes := &mockChangeRequestRepo{}
+	u := newTestConsumerUsecase(t, consumer, changes)
+
+	name, salary := "Budi S", 9000000.0
+	p, err := u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{FullName: &name, Salary: &salary})
+	require.NoError(t, err)
+
+	require.Equal(t, "Budi S", p.FullName)
+	require.Equal(t, 5000000.0, p.Salary)
+	require.NotNil(t, p.PendingChange)
+	require.Equal(t, salary, *p.PendingChange.Salary)
+	require.Nil(t, p.PendingChange.NIK)
+
+	legal := "BUDI SANTOSO PUTRA"
+	_, err = u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{LegalName: &legal})
+	require.Equal(t, ErrChangeRequestPending, err)
+
+	_, err = u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{FullName: &name})
+	require.Equal(t, ErrNothingToUpdate, err)
+}
+
+func TestReviewChange_ApproveAppliesChanges(t *testing.T) {
+	consumer := testConsumer()
+	changes := &mockChangeRequestRepo{}
+	u := newTestConsumerUsecase(t, consumer, changes)
+
+	salary := 9000000.0
+	p, err := u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{Salary: &salary})
+	require.NoError(t, err)
+	id := p.PendingChange.ID
+
+	require.Equal(t, ErrChangeRequestNotFound, u.ReviewChange(context.Background(), 11, id, 1, true, ""))
+	require.NoError(t, u.ReviewChange(context.Background(), 10, id, 1, true, "payslip checked"))
+	require.Equal(t, salary, consumer.Salary)
+	require.Equal(t, entity.ChangeRequestApproved, changes.requests[0].Status)
+
+	require.Equal(t, ErrChangeRequestReviewed, u.ReviewChange(context.Background(), 10, id, 1, false, ""))
+}
+
+func TestReviewChange_SalaryApprovalRecalculatesLimits(t *testing.T) {
+	consumer := testConsumer()
+	changes := &mockChangeRequestRepo{}
+	updated := map[uint8]float64{}
+	limits := &mockConsumerLimitRepo{
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: uint64(tenor), ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 2000000}, nil
+		},
+		maxFn: func(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, policyVersion uint32) error {
+			updated[uint8(id)] = maxLimit
+			return nil
+		},
+	}
+	u := newTestConsumerUsecaseWithLimits(t, consumer, changes, limits)
+
+	salary := 9000000.0
+	p, err := u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{Salary: &salary})
+	require.NoError(t, err)
+	require.Empty(t, updated, "limits must not move before approval")
+
+	require.NoError(t, u.ReviewChange(context.Background(), 10, p.PendingChange.ID, 1, true, ""))
+	require.Equal(t, 3600000.0, updated[1])
+	require.Equal(t, 2000.0, updated[6])
+}
+
+func TestAdminUpdate_AppliesImmediately(t *testing.T) {
+	consumer := testConsumer()
+	changes := &mockChangeRequestRepo{}
+	u := newTestConsumerUsecase(t, consumer, changes)
+
+	place := "Bandung"
+	p, err := u.AdminUpdate(context.Background(), 10, 1, UpdateConsumerRequest{BirthPlace: &place})
+	require.NoError(t, err)
+	require.Equal(t, "Bandung", p.BirthPlace)
+	require.Nil(t, p.PendingChange)
+	require.Empty(t, changes.requests)
+}
+
+func TestUpdateProfile_ChecksNIKAgainstBirthDate(t *testing.T) {
+	consumer := testConsumer()
+	u := newTestConsumerUsecase(t, consumer, &mockChangeRequestRepo{})
+
+	date := "1990-01-02"
+	_, err := u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{BirthDate: &date})
+	require.Equal(t, ErrNIKBirthDateMismatch, err)
+
+	nik := "3173010201900001"
+	p, err := u.UpdateProfile(context.Background(), 10, UpdateConsumerRequest{NIK: &nik, BirthDate: &date})
+	require.NoError(t, err)
+	require.Equal(t, nik, *p.PendingChange.NIK)
+}
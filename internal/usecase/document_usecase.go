@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/infrastructure/storage"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+var ErrDocumentNotFound = errors.New("document not found")
+var ErrDocumentTooLarge = errors.New("document is too large")
+var ErrDocumentEmpty = errors.New("document is empty")
+var ErrUnsupportedDocumentType = errors.New("document must be a JPEG or PNG image")
+var ErrInvalidDocumentKind = errors.New("invalid document kind")
+var ErrDocumentKindMismatch = errors.New("document is of the wrong kind")
+var ErrDocumentInUse = errors.New("document belongs to another consumer")
+var ErrInvalidDocumentURL = errors.New("invalid or expired document link")
+
+// allowedDocumentTypes are sniffed from the content, not taken from the
+// client's Content-Type header.
+// documentSweepBatch is how many unclaimed documents one sweeper transaction
+// deletes.
+const documentSweepBatch = 100
+
+var allowedDocumentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+type UploadedDocument struct {
+	ID          string              `json:"id"`
+	Kind        entity.DocumentKind `json:"kind"`
+	ContentType string              `json:"content_type"`
+	Size        int64               `json:"size"`
+	SHA256      string              `json:"sha256"`
+}
+
+type DocumentURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DocumentUsecase stores identity photos in the blob store and hands out
+// short-lived signed links to read them back. Anonymous uploads that no
+// registration claims within unclaimedTTL are deleted by the sweeper.
+type DocumentUsecase struct {
+	db           *sql.DB
+	docRepo      repository.DocumentRepository
+	store        storage.BlobStore
+	urlKey       []byte
+	urlTTL       time.Duration
+	maxSize      int64
+	unclaimedTTL time.Duration
+}
+
+func NewDocumentUsecase(db *sql.DB, d repository.DocumentRepository, store storage.BlobStore, urlKey []byte, urlTTL time.Duration, maxSize int64, unclaimedTTL time.Duration) *DocumentUsecase {
+	return &DocumentUsecase{db, d, store, urlKey, urlTTL, maxSize, unclaimedTTL}
+}
+
+// Upload stores the content under its SHA-256 and records a new document.
+// consumerID is nil for uploads made before registration; Register attaches
+// those documents to the new consumer.
+func (u *DocumentUsecase) Upload(ctx context.Context, consumerID *uint64, kind entity.DocumentKind, r io.Reader) (*UploadedDocument, error) {
+	if !kind.Valid() {
+		return nil, ErrInvalidDocumentKind
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, u.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > u.maxSize {
+		return nil, ErrDocumentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrDocumentEmpty
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedDocumentTypes[contentType] {
+		return nil, ErrUnsupportedDocumentType
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if err := u.store.Put(ctx, hash, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	d := &entity.Document{
+		ID:          "doc_" + token,
+		ConsumerID:  consumerID,
+		Kind:        kind,
+		SHA256:      hash,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := u.docRepo.Create(ctx, tx, d); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &UploadedDocument{ID: d.ID, Kind: d.Kind, ContentType: d.ContentType, Size: d.Size, SHA256: d.SHA256}, nil
+}
+
+// SignedURL returns a download link valid for the configured TTL. When
+// consumerID is set the document must belong to that consumer; staff pass nil.
+func (u *DocumentUsecase) SignedURL(ctx context.Context, id string, consumerID *uint64) (*DocumentURL, error) {
+	d, err := u.docRepo.FindByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if consumerID != nil && (d.ConsumerID == nil || *d.ConsumerID != *consumerID) {
+		return nil, ErrDocumentNotFound
+	}
+
+	expiresAt := time.Now().UTC().Add(u.urlTTL).Truncate(time.Second)
+	path := documentContentPath(d.ID)
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	q.Set("signature", utils.SignURL(u.urlKey, path, expiresAt.Unix()))
+	return &DocumentURL{URL: path + "?" + q.Encode(), ExpiresAt: expiresAt}, nil
+}
+
+// Open checks a signed link and returns the document with its content. The
+// caller closes the reader.
+func (u *DocumentUsecase) Open(ctx context.Context, id, expires, signature string) (*entity.Document, io.ReadCloser, error) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp || !utils.VerifyURLSignature(u.urlKey, documentContentPath(id), exp, signature) {
+		return nil, nil, ErrInvalidDocumentURL
+	}
+
+	d, err := u.docRepo.FindByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := u.store.Open(ctx, d.SHA256)
+	if err == storage.ErrBlobNotFound {
+		return nil, nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, rc, nil
+}
+
+// DeleteUnclaimed deletes up to one batch of documents left unclaimed past the
+// TTL and returns how many it deleted. A blob is removed only once no other
+// document shares it.
+func (u *DocumentUsecase) DeleteUnclaimed(ctx context.Context) (int, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	docs, err := u.docRepo.ListUnclaimedForUpdate(ctx, tx, time.Now().UTC().Add(-u.unclaimedTTL), documentSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range docs {
+		if err := u.docRepo.Delete(ctx, tx, d.ID); err != nil {
+			return 0, err
+		}
+	}
+	orphans := map[string]bool{}
+	for _, d := range docs {
+		shared, err := u.docRepo.ExistsBySHA256(ctx, tx, d.SHA256)
+		if err != nil {
+			return 0, err
+		}
+		if !shared {
+			orphans[d.SHA256] = true
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// As with erasure, blobs go only after the commit; one that fails to
+	// delete is logged and left behind.
+	for sha := range orphans {
+		if err := u.store.Delete(ctx, sha); err != nil {
+			log.Printf("sweeping unclaimed documents: deleting blob %s: %v", sha, err)
+		}
+	}
+	return len(docs), nil
+}
+
+// RunSweeper deletes unclaimed documents every interval until ctx is done.
+// Each tick keeps deleting batches until none are left.
+func (u *DocumentUsecase) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := u.DeleteUnclaimed(ctx)
+			if err != nil {
+				log.Printf("sweeping unclaimed documents: %v", err)
+				break
+			}
+			if n < documentSweepBatch {
+				break
+			}
+		}
+	}
+}
+
+func documentContentPath(id string) string {
+	return "/api/documents/" + url.PathEscape(id) + "/content"
+}
+
+// attachDocument checks that document id is of the expected kind and claims it
+// for the consumer. Documents already attached to the same consumer are fine.
+func attachDocument(ctx context.Context, repo repository.DocumentRepository, tx *sql.Tx, consumerID uint64, id string, kind entity.DocumentKind) error {
+	d, err := repo.FindByIDForUpdate(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		return ErrDocumentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if d.Kind != kind {
+		return ErrDocumentKindMismatch
+	}
+	if d.ConsumerID != nil {
+		if *d.ConsumerID == consumerID {
+			return nil
+		}
+		return ErrDocumentInUse
+	}
+	return repo.Claim(ctx, tx, id, consumerID)
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/infrastructure/storage"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDocumentRepo keeps documents in memory.
+type mockDocumentRepo struct {
+	docs map[string]*entity.Document
+}
+
+func newMockDocumentRepo(docs ...*entity.Document) *mockDocumentRepo {
+	m := &mockDocumentRepo{docs: map[string]*entity.Document{}}
+	for _, d := range docs {
+		m.docs[d.ID] = d
+	}
+	return m
+}
+
+func (m *mockDocumentRepo) Create(ctx context.Context, tx *sql.Tx, d *entity.Document) error {
+	if m.docs == nil {
+		m.docs = map[string]*entity.Document{}
+	}
+	m.docs[d.ID] = d
+	return nil
+}
+func (m *mockDocumentRepo) FindByID(ctx context.Context, id string) (*entity.Document, error) {
+	d, ok := m.docs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return d, nil
+}
+func (m *mockDocumentRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id string) (*entity.Document, error) {
+	return m.FindByID(ctx, id)
+}
+func (m *mockDocumentRepo) Claim(ctx context.Context, tx *sql.Tx, id string, consumerID uint64) error {
+	m.docs[id].ConsumerID = &consumerID
+	return nil
+}
+
+func (m *mockDocumentRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Document, error) {
+	var out []*entity.Document
+	for _, d := range m.docs {
+		if d.ConsumerID != nil && *d.ConsumerID == consumerID {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+func (m *mockDocumentRepo) DeleteByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+	for id, d := range m.docs {
+		if d.ConsumerID != nil && *d.ConsumerID == consumerID {
+			delete(m.docs, id)
+		}
+	}
+	return nil
+}
+func (m *mockDocumentRepo) ListUnclaimedForUpdate(ctx context.Context, tx *sql.Tx, before time.Time, limit int) ([]*entity.Document, error) {
+	var out []*entity.Document
+	for _, d := range m.docs {
+		if d.ConsumerID == nil && d.CreatedAt.Before(before) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+func (m *mockDocumentRepo) Delete(ctx context.Context, tx *sql.Tx, id string) error {
+	delete(m.docs, id)
+	return nil
+}
+func (m *mockDocumentRepo) ExistsBySHA256(ctx context.Context, tx *sql.Tx, sha256 string) (bool, error) {
+	for _, d := range m.docs {
+		if d.SHA256 == sha256 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// testDocuments returns the unclaimed KTP and selfie the test consumers use.
+func testDocuments() *mockDocumentRepo {
+	return newMockDocumentRepo(
+		&entity.Document{ID: "doc_ktp1", Kind: entity.DocumentKTP},
+		&entity.Document{ID: "doc_selfie1", Kind: entity.DocumentSelfie},
+		&entity.Document{ID: "doc_ktp2", Kind: entity.DocumentKTP},
+		&entity.Document{ID: "doc_selfie2", Kind: entity.DocumentSelfie},
+	)
+}
+
+type memoryBlobStore struct {
+	blobs map[string][]byte
+}
+
+func (s *memoryBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.blobs[key] = b
+	return nil
+}
+func (s *memoryBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, storage.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+func (s *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+// pngHeader is enough for content sniffing to report image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newTestDocumentUsecase(t *testing.T, repo *mockDocumentRepo, store *memoryBlobStore) *DocumentUsecase {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 5; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+	return NewDocumentUsecase(db, repo, store, []byte("0123456789abcdef0123456789abcdef"), 15*time.Minute, 1024, 24*time.Hour)
+}
+
+func TestDocumentUpload_SignedDownload(t *testing.T) {
+	repo := newMockDocumentRepo()
+	store := &memoryBlobStore{blobs: map[string][]byte{}}
+	u := newTestDocumentUsecase(t, repo, store)
+
+	first, err := u.Upload(context.Background(), nil, entity.DocumentKTP, bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+	require.Equal(t, "image/png", first.ContentType)
+	require.True(t, strings.HasPrefix(first.ID, "doc_"))
+
+	consumerID := uint64(10)
+	second, err := u.Upload(context.Background(), &consumerID, entity.DocumentKTP, bytes.NewReader(pngHeader))
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID)
+	require.Equal(t, first.SHA256, second.SHA256)
+	require.Len(t, store.blobs, 1)
+
+	_, err = u.SignedURL(context.Background(), first.ID, &consumerID)
+	require.Equal(t, ErrDocumentNotFound, err)
+
+	link, err := u.SignedURL(context.Background(), second.ID, &consumerID)
+	require.NoError(t, err)
+	parsed, err := url.Parse(link.URL)
+	require.NoError(t, err)
+	q := parsed.Query()
+
+	d, rc, err := u.Open(context.Background(), second.ID, q.Get("expires"), q.Get("signature"))
+	require.NoError(t, err)
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, pngHeader, body)
+	require.Equal(t, "image/png", d.ContentType)
+
+	_, _, err = u.Open(context.Background(), first.ID, q.Get("expires"), q.Get("signature"))
+	require.Equal(t, ErrInvalidDocumentURL, err)
+	_, _, err = u.Open(context.Background(), second.ID, "1700000000", q.Get("signature"))
+	require.Equal(t, ErrInvalidDocumentURL, err)
+}
+
+func TestDocumentUpload_Validation(t *testing.T) {
+	u := newTestDocumentUsecase(t, newMockDocumentRepo(), &memoryBlobStore{blobs: map[string][]byte{}})
+
+	_, err := u.Upload(context.Background(), nil, entity.DocumentKind("PASSPORT"), bytes.NewReader(pngHeader))
+	require.Equal(t, ErrInvalidDocumentKind, err)
+
+	_, err = u.Upload(context.Background(), nil, entity.DocumentKTP, strings.NewReader(""))
+	require.Equal(t, ErrDocumentEmpty, err)
+
+	_, err = u.Upload(context.Background(), nil, entity.DocumentKTP, strings.NewReader("<html><body>hi</body></html>"))
+	require.Equal(t, ErrUnsupportedDocumentType, err)
+
+	_, err = u.Upload(context.Background(), nil, entity.DocumentKTP, bytes.NewReader(append(pngHeader, make([]byte, 1024)...)))
+	require.Equal(t, ErrDocumentTooLarge, err)
+}
+
+func TestAttachDocument(t *testing.T) {
+	other := uint64(11)
+	repo := newMockDocumentRepo(
+		&entity.Document{ID: "doc_ktp", Kind: entity.DocumentKTP},
+		&entity.Document{ID: "doc_taken", Kind: entity.DocumentKTP, ConsumerID: &other},
+	)
+
+	require.Equal(t, ErrDocumentNotFound, attachDocument(context.Background(), repo, nil, 10, "doc_missing", entity.DocumentKTP))
+	require.Equal(t, ErrDocumentKindMismatch, attachDocument(context.Background(), repo, nil, 10, "doc_ktp", entity.DocumentSelfie))
+	require.Equal(t, ErrDocumentInUse, attachDocument(context.Background(), repo, nil, 10, "doc_taken", entity.DocumentKTP))
+
+	require.NoError(t, attachDocument(context.Background(), repo, nil, 10, "doc_ktp", entity.DocumentKTP))
+	require.Equal(t, uint64(10), *repo.docs["doc_ktp"].ConsumerID)
+	require.NoError(t, attachDocument(context.Background(), repo, nil, 10, "doc_ktp", entity.DocumentKTP))
+}
+
+func TestDeleteUnclaimed(t *testing.T) {
+	consumerID := uint64(10)
+	old := time.Now().Add(-25 * time.Hour)
+	repo := newMockDocumentRepo(
+		&entity.Document{ID: "doc_stale", Kind: entity.DocumentKTP, SHA256: "aaa", CreatedAt: old},
+		&entity.Document{ID: "doc_stale_shared", Kind: entity.DocumentKTP, SHA256: "bbb", CreatedAt: old},
+		&entity.Document{ID: "doc_claimed", Kind: entity.DocumentKTP, SHA256: "bbb", CreatedAt: old, ConsumerID: &consumerID},
+		&entity.Document{ID: "doc_fresh", Kind: entity.DocumentSelfie, SHA256: "ccc", CreatedAt: time.Now()},
+	)
+	store := &memoryBlobStore{blobs: map[string][]byte{"aaa": pngHeader, "bbb": pngHeader, "ccc": pngHeader}}
+	u := newTestDocumentUsecase(t, repo, store)
+
+	n, err := u.DeleteUnclaimed(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	require.NotContains(t, repo.docs, "doc_stale")
+	require.NotContains(t, repo.docs, "doc_stale_shared")
+	require.Contains(t, repo.docs, "doc_claimed")
+	require.Contains(t, repo.docs, "doc_fresh")
+	// bbb is still used by the claimed document
+	require.NotContains(t, store.blobs, "aaa")
+	require.Contains(t, store.blobs, "bbb")
+	require.Contains(t, store.blobs, "ccc")
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/infrastructure/notifier"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+var ErrEmailNotVerified = errors.New("email address is not verified")
+var ErrEmailAlreadyVerified = errors.New("email address is already verified")
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+type EmailVerificationUsecase struct {
+	db            *sql.DB
+	authRepo      repository.AuthRepository
+	userTokenRepo repository.UserTokenRepository
+	notifier      notifier.Notifier
+	ttl           time.Duration
+	verifyURL     string
+}
+
+func NewEmailVerificationUsecase(db *sql.DB, a repository.AuthRepository, ut repository.UserTokenRepository, n notifier.Notifier, ttl time.Duration, verifyURL string) *EmailVerificationUsecase {
+	return &EmailVerificationUsecase{db, a, ut, n, ttl, verifyURL}
+}
+
+// SendByEmail mails a verification link to a freshly registered account.
+func (u *EmailVerificationUsecase) SendByEmail(ctx context.Context, email string) error {
+	user, err := u.authRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	return u.send(ctx, user)
+}
+
+// Resend issues a new link, invalidating any earlier one.
+func (u *EmailVerificationUsecase) Resend(ctx context.Context, authUserID uint64) error {
+	user, err := u.authRepo.FindByID(ctx, authUserID)
+	if err != nil {
+		return err
+	}
+	return u.send(ctx, user)
+}
+
+func (u *EmailVerificationUsecase) send(ctx context.Context, user *entity.AuthUser) error {
+	if user.Verified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.userTokenRepo.InvalidateByAuthUser(ctx, tx, user.ID, entity.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+	err = u.userTokenRepo.Create(ctx, tx, &entity.UserToken{
+		AuthUserID: user.ID,
+		Purpose:    entity.TokenPurposeEmailVerification,
+		TokenHash:  utils.HashToken(token),
+		ExpiresAt:  time.Now().UTC().Add(u.ttl),
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return u.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Open the link below to verify your email address. It expires in %s.\n\n%s?token=%s",
+			u.ttl, u.verifyURL, url.QueryEscape(token)),
+	})
+}
+
+// Verify redeems a verification token and marks the email as verified.
+func (u *EmailVerificationUsecase) Verify(ctx context.Context, req VerifyEmailRequest) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := u.userTokenRepo.FindByHashForUpdate(ctx, tx, entity.TokenPurposeEmailVerification, utils.HashToken(req.Token))
+	if err == sql.ErrNoRows {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+	if t.UsedAt != nil || !time.Now().UTC().Before(t.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	if err := u.userTokenRepo.MarkUsed(ctx, tx, t.ID); err != nil {
+		return err
+	}
+	if err := u.authRepo.MarkVerified(ctx, tx, t.AuthUserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailVerification_SendByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	tokenRepo := &mockUserTokenRepo{}
+	n := &mockNotifier{}
+	u := NewEmailVerificationUsecase(db, passwordAuthRepo(t, "Secret123"), tokenRepo, n, time.Hour, "https://app.example/verify")
+
+	require.NoError(t, u.SendByEmail(context.Background(), "budi@mail.com"))
+	require.Len(t, n.sent, 1)
+	require.Contains(t, n.sent[0].Body, "https://app.example/verify?token=")
+	require.Len(t, tokenRepo.created, 1)
+	require.Equal(t, entity.TokenPurposeEmailVerification, tokenRepo.created[0].Purpose)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerification_ResendWhenVerified(t *testing.T) {
+	verifiedAt := time.Now()
+	authRepo := &mockAuthRepoForRegister{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: id, Email: "budi@mail.com", VerifiedAt: &verifiedAt}, nil
+		},
+	}
+	n := &mockNotifier{}
+	u := NewEmailVerificationUsecase(nil, authRepo, &mockUserTokenRepo{}, n, time.Hour, "")
+
+	require.Equal(t, ErrEmailAlreadyVerified, u.Resend(context.Background(), 5))
+	require.Empty(t, n.sent)
+}
+
+func TestEmailVerification_Verify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	tokenRepo := &mockUserTokenRepo{
+		findFn: func(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error) {
+			require.Equal(t, entity.TokenPurposeEmailVerification, purpose)
+			require.Equal(t, utils.HashToken("tok"), hash)
+			return &entity.UserToken{ID: 4, AuthUserID: 5, Purpose: purpose, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	var verified uint64
+	authRepo := &mockAuthRepoForRegister{
+		markVerifiedFn: func(ctx context.Context, tx *sql.Tx, id uint64) error {
+			verified = id
+			return nil
+		},
+	}
+	u := NewEmailVerificationUsecase(db, authRepo, tokenRepo, &mockNotifier{}, time.Hour, "")
+
+	require.NoError(t, u.Verify(context.Background(), VerifyEmailRequest{Token: "tok"}))
+	require.Equal(t, uint64(5), verified)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmailVerification_UnknownToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	u := NewEmailVerificationUsecase(db, &mockAuthRepoForRegister{}, &mockUserTokenRepo{}, &mockNotifier{}, time.Hour, "")
+
+	require.Equal(t, ErrInvalidVerificationToken, u.Verify(context.Background(), VerifyEmailRequest{Token: "nope"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
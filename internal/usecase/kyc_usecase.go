@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrInvalidKYCTransition = errors.New("KYC case cannot move to that state")
+var ErrInvalidKYCStatus = errors.New("invalid KYC status")
+
+type KYCApproveRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+// KYCRejectRequest rejects a case for good, or with Resubmit asks the consumer
+// for new documents.
+type KYCRejectRequest struct {
+	Note     string `json:"note" binding:"required,max=255"`
+	Resubmit bool   `json:"resubmit"`
+}
+
+type KYCResubmitRequest struct {
+	KTPDocumentID    string `json:"ktp_document_id" binding:"required"`
+	SelfieDocumentID string `json:"selfie_document_id" binding:"required"`
+}
+
+type KYCCase struct {
+	ConsumerID       uint64              `json:"consumer_id"`
+	NIK              string              `json:"nik"`
+	FullName         string              `json:"full_name"`
+	LegalName        string              `json:"legal_name"`
+	BirthPlace       string              `json:"birth_place"`
+	BirthDate        string              `json:"birth_date"`
+	KTPDocumentID    string              `json:"ktp_document_id"`
+	SelfieDocumentID string              `json:"selfie_document_id"`
+	Status           entity.KYCStatus    `json:"status"`
+	History          []*entity.KYCReview `json:"history,omitempty"`
+}
+
+// KYCUsecase moves consumers through identity review. Credit limits stay
+// inactive until a case is approved.
+type KYCUsecase struct {
+	db           *sql.DB
+	consumerRepo repository.ConsumerRepository
+	reviewRepo   repository.KYCReviewRepository
+	limitRepo    repository.ConsumerLimitRepository
+	docRepo      repository.DocumentRepository
+}
+
+func NewKYCUsecase(db *sql.DB, c repository.ConsumerRepository, r repository.KYCReviewRepository, l repository.ConsumerLimitRepository, d repository.DocumentRepository) *KYCUsecase {
+	return &KYCUsecase{db, c, r, l, d}
+}
+
+func (u *KYCUsecase) List(ctx context.Context, status entity.KYCStatus) ([]*KYCCase, error) {
+	if !status.Valid() {
+		return nil, ErrInvalidKYCStatus
+	}
+
+	consumers, err := u.consumerRepo.ListByKYCStatus(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]*KYCCase, 0, len(consumers))
+	for _, c := range consumers {
+		cases = append(cases, kycCase(c))
+	}
+	return cases, nil
+}
+
+// Get returns the case together with its decision history.
+func (u *KYCUsecase) Get(ctx context.Context, consumerID uint64) (*KYCCase, error) {
+	c, err := u.consumerRepo.FindByID(ctx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kc := kycCase(c)
+	if kc.History, err = u.reviewRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	return kc, nil
+}
+
+func (u *KYCUsecase) Approve(ctx context.Context, consumerID, staffUserID uint64, note string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := u.findForUpdate(ctx, tx, consumerID)
+	if err != nil {
+		return err
+	}
+	if err := u.transition(ctx, tx, c, entity.KYCApproved, &staffUserID, note); err != nil {
+		return err
+	}
+	if err := u.limitRepo.ActivateByConsumer(ctx, tx, consumerID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (u *KYCUsecase) Reject(ctx context.Context, consumerID, staffUserID uint64, req KYCRejectRequest) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := u.findForUpdate(ctx, tx, consumerID)
+	if err != nil {
+		return err
+	}
+	to := entity.KYCRejected
+	if req.Resubmit {
+		to = entity.KYCNeedsResubmission
+	}
+	if err := u.transition(ctx, tx, c, to, &staffUserID, req.Note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Resubmit replaces the consumer's documents and puts the case back in the
+// review queue. It is only allowed when staff asked for new documents.
+func (u *KYCUsecase) Resubmit(ctx context.Context, consumerID uint64, req KYCResubmitRequest) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := u.findForUpdate(ctx, tx, consumerID)
+	if err != nil {
+		return err
+	}
+	if !c.KYCStatus.CanMoveTo(entity.KYCPendingReview) {
+		return ErrInvalidKYCTransition
+	}
+
+	if err := attachDocument(ctx, u.docRepo, tx, c.ID, req.KTPDocumentID, entity.DocumentKTP); err != nil {
+		return err
+	}
+	if err := attachDocument(ctx, u.docRepo, tx, c.ID, req.SelfieDocumentID, entity.DocumentSelfie); err != nil {
+		return err
+	}
+	c.KTPDocumentID = req.KTPDocumentID
+	c.SelfieDocumentID = req.SelfieDocumentID
+	if err := u.consumerRepo.Update(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := u.transition(ctx, tx, c, entity.KYCPendingReview, nil, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (u *KYCUsecase) findForUpdate(ctx context.Context, tx *sql.Tx, consumerID uint64) (*entity.Consumer, error) {
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	return c, err
+}
+
+// transition moves the case and records the decision in the history.
+func (u *KYCUsecase) transition(ctx context.Context, tx *sql.Tx, c *entity.Consumer, to entity.KYCStatus, staffUserID *uint64, note string) error {
+	if !c.KYCStatus.CanMoveTo(to) {
+		return ErrInvalidKYCTransition
+	}
+	if err := u.consumerRepo.UpdateKYCStatus(ctx, tx, c.ID, to); err != nil {
+		return err
+	}
+	err := u.reviewRepo.Create(ctx, tx, &entity.KYCReview{
+		ConsumerID: c.ID,
+		FromStatus: c.KYCStatus,
+		ToStatus:   to,
+		Note:       note,
+		ReviewedBy: staffUserID,
+	})
+	if err != nil {
+		return err
+	}
+	c.KYCStatus = to
+	return nil
+}
+
+func kycCase(c *entity.Consumer) *KYCCase {
+	return &KYCCase{
+		ConsumerID:       c.ID,
+		NIK:              c.NIK,
+		FullName:         c.FullName,
+		LegalName:        c.LegalName,
+		BirthPlace:       c.BirthPlace,
+		BirthDate:        c.BirthDate,
+		KTPDocumentID:    c.KTPDocumentID,
+		SelfieDocumentID: c.SelfieDocumentID,
+		Status:           c.KYCStatus,
+	}
+}
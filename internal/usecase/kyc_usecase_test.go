@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockKYCReviewRepo keeps the decision history in memory.
+type mockKYCReviewRepo struct {
+	reviews []*entity.KYCReview
+}
+
+func (m *mockKYCReviewRepo) Create(ctx context.Context, tx *sql.Tx, r *entity.KYCReview) error {
+	r.ID = uint64(len(m.reviews) + 1)
+	m.reviews = append(m.reviews, r)
+	return nil
+}
+func (m *mockKYCReviewRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.KYCReview, error) {
+	var out []*entity.KYCReview
+	for _, r := range m.reviews {
+		if r.ConsumerID == consumerID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func newTestKYCUsecase(t *testing.T, consumer *entity.Consumer, reviews *mockKYCReviewRepo, activated *[]uint64) *KYCUsecase {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 5; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+
+	repo := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			if id != consumer.ID {
+				return nil, sql.ErrNoRows
+			}
+			c := *consumer
+			return &c, nil
+		},
+		updateFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error {
+			*consumer = *c
+			return nil
+		},
+		kycFn: func(ctx context.Context, tx *sql.Tx, id uint64, status entity.KYCStatus) error {
+			consumer.KYCStatus = status
+			return nil
+		},
+	}
+	limits := &mockConsumerLimitRepo{
+		activateFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64) error {
+			*activated = append(*activated, consumerID)
+			return nil
+		},
+	}
+	return NewKYCUsecase(db, repo, reviews, limits, testDocuments())
+}
+
+func TestKYCApprove_ActivatesLimits(t *testing.T) {
+	consumer := testConsumer()
+	consumer.KYCStatus = entity.KYCPendingReview
+	reviews := &mockKYCReviewRepo{}
+	var activated []uint64
+	u := newTestKYCUsecase(t, consumer, reviews, &activated)
+
+	require.NoError(t, u.Approve(context.Background(), 10, 1, "documents match"))
+	require.Equal(t, entity.KYCApproved, consumer.KYCStatus)
+	require.Equal(t, []uint64{10}, activated)
+
+	c, err := u.Get(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, c.History, 1)
+	require.Equal(t, entity.KYCPendingReview, c.History[0].FromStatus)
+	require.Equal(t, entity.KYCApproved, c.History[0].ToStatus)
+	require.Equal(t, uint64(1), *c.History[0].ReviewedBy)
+	require.Equal(t, "documents match", c.History[0].Note)
+
+	require.Equal(t, ErrInvalidKYCTransition, u.Approve(context.Background(), 10, 1, ""))
+	require.Equal(t, ErrConsumerNotFound, u.Approve(context.Background(), 11, 1, ""))
+}
+
+func TestKYCReject_Resubmission(t *testing.T) {
+	consumer := testConsumer()
+	consumer.KYCStatus = entity.KYCPendingReview
+	reviews := &mockKYCReviewRepo{}
+	var activated []uint64
+	u := newTestKYCUsecase(t, consumer, reviews, &activated)
+
+	resubmit := KYCResubmitRequest{KTPDocumentID: "doc_ktp2", SelfieDocumentID: "doc_selfie2"}
+	require.Equal(t, ErrInvalidKYCTransition, u.Resubmit(context.Background(), 10, resubmit))
+
+	require.NoError(t, u.Reject(context.Background(), 10, 1, KYCRejectRequest{Note: "KTP photo blurred", Resubmit: true}))
+	require.Equal(t, entity.KYCNeedsResubmission, consumer.KYCStatus)
+
+	require.NoError(t, u.Resubmit(context.Background(), 10, resubmit))
+	require.Equal(t, entity.KYCPendingReview, consumer.KYCStatus)
+	require.Equal(t, "doc_ktp2", consumer.KTPDocumentID)
+	require.Nil(t, reviews.reviews[1].ReviewedBy)
+
+	require.NoError(t, u.Reject(context.Background(), 10, 1, KYCRejectRequest{Note: "NIK does not match KTP"}))
+	require.Equal(t, entity.KYCRejected, consumer.KYCStatus)
+	require.Equal(t, ErrInvalidKYCTransition, u.Resubmit(context.Background(), 10, resubmit))
+	require.Empty(t, activated)
+	require.Len(t, reviews.reviews, 3)
+}
+
+func TestKYCList_InvalidStatus(t *testing.T) {
+	u := NewKYCUsecase(nil, &mockConsumerRepo{}, &mockKYCReviewRepo{}, &mockConsumerLimitRepo{}, newMockDocumentRepo())
+
+	_, err := u.List(context.Background(), entity.KYCStatus("DONE"))
+	require.Equal(t, ErrInvalidKYCStatus, err)
+}
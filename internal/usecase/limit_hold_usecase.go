@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrHoldNotFound = errors.New("limit hold not found")
+var ErrHoldNotActive = errors.New("limit hold is no longer active")
+var ErrHoldExpired = errors.New("limit hold has expired")
+
+// holdSweepBatch is how many expired holds one sweeper transaction releases.
+const holdSweepBatch = 100
+
+// HoldOwner is who acts on a hold. A merchant may only cancel the holds it
+// placed; a consumer may act on any hold on their own limits.
+type HoldOwner struct {
+	ConsumerID uint64
+	MerchantID uint64
+}
+
+func (o HoldOwner) owns(h *entity.LimitHold) bool {
+	if o.MerchantID != 0 {
+		return h.MerchantID != nil && *h.MerchantID == o.MerchantID
+	}
+	return h.ConsumerID == o.ConsumerID
+}
+
+// LimitHoldUsecase runs the two-phase checkout: a hold reserves the price of
+// an asset on a limit without creating a contract, and is later confirmed
+// into a transaction, cancelled, or released by the sweeper once its TTL
+// has passed.
+type LimitHoldUsecase struct {
+	db           *sql.DB
+	repo         repository.LimitHoldRepository
+	limitRepo    repository.ConsumerLimitRepository
+	assetRepo    repository.AssetRepository
+	txRepo       repository.ConsumerTransactionRepository
+	ledgerRepo   repository.LimitLedgerRepository
+	authRepo     repository.AuthRepository
+	consumerRepo repository.ConsumerRepository
+	purchaseAuth repository.PurchaseAuthorizationRepository
+	ttl          time.Duration
+	now          func() time.Time
+}
+
+func NewLimitHoldUsecase(db *sql.DB, h repository.LimitHoldRepository, l repository.ConsumerLimitRepository, a repository.AssetRepository, t repository.ConsumerTransactionRepository, lg repository.LimitLedgerRepository, au repository.AuthRepository, c repository.ConsumerRepository, pa repository.PurchaseAuthorizationRepository, ttl time.Duration) *LimitHoldUsecase {
+	return &LimitHoldUsecase{db, h, l, a, t, lg, au, c, pa, ttl, time.Now}
+}
+
+// Create reserves the price of an asset on the consumer's own limit for tenor.
+func (u *LimitHoldUsecase) Create(ctx context.Context, consumerID, assetID uint64, tenor uint8) (*entity.LimitHold, error) {
+	return u.create(ctx, consumerID, nil, assetID, tenor, nil)
+}
+
+// CreateForMerchant places a hold for a customer on one of the merchant's
+// assets. Like PurchaseForMerchant it needs the consumer's purchase token,
+// which is spent before anything about the consumer is looked at. Every
+// refusal on the consumer's side is ErrPurchaseNotAuthorized, so a merchant
+// cannot tell an unknown consumer from one who is suspended or short of
+// credit.
+func (u *LimitHoldUsecase) CreateForMerchant(ctx context.Context, merchantID, consumerID, assetID uint64, tenor uint8, token string) (*entity.LimitHold, error) {
+	redeem := func(ctx context.Context, tx *sql.Tx) error {
+		return redeemPurchaseAuthorization(ctx, u.purchaseAuth, tx, token, merchantID, consumerID, assetID, tenor)
+	}
+	h, err := u.create(ctx, consumerID, &merchantID, assetID, tenor, redeem)
+	switch err {
+	case ErrConsumerNotFound, ErrEmailNotVerified, ErrConsumerNotActive, ErrLimitNotFound, ErrLimitInactive, ErrInsufficientLimit:
+		return nil, ErrPurchaseNotAuthorized
+	}
+	return h, err
+}
+
+// create reserves the hold. merchantID is set when a merchant places it, and
+// the asset must then be one of theirs. redeem, when set, runs first inside
+// the hold transaction.
+func (u *LimitHoldUsecase) create(ctx context.Context, consumerID uint64, merchantID *uint64, assetID uint64, tenor uint8, redeem func(ctx context.Context, tx *sql.Tx) error) (*entity.LimitHold, error) {
+	if !allowedTenor(tenor) {
+		return nil, ErrInvalidTenor
+	}
+
+	asset, err := u.assetRepo.GetByID(ctx, assetID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMerchantAssetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if merchantID != nil && (asset.MerchantID == nil || *asset.MerchantID != *merchantID) {
+		return nil, ErrMerchantAssetNotFound
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if redeem != nil {
+		if err := redeem(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	err = checkCanPurchase(ctx, u.authRepo, u.consumerRepo, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Held in whole rupiah, the same as the OTR the hold turns into.
+	amount := math.Round(asset.PriceProduct)
+	held, err := u.limitRepo.AddHeldLimit(ctx, tx, consumerID, tenor, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !held {
+		return nil, limitRefusal(ctx, u.limitRepo, consumerID, tenor, ErrInsufficientLimit)
+	}
+
+	cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, consumerID, tenor)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &entity.LimitHold{
+		ConsumerID:      consumerID,
+		ConsumerLimitID: cl.ID,
+		TenorMonth:      tenor,
+		AssetID:         assetID,
+		MerchantID:      merchantID,
+		Amount:          amount,
+		Status:          entity.LimitHoldActive,
+		ExpiresAt:       u.now().UTC().Add(u.ttl),
+	}
+	id, err := u.repo.Create(ctx, tx, h)
+	if err != nil {
+		return nil, err
+	}
+	h.ID = id
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Confirm turns a hold into a contract. The held amount becomes used limit
+// in the same transaction, so the credit is never free in between. A hold
+// past its TTL is expired instead. Only the consumer confirms, including
+// holds a merchant placed for them: the confirmation is their consent.
+func (u *LimitHoldUsecase) Confirm(ctx context.Context, consumerID, id uint64) (*entity.Transaction, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	h, err := u.activeHold(ctx, tx, HoldOwner{ConsumerID: consumerID}, id)
+	if err != nil {
+		return nil, err
+	}
+	if h.Expired(u.now()) {
+		if err := u.release(ctx, tx, h, entity.LimitHoldExpired); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrHoldExpired
+	}
+
+	if err := checkCanPurchase(ctx, u.authRepo, u.consumerRepo, h.ConsumerID); err != nil {
+		return nil, err
+	}
+
+	if err := u.limitRepo.ConvertHeldLimit(ctx, tx, h.ConsumerLimitID, h.Amount); err != nil {
+		return nil, err
+	}
+	cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, h.ConsumerID, h.TenorMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := newContract(h.ConsumerID, h.ConsumerLimitID, h.AssetID, h.TenorMonth, h.Amount)
+	trID, err := u.txRepo.Create(ctx, tx, tr)
+	if err != nil {
+		return nil, err
+	}
+	tr.ID = trID
+
+	err = u.ledgerRepo.Create(ctx, tx, &entity.LimitLedgerEntry{
+		ConsumerLimitID: cl.ID,
+		ConsumerID:      h.ConsumerID,
+		TenorMonth:      h.TenorMonth,
+		Kind:            entity.LimitDebit,
+		Amount:          h.Amount,
+		UsedBefore:      cl.UsedLimit - h.Amount,
+		UsedAfter:       cl.UsedLimit,
+		MaxBefore:       cl.MaxLimit,
+		MaxAfter:        cl.MaxLimit,
+		SourceType:      entity.LimitSourceTransaction,
+		SourceID:        strconv.FormatUint(trID, 10),
+		Reason:          "purchase " + tr.ContractNo + " from hold " + strconv.FormatUint(h.ID, 10),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Resolve(ctx, tx, h.ID, entity.LimitHoldConfirmed, &trID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// Cancel gives the held amount back to the limit.
+func (u *LimitHoldUsecase) Cancel(ctx context.Context, owner HoldOwner, id uint64) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	h, err := u.activeHold(ctx, tx, owner, id)
+	if err != nil {
+		return err
+	}
+	if err := u.release(ctx, tx, h, entity.LimitHoldCancelled); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExpireHolds releases up to one batch of holds whose TTL has passed and
+// returns how many it released.
+func (u *LimitHoldUsecase) ExpireHolds(ctx context.Context) (int, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	holds, err := u.repo.ListExpiredForUpdate(ctx, tx, u.now().UTC(), holdSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	for _, h := range holds {
+		if err := u.release(ctx, tx, h, entity.LimitHoldExpired); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(holds), nil
+}
+
+// RunSweeper expires holds every interval until ctx is done. Each tick keeps
+// releasing batches until none are left.
+func (u *LimitHoldUsecase) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := u.ExpireHolds(ctx)
+			if err != nil {
+				log.Printf("expiring limit holds: %v", err)
+				break
+			}
+			if n < holdSweepBatch {
+				break
+			}
+		}
+	}
+}
+
+// activeHold locks a HELD hold the owner may act on. Holds of someone else
+// are reported as not found.
+func (u *LimitHoldUsecase) activeHold(ctx context.Context, tx *sql.Tx, owner HoldOwner, id uint64) (*entity.LimitHold, error) {
+	h, err := u.repo.FindByIDForUpdate(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !owner.owns(h) {
+		return nil, ErrHoldNotFound
+	}
+	if h.Status != entity.LimitHoldActive {
+		return nil, ErrHoldNotActive
+	}
+	return h, nil
+}
+
+func (u *LimitHoldUsecase) release(ctx context.Context, tx *sql.Tx, h *entity.LimitHold, status entity.LimitHoldStatus) error {
+	if err := u.limitRepo.ReleaseHeldLimit(ctx, tx, h.ConsumerLimitID, h.Amount); err != nil {
+		return err
+	}
+	return u.repo.Resolve(ctx, tx, h.ID, status, nil)
+}
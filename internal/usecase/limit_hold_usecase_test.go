@@ -0,0 +1,530 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLimitHoldRepo struct {
+	mu       sync.Mutex
+	holds    map[uint64]*entity.LimitHold
+	resolved map[uint64]entity.LimitHoldStatus
+}
+
+func newMockLimitHoldRepo(holds ...*entity.LimitHold) *mockLimitHoldRepo {
+	m := &mockLimitHoldRepo{holds: map[uint64]*entity.LimitHold{}, resolved: map[uint64]entity.LimitHoldStatus{}}
+	for _, h := range holds {
+		m.holds[h.ID] = h
+	}
+	return m
+}
+
+func (m *mockLimitHoldRepo) Create(ctx context.Context, tx *sql.Tx, h *entity.LimitHold) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := uint64(len(m.holds) + 1)
+	m.holds[id] = h
+	return id, nil
+}
+
+func (m *mockLimitHoldRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.LimitHold, error) {
+	h, ok := m.holds[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return h, nil
+}
+
+func (m *mockLimitHoldRepo) ListExpiredForUpdate(ctx context.Context, tx *sql.Tx, now time.Time, limit int) ([]*entity.LimitHold, error) {
+	var res []*entity.LimitHold
+	for _, h := range m.holds {
+		if h.Status == entity.LimitHoldActive && h.Expired(now) && len(res) < limit {
+			res = append(res, h)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockLimitHoldRepo) Resolve(ctx context.Context, tx *sql.Tx, id uint64, status entity.LimitHoldStatus, transactionID *uint64) error {
+	m.resolved[id] = status
+	m.holds[id].Status = status
+	m.holds[id].TransactionID = transactionID
+	return nil
+}
+
+func (m *mockLimitHoldRepo) HasActiveByConsumer(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+	for _, h := range m.holds {
+		if h.ConsumerID == consumerID && h.Status == entity.LimitHoldActive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func testHold(id uint64, expiresAt time.Time) *entity.LimitHold {
+	merchantID := uint64(7)
+	return &entity.LimitHold{
+		ID:              id,
+		ConsumerID:      1,
+		ConsumerLimitID: 4,
+		TenorMonth:      3,
+		AssetID:         2,
+		MerchantID:      &merchantID,
+		Amount:          1000000,
+		Status:          entity.LimitHoldActive,
+		ExpiresAt:       expiresAt,
+	}
+}
+
+func TestCreateHold_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	merchantID := uint64(7)
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: 999999.6, MerchantID: &merchantID}, nil
+		},
+	}
+	var heldAmount float64
+	limitRepo := &mockConsumerLimitRepo{
+		holdFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			heldAmount = amount
+			return true, nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 4, ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 5000000, HeldLimit: 1000000, Active: true}, nil
+		},
+	}
+	holds := newMockLimitHoldRepo()
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	u := NewLimitHoldUsecase(db, holds, limitRepo, assetRepo, &mockTxRepoTx{}, &mockLimitLedgerRepo{}, verifiedAuthRepo(), &mockConsumerRepo{}, nil, 15*time.Minute)
+	u.now = func() time.Time { return now }
+
+	h, err := u.Create(context.Background(), 1, 2, 3)
+	require.NoError(t, err)
+	require.Equal(t, 1000000.0, heldAmount)
+	require.Equal(t, uint64(4), h.ConsumerLimitID)
+	require.Equal(t, now.Add(15*time.Minute), h.ExpiresAt)
+	require.Equal(t, entity.LimitHoldActive, h.Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCreateHold_Concurrent fires many holds at one limit at once. The
+// repository applies each amount the way the conditional UPDATE does, so the
+// only way to over-spend is for the usecase to decide on a stale read.
+func TestCreateHold_Concurrent(t *testing.T) {
+	const (
+		maxLimit = 1000.0
+		price    = 30.0
+		requests = 200
+	)
+	fits := 33 // whole prices that fit in maxLimit
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < requests; i++ {
+		mock.ExpectBegin()
+	}
+	for i := 0; i < fits; i++ {
+		mock.ExpectCommit()
+	}
+	for i := fits; i < requests; i++ {
+		mock.ExpectRollback()
+	}
+
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: price}, nil
+		},
+	}
+	var mu sync.Mutex
+	held := 0.0
+	limitRepo := &mockConsumerLimitRepo{
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return &entity.ConsumerLimit{ID: 4, ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: maxLimit, HeldLimit: held, Active: true}, nil
+		},
+		holdFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if held+amount > maxLimit {
+				return false, nil
+			}
+			held += amount
+			return true, nil
+		},
+	}
+	u := NewLimitHoldUsecase(db, newMockLimitHoldRepo(), limitRepo, assetRepo, &mockTxRepoTx{}, &mockLimitLedgerRepo{}, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+
+	var wg sync.WaitGroup
+	var granted, refused int64
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := u.Create(context.Background(), 1, 2, 3)
+			switch err {
+			case nil:
+				atomic.AddInt64(&granted, 1)
+			case ErrInsufficientLimit:
+				atomic.AddInt64(&refused, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(fits), granted)
+	require.Equal(t, int64(requests-fits), refused)
+	require.Equal(t, float64(fits)*price, held)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateHold_Refused(t *testing.T) {
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: 1000000}, nil
+		},
+	}
+
+	t.Run("other merchants asset", func(t *testing.T) {
+		u := NewLimitHoldUsecase(nil, newMockLimitHoldRepo(), &mockConsumerLimitRepo{}, assetRepo, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, &mockPurchaseAuthRepo{}, time.Minute)
+		_, err := u.CreateForMerchant(context.Background(), 8, 1, 2, 3, "token")
+		require.ErrorIs(t, err, ErrMerchantAssetNotFound)
+	})
+
+	t.Run("does not fit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		limitRepo := &mockConsumerLimitRepo{
+			holdFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+				return false, nil
+			},
+			getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+				return &entity.ConsumerLimit{MaxLimit: 1500000, UsedLimit: 200000, HeldLimit: 600000, Active: true}, nil
+			},
+		}
+		u := NewLimitHoldUsecase(db, newMockLimitHoldRepo(), limitRepo, assetRepo, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+		_, err = u.Create(context.Background(), 1, 2, 3)
+		require.ErrorIs(t, err, ErrInsufficientLimit)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// merchantHoldFixture has merchant 7 selling asset 2 for 1,000,000 and
+// consumer 1 holding a purchase token for it on tenor 3.
+func merchantHoldFixture(t *testing.T, limitRepo *mockConsumerLimitRepo, authRepo *mockAuthRepoForRegister) (*LimitHoldUsecase, sqlmock.Sqlmock, *mockPurchaseAuthRepo, *mockLimitHoldRepo) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	merchantID := uint64(7)
+	assetRepo := &mockAssetRepoTx{
+		getByIDFn: func(ctx context.Context, id uint64) (*entity.Asset, error) {
+			return &entity.Asset{ID: id, PriceProduct: 1000000, MerchantID: &merchantID}, nil
+		},
+	}
+	auths := &mockPurchaseAuthRepo{auths: []*entity.PurchaseAuthorization{{
+		ID: 1, ConsumerID: 1, MerchantID: 7, AssetID: 2, TenorMonth: 3,
+		TokenHash: utils.HashToken("token"), ExpiresAt: time.Now().Add(time.Minute),
+	}}}
+	holds := newMockLimitHoldRepo()
+	u := NewLimitHoldUsecase(db, holds, limitRepo, assetRepo, nil, nil, authRepo, &mockConsumerRepo{}, auths, time.Minute)
+	return u, mock, auths, holds
+}
+
+func TestMerchantCreateHold_SpendsAuthorization(t *testing.T) {
+	limitRepo := &mockConsumerLimitRepo{
+		holdFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			return true, nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 4, ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 5000000, Active: true}, nil
+		},
+	}
+	u, mock, auths, _ := merchantHoldFixture(t, limitRepo, verifiedAuthRepo())
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	h, err := u.CreateForMerchant(context.Background(), 7, 1, 2, 3, "token")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), *h.MerchantID)
+	require.Equal(t, []uint64{1}, auths.used)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchantCreateHold_WithoutAuthorizationLearnsNothing(t *testing.T) {
+	authRepo := &mockAuthRepoForRegister{
+		findByConsumerIDFn: func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+			t.Fatal("the consumer must not be looked up without a valid token")
+			return nil, nil
+		},
+	}
+	cases := []struct {
+		name       string
+		consumerID uint64
+		tenor      uint8
+		token      string
+	}{
+		{"unknown token", 1, 3, "other"},
+		{"another consumer", 2, 3, "token"},
+		{"another tenor", 1, 6, "token"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, mock, auths, holds := merchantHoldFixture(t, &mockConsumerLimitRepo{}, authRepo)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			_, err := u.CreateForMerchant(context.Background(), 7, tc.consumerID, 2, tc.tenor, tc.token)
+			require.ErrorIs(t, err, ErrPurchaseNotAuthorized)
+			require.Empty(t, auths.used)
+			require.Empty(t, holds.holds)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMerchantCreateHold_ConsumerRefusalsLookAlike(t *testing.T) {
+	unverified := &mockAuthRepoForRegister{
+		findByConsumerIDFn: func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+			return &entity.AuthUser{ID: 1, ConsumerID: consumerID}, nil
+		},
+	}
+	full := &mockConsumerLimitRepo{
+		holdFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64, tenor uint8, amount float64) (bool, error) {
+			return false, nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 4, MaxLimit: 1500000, UsedLimit: 1000000, Active: true}, nil
+		},
+	}
+	cases := []struct {
+		name      string
+		limitRepo *mockConsumerLimitRepo
+		authRepo  *mockAuthRepoForRegister
+	}{
+		{"email not verified", &mockConsumerLimitRepo{}, unverified},
+		{"insufficient limit", full, verifiedAuthRepo()},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, mock, _, holds := merchantHoldFixture(t, tc.limitRepo, tc.authRepo)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			_, err := u.CreateForMerchant(context.Background(), 7, 1, 2, 3, "token")
+			require.Equal(t, ErrPurchaseNotAuthorized, err)
+			require.Empty(t, holds.holds)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// The hold was placed by merchant 7; consumer 1 confirming it is their
+// consent to the purchase.
+func TestConfirmHold_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	now := time.Now()
+	holds := newMockLimitHoldRepo(testHold(12, now.Add(time.Minute)))
+	converted := 0.0
+	limitRepo := &mockConsumerLimitRepo{
+		convertFn: func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+			require.Equal(t, uint64(4), id)
+			converted = amount
+			return nil
+		},
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			return &entity.ConsumerLimit{ID: 4, ConsumerID: consumerID, TenorMonth: tenor, MaxLimit: 5000000, UsedLimit: 1500000, Active: true}, nil
+		},
+	}
+	txRepo := &mockTxRepoTx{
+		createFn: func(ctx context.Context, tx *sql.Tx, tr *entity.Transaction) (uint64, error) {
+			require.Equal(t, "SUCCESS", tr.Status)
+			require.Equal(t, int64(1000000), tr.OTR)
+			require.Equal(t, uint64(4), tr.ConsumerLimitID)
+			return 42, nil
+		},
+	}
+	ledger := &mockLimitLedgerRepo{}
+
+	u := NewLimitHoldUsecase(db, holds, limitRepo, &mockAssetRepoTx{}, txRepo, ledger, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+	tr, err := u.Confirm(context.Background(), 1, 12)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), tr.ID)
+	require.Equal(t, 1000000.0, converted)
+	require.Equal(t, entity.LimitHoldConfirmed, holds.resolved[12])
+	require.Equal(t, uint64(42), *holds.holds[12].TransactionID)
+
+	require.Len(t, ledger.entries, 1)
+	e := ledger.entries[0]
+	require.Equal(t, entity.LimitDebit, e.Kind)
+	require.Equal(t, 500000.0, e.UsedBefore)
+	require.Equal(t, 1500000.0, e.UsedAfter)
+	require.Equal(t, "42", e.SourceID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmHold_Expired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The expiry itself is committed even though the confirm fails.
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	holds := newMockLimitHoldRepo(testHold(12, time.Now().Add(-time.Second)))
+	released := 0.0
+	limitRepo := &mockConsumerLimitRepo{
+		releaseFn: func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+			released = amount
+			return nil
+		},
+		convertFn: func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+			t.Fatal("expired hold must not be converted")
+			return nil
+		},
+	}
+
+	u := NewLimitHoldUsecase(db, holds, limitRepo, &mockAssetRepoTx{}, &mockTxRepoTx{}, &mockLimitLedgerRepo{}, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+	_, err = u.Confirm(context.Background(), 1, 12)
+	require.ErrorIs(t, err, ErrHoldExpired)
+	require.Equal(t, 1000000.0, released)
+	require.Equal(t, entity.LimitHoldExpired, holds.resolved[12])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHold_NotOwnedOrNotActive(t *testing.T) {
+	cases := []struct {
+		name  string
+		owner HoldOwner
+		id    uint64
+		want  error
+	}{
+		{"other merchant", HoldOwner{MerchantID: 8}, 12, ErrHoldNotFound},
+		{"other consumer", HoldOwner{ConsumerID: 2}, 12, ErrHoldNotFound},
+		{"missing", HoldOwner{ConsumerID: 1}, 99, ErrHoldNotFound},
+		{"already cancelled", HoldOwner{ConsumerID: 1}, 13, ErrHoldNotActive},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			cancelled := testHold(13, time.Now().Add(time.Minute))
+			cancelled.Status = entity.LimitHoldCancelled
+			holds := newMockLimitHoldRepo(testHold(12, time.Now().Add(time.Minute)), cancelled)
+
+			u := NewLimitHoldUsecase(db, holds, &mockConsumerLimitRepo{}, nil, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+			err = u.Cancel(context.Background(), tc.owner, tc.id)
+			require.True(t, errors.Is(err, tc.want), "got %v", err)
+			require.Empty(t, holds.resolved)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCancelHold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	holds := newMockLimitHoldRepo(testHold(12, time.Now().Add(time.Minute)))
+	released := 0.0
+	limitRepo := &mockConsumerLimitRepo{
+		releaseFn: func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+			released = amount
+			return nil
+		},
+	}
+
+	u := NewLimitHoldUsecase(db, holds, limitRepo, nil, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+	require.NoError(t, u.Cancel(context.Background(), HoldOwner{ConsumerID: 1}, 12))
+	require.Equal(t, 1000000.0, released)
+	require.Equal(t, entity.LimitHoldCancelled, holds.resolved[12])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpireHolds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	now := time.Now()
+	holds := newMockLimitHoldRepo(testHold(12, now.Add(-time.Minute)), testHold(13, now.Add(time.Minute)))
+	released := 0
+	limitRepo := &mockConsumerLimitRepo{
+		releaseFn: func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+			released++
+			return nil
+		},
+	}
+
+	u := NewLimitHoldUsecase(db, holds, limitRepo, nil, nil, nil, nil, nil, nil, time.Minute)
+	u.now = func() time.Time { return now }
+
+	n, err := u.ExpireHolds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, released)
+	require.Equal(t, entity.LimitHoldExpired, holds.resolved[12])
+	require.Equal(t, entity.LimitHoldActive, holds.holds[13].Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmHold_OtherConsumer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	holds := newMockLimitHoldRepo(testHold(12, time.Now().Add(time.Minute)))
+	u := NewLimitHoldUsecase(db, holds, &mockConsumerLimitRepo{}, nil, nil, nil, verifiedAuthRepo(), &mockConsumerRepo{}, nil, time.Minute)
+	_, err = u.Confirm(context.Background(), 2, 12)
+	require.ErrorIs(t, err, ErrHoldNotFound)
+	require.Empty(t, holds.resolved)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
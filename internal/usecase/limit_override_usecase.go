@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrOverrideNotFound = errors.New("limit override request not found")
+var ErrOverridePending = errors.New("an override is already pending for this limit")
+var ErrOverrideReviewed = errors.New("limit override request already reviewed")
+var ErrOverrideSelfReview = errors.New("an override must be reviewed by another staff user")
+var ErrOverrideUnchanged = errors.New("new max limit equals the current max limit")
+var ErrOverrideBelowUsed = errors.New("new max limit is below the used and held limit")
+
+type ProposeLimitOverrideRequest struct {
+	Tenor         uint8    `json:"tenor" binding:"required"`
+	MaxLimit      *float64 `json:"max_limit" binding:"required,gte=0"`
+	Justification string   `json:"justification" binding:"required,max=500"`
+}
+
+type ReviewLimitOverrideRequest struct {
+	Note string `json:"note" binding:"max=255"`
+}
+
+// LimitOverrideUsecase lets staff set a consumer's max limit by hand under
+// maker-checker control: one staff user proposes the new max limit, another
+// approves or rejects it, and only approval touches consumer_limits.
+type LimitOverrideUsecase struct {
+	db         *sql.DB
+	repo       repository.LimitOverrideRepository
+	limitRepo  repository.ConsumerLimitRepository
+	ledgerRepo repository.LimitLedgerRepository
+}
+
+func NewLimitOverrideUsecase(db *sql.DB, r repository.LimitOverrideRepository, l repository.ConsumerLimitRepository, lg repository.LimitLedgerRepository) *LimitOverrideUsecase {
+	return &LimitOverrideUsecase{db, r, l, lg}
+}
+
+// Propose records an override request for one of the consumer's limits. A
+// limit has at most one pending request.
+func (u *LimitOverrideUsecase) Propose(ctx context.Context, staffUserID, consumerID uint64, req ProposeLimitOverrideRequest) (*entity.LimitOverrideRequest, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the limit keeps two proposals for it from racing.
+	cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, consumerID, req.Tenor)
+	if err == sql.ErrNoRows {
+		return nil, ErrLimitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	newMax := *req.MaxLimit
+	if newMax == cl.MaxLimit {
+		return nil, ErrOverrideUnchanged
+	}
+	if newMax < cl.UsedLimit+cl.HeldLimit {
+		return nil, ErrOverrideBelowUsed
+	}
+
+	_, err = u.repo.FindPendingByLimit(ctx, cl.ID)
+	if err == nil {
+		return nil, ErrOverridePending
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	o := &entity.LimitOverrideRequest{
+		ConsumerLimitID: cl.ID,
+		ConsumerID:      consumerID,
+		TenorMonth:      cl.TenorMonth,
+		CurrentMaxLimit: cl.MaxLimit,
+		NewMaxLimit:     newMax,
+		Justification:   req.Justification,
+		Status:          entity.LimitOverridePending,
+		RequestedBy:     staffUserID,
+	}
+	id, err := u.repo.Create(ctx, tx, o)
+	if err != nil {
+		return nil, err
+	}
+	o.ID = id
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Review approves or rejects a pending override. Approval sets the limit's
+// max limit and logs an OVERRIDE in the limit ledger in the same transaction;
+// the used and held limit are checked again since they may have grown after
+// the request was made. If they have, the request is rejected instead, so it
+// no longer blocks a new proposal, and ErrOverrideBelowUsed is returned.
+func (u *LimitOverrideUsecase) Review(ctx context.Context, staffUserID, requestID uint64, approve bool, note string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	o, err := u.repo.FindByIDForUpdate(ctx, tx, requestID)
+	if err == sql.ErrNoRows {
+		return ErrOverrideNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if o.Status != entity.LimitOverridePending {
+		return ErrOverrideReviewed
+	}
+	if o.RequestedBy == staffUserID {
+		return ErrOverrideSelfReview
+	}
+
+	status := entity.LimitOverrideRejected
+	if approve {
+		status = entity.LimitOverrideApproved
+
+		cl, err := u.limitRepo.GetByIDForUpdate(ctx, tx, o.ConsumerLimitID)
+		if err != nil {
+			return err
+		}
+		if o.NewMaxLimit < cl.UsedLimit+cl.HeldLimit {
+			if err := u.repo.Review(ctx, tx, o.ID, entity.LimitOverrideRejected, staffUserID, ErrOverrideBelowUsed.Error()); err != nil {
+				return err
+			}
+			if err := tx.Commit(); err != nil {
+				return err
+			}
+			return ErrOverrideBelowUsed
+		}
+		if err := u.limitRepo.OverrideMaxLimit(ctx, tx, cl.ID, o.NewMaxLimit, o.ID); err != nil {
+			return err
+		}
+		err = u.ledgerRepo.Create(ctx, tx, &entity.LimitLedgerEntry{
+			ConsumerLimitID: cl.ID,
+			ConsumerID:      cl.ConsumerID,
+			TenorMonth:      cl.TenorMonth,
+			Kind:            entity.LimitOverride,
+			UsedBefore:      cl.UsedLimit,
+			UsedAfter:       cl.UsedLimit,
+			MaxBefore:       cl.MaxLimit,
+			MaxAfter:        o.NewMaxLimit,
+			SourceType:      entity.LimitSourceStaff,
+			SourceID:        strconv.FormatUint(staffUserID, 10),
+			Reason:          "override request " + strconv.FormatUint(o.ID, 10) + " requested by staff " + strconv.FormatUint(o.RequestedBy, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := u.repo.Review(ctx, tx, o.ID, status, staffUserID, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListPending returns the override requests waiting for a checker, oldest
+// first.
+func (u *LimitOverrideUsecase) ListPending(ctx context.Context) ([]*entity.LimitOverrideRequest, error) {
+	return u.repo.ListByStatus(ctx, entity.LimitOverridePending)
+}
+
+// ListByConsumer returns every override request for the consumer's limits,
+// reviewed or not.
+func (u *LimitOverrideUsecase) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitOverrideRequest, error) {
+	return u.repo.ListByConsumer(ctx, consumerID)
+}
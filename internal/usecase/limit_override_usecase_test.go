@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLimitOverrideRepo struct {
+	requests []*entity.LimitOverrideRequest
+	reviewed map[uint64]entity.LimitOverrideStatus
+}
+
+func (m *mockLimitOverrideRepo) Create(ctx context.Context, tx *sql.Tx, o *entity.LimitOverrideRequest) (uint64, error) {
+	c := *o
+	c.ID = uint64(len(m.requests) + 1)
+	m.requests = append(m.requests, &c)
+	return c.ID, nil
+}
+
+func (m *mockLimitOverrideRepo) FindPendingByLimit(ctx context.Context, consumerLimitID uint64) (*entity.LimitOverrideRequest, error) {
+	for _, o := range m.requests {
+		if o.ConsumerLimitID == consumerLimitID && o.Status == entity.LimitOverridePending {
+			return o, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockLimitOverrideRepo) FindByIDForUpdate(ctx context.Context, tx *sql.Tx, id uint64) (*entity.LimitOverrideRequest, error) {
+	for _, o := range m.requests {
+		if o.ID == id {
+			c := *o
+			return &c, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockLimitOverrideRepo) Review(ctx context.Context, tx *sql.Tx, id uint64, status entity.LimitOverrideStatus, staffUserID uint64, note string) error {
+	if m.reviewed == nil {
+		m.reviewed = map[uint64]entity.LimitOverrideStatus{}
+	}
+	m.reviewed[id] = status
+	for _, o := range m.requests {
+		if o.ID == id {
+			o.Status = status
+		}
+	}
+	return nil
+}
+
+func (m *mockLimitOverrideRepo) ListByStatus(ctx context.Context, status entity.LimitOverrideStatus) ([]*entity.LimitOverrideRequest, error) {
+	var res []*entity.LimitOverrideRequest
+	for _, o := range m.requests {
+		if o.Status == status {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockLimitOverrideRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.LimitOverrideRequest, error) {
+	var res []*entity.LimitOverrideRequest
+	for _, o := range m.requests {
+		if o.ConsumerID == consumerID {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+// overrideFixture has limit 4 of consumer 10 for tenor 3: 6,000,000 max with
+// 1,500,000 used and 500,000 held.
+func overrideFixture(t *testing.T) (*LimitOverrideUsecase, sqlmock.Sqlmock, *mockLimitOverrideRepo, *mockLimitLedgerRepo, map[uint64]float64) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	cl := &entity.ConsumerLimit{ID: 4, ConsumerID: 10, TenorMonth: 3, MaxLimit: 6000000, UsedLimit: 1500000, HeldLimit: 500000, Active: true}
+	overridden := map[uint64]float64{}
+	limitRepo := &mockConsumerLimitRepo{
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			if consumerID != cl.ConsumerID || tenor != cl.TenorMonth {
+				return nil, sql.ErrNoRows
+			}
+			c := *cl
+			return &c, nil
+		},
+		byIDFn: func(ctx context.Context, id uint64) (*entity.ConsumerLimit, error) {
+			require.Equal(t, cl.ID, id)
+			c := *cl
+			return &c, nil
+		},
+		overrideFn: func(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, overrideID uint64) error {
+			overridden[id] = maxLimit
+			return nil
+		},
+	}
+	repo := &mockLimitOverrideRepo{}
+	ledger := &mockLimitLedgerRepo{}
+
+	return NewLimitOverrideUsecase(db, repo, limitRepo, ledger), mock, repo, ledger, overridden
+}
+
+func proposeOverride(maxLimit float64) ProposeLimitOverrideRequest {
+	return ProposeLimitOverrideRequest{Tenor: 3, MaxLimit: &maxLimit, Justification: "verified bonus income"}
+}
+
+func TestLimitOverride_ApprovedByChecker(t *testing.T) {
+	u, mock, repo, ledger, overridden := overrideFixture(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	o, err := u.Propose(context.Background(), 2, 10, proposeOverride(9000000))
+	require.NoError(t, err)
+	require.Equal(t, entity.LimitOverridePending, o.Status)
+	require.Equal(t, 6000000.0, o.CurrentMaxLimit)
+	require.Equal(t, uint64(2), o.RequestedBy)
+	// nothing changes until a checker approves
+	require.Empty(t, overridden)
+	require.Empty(t, ledger.entries)
+
+	require.NoError(t, u.Review(context.Background(), 3, o.ID, true, "payslips checked"))
+	require.Equal(t, entity.LimitOverrideApproved, repo.reviewed[o.ID])
+	require.Equal(t, 9000000.0, overridden[4])
+
+	require.Len(t, ledger.entries, 1)
+	e := ledger.entries[0]
+	require.Equal(t, entity.LimitOverride, e.Kind)
+	require.Zero(t, e.Amount)
+	require.Equal(t, 6000000.0, e.MaxBefore)
+	require.Equal(t, 9000000.0, e.MaxAfter)
+	require.Equal(t, entity.LimitSourceStaff, e.SourceType)
+	require.Equal(t, "3", e.SourceID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverride_RejectedLeavesLimit(t *testing.T) {
+	u, mock, repo, ledger, overridden := overrideFixture(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	o, err := u.Propose(context.Background(), 2, 10, proposeOverride(3000000))
+	require.NoError(t, err)
+	require.NoError(t, u.Review(context.Background(), 3, o.ID, false, "no evidence"))
+	require.Equal(t, entity.LimitOverrideRejected, repo.reviewed[o.ID])
+	require.Empty(t, overridden)
+	require.Empty(t, ledger.entries)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverride_ApprovalBelowUsedRejects(t *testing.T) {
+	u, mock, repo, ledger, overridden := overrideFixture(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	o, err := u.Propose(context.Background(), 2, 10, proposeOverride(2500000))
+	require.NoError(t, err)
+
+	// the consumer spends more before the checker gets to the request
+	limits := u.limitRepo.(*mockConsumerLimitRepo)
+	limits.byIDFn = func(ctx context.Context, id uint64) (*entity.ConsumerLimit, error) {
+		return &entity.ConsumerLimit{ID: 4, ConsumerID: 10, TenorMonth: 3, MaxLimit: 6000000, UsedLimit: 2600000, HeldLimit: 500000, Active: true}, nil
+	}
+	err = u.Review(context.Background(), 3, o.ID, true, "payslips checked")
+	require.ErrorIs(t, err, ErrOverrideBelowUsed)
+	require.Equal(t, entity.LimitOverrideRejected, repo.reviewed[o.ID])
+	require.Empty(t, overridden)
+	require.Empty(t, ledger.entries)
+
+	// the rejected request no longer blocks the limit
+	_, err = u.Propose(context.Background(), 2, 10, proposeOverride(9000000))
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverride_MakerCannotReview(t *testing.T) {
+	u, mock, repo, _, overridden := overrideFixture(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	o, err := u.Propose(context.Background(), 2, 10, proposeOverride(9000000))
+	require.NoError(t, err)
+
+	err = u.Review(context.Background(), 2, o.ID, true, "")
+	require.ErrorIs(t, err, ErrOverrideSelfReview)
+	require.Empty(t, repo.reviewed)
+	require.Empty(t, overridden)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverride_ProposeRefused(t *testing.T) {
+	cases := []struct {
+		name     string
+		tenor    uint8
+		maxLimit float64
+		want     error
+	}{
+		{"no limit", 6, 9000000, ErrLimitNotFound},
+		{"unchanged", 3, 6000000, ErrOverrideUnchanged},
+		{"below used and held", 3, 1999999, ErrOverrideBelowUsed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, mock, repo, _, _ := overrideFixture(t)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			req := proposeOverride(tc.maxLimit)
+			req.Tenor = tc.tenor
+			_, err := u.Propose(context.Background(), 2, 10, req)
+			require.ErrorIs(t, err, tc.want)
+			require.Empty(t, repo.requests)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLimitOverride_OnePendingPerLimit(t *testing.T) {
+	u, mock, _, _, _ := overrideFixture(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err := u.Propose(context.Background(), 2, 10, proposeOverride(9000000))
+	require.NoError(t, err)
+	_, err = u.Propose(context.Background(), 5, 10, proposeOverride(8000000))
+	require.ErrorIs(t, err, ErrOverridePending)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitOverride_ReviewedOnce(t *testing.T) {
+	u, mock, repo, _, _ := overrideFixture(t)
+	repo.requests = []*entity.LimitOverrideRequest{
+		{ID: 1, ConsumerLimitID: 4, ConsumerID: 10, TenorMonth: 3, NewMaxLimit: 9000000, Status: entity.LimitOverrideApproved, RequestedBy: 2},
+	}
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := u.Review(context.Background(), 3, 1, true, "")
+	require.ErrorIs(t, err, ErrOverrideReviewed)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrInvalidLimitRules = errors.New("invalid limit rules")
+var ErrLimitPolicyNotFound = errors.New("limit policy not found")
+var ErrLimitPolicyPublished = errors.New("limit policy already published")
+var ErrNoActiveLimitPolicy = errors.New("no limit policy has been published")
+var ErrInvalidRiskGrade = errors.New("invalid risk grade")
+
+type CreateLimitPolicyRequest struct {
+	Rules entity.LimitRules `json:"rules" binding:"required"`
+	Note  string            `json:"note" binding:"max=255"`
+}
+
+type PreviewLimitRequest struct {
+	Salary    float64          `json:"salary" binding:"required,gt=0"`
+	RiskGrade entity.RiskGrade `json:"risk_grade"`
+}
+
+type SetRiskGradeRequest struct {
+	RiskGrade entity.RiskGrade `json:"risk_grade" binding:"required"`
+}
+
+// LimitPolicyUsecase manages the versioned rule sets that turn a consumer's
+// salary and risk grade into credit limits. Policies are drafted, checked with
+// Preview and then published; a published policy never changes.
+type LimitPolicyUsecase struct {
+	db           *sql.DB
+	policyRepo   repository.LimitPolicyRepository
+	consumerRepo repository.ConsumerRepository
+	recalc       *LimitRecalcUsecase
+}
+
+func NewLimitPolicyUsecase(db *sql.DB, p repository.LimitPolicyRepository, c repository.ConsumerRepository, recalc *LimitRecalcUsecase) *LimitPolicyUsecase {
+	return &LimitPolicyUsecase{db, p, c, recalc}
+}
+
+// Create stores the rules as a new draft version.
+func (u *LimitPolicyUsecase) Create(ctx context.Context, staffUserID uint64, req CreateLimitPolicyRequest) (*entity.LimitPolicy, error) {
+	if err := validateLimitRules(&req.Rules); err != nil {
+		return nil, err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	p := &entity.LimitPolicy{Rules: req.Rules, Note: req.Note, CreatedBy: &staffUserID}
+	if p.Version, err = u.policyRepo.Create(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (u *LimitPolicyUsecase) List(ctx context.Context) ([]*entity.LimitPolicy, error) {
+	return u.policyRepo.List(ctx)
+}
+
+func (u *LimitPolicyUsecase) Get(ctx context.Context, version uint32) (*entity.LimitPolicy, error) {
+	p, err := u.policyRepo.FindByVersion(ctx, version)
+	if err == sql.ErrNoRows {
+		return nil, ErrLimitPolicyNotFound
+	}
+	return p, err
+}
+
+// Publish makes the draft the active policy and, once it is committed, wakes
+// the recalculation worker to move existing limits to it in batches. The
+// rules are checked again so a draft saved under looser checks cannot go live.
+func (u *LimitPolicyUsecase) Publish(ctx context.Context, version uint32, staffUserID uint64) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	p, err := u.policyRepo.FindByVersionForUpdate(ctx, tx, version)
+	if err == sql.ErrNoRows {
+		return ErrLimitPolicyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if p.Published() {
+		return ErrLimitPolicyPublished
+	}
+	if err := validateLimitRules(&p.Rules); err != nil {
+		return err
+	}
+	if err := u.policyRepo.Publish(ctx, tx, version, staffUserID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	u.recalc.PolicyPublished()
+	return nil
+}
+
+// Preview computes the limits a policy, published or not, would give.
+func (u *LimitPolicyUsecase) Preview(ctx context.Context, version uint32, req PreviewLimitRequest) ([]entity.TenorLimit, error) {
+	if req.RiskGrade != "" && !req.RiskGrade.Valid() {
+		return nil, ErrInvalidRiskGrade
+	}
+	p, err := u.Get(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	return p.Rules.Compute(req.Salary, req.RiskGrade), nil
+}
+
+// SetRiskGrade grades the consumer. The grade is used for limits computed
+// afterwards.
+func (u *LimitPolicyUsecase) SetRiskGrade(ctx context.Context, consumerID uint64, grade entity.RiskGrade) error {
+	if !grade.Valid() {
+		return ErrInvalidRiskGrade
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return ErrConsumerNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := u.consumerRepo.UpdateRiskGrade(ctx, tx, consumerID, grade); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// activeLimits computes the consumer's limits with the active policy and
+// returns the policy version alongside them.
+func activeLimits(ctx context.Context, repo repository.LimitPolicyRepository, salary float64, grade entity.RiskGrade) (uint32, []entity.TenorLimit, error) {
+	p, err := repo.FindActive(ctx)
+	if err == sql.ErrNoRows {
+		return 0, nil, ErrNoActiveLimitPolicy
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return p.Version, p.Rules.Compute(salary, grade), nil
+}
+
+// validateLimitRules rejects rule sets that would compute nonsense: every
+// tenor once, one that purchases and holds accept, with a positive
+// multiplier, positive band ratios and risk adjustments only for known grades.
+func validateLimitRules(r *entity.LimitRules) error {
+	if len(r.SalaryBands) == 0 || len(r.Tenors) == 0 {
+		return ErrInvalidLimitRules
+	}
+
+	bands := make(map[float64]bool, len(r.SalaryBands))
+	for _, b := range r.SalaryBands {
+		if b.MinSalary < 0 || b.Ratio <= 0 || bands[b.MinSalary] {
+			return ErrInvalidLimitRules
+		}
+		bands[b.MinSalary] = true
+	}
+
+	tenors := make(map[uint8]bool, len(r.Tenors))
+	for _, t := range r.Tenors {
+		if !allowedTenor(t.Tenor) || tenors[t.Tenor] || t.Multiplier <= 0 || t.Floor < 0 || t.Cap < 0 {
+			return ErrInvalidLimitRules
+		}
+		if t.Cap > 0 && t.Cap < t.Floor {
+			return ErrInvalidLimitRules
+		}
+		tenors[t.Tenor] = true
+	}
+
+	for grade, a := range r.RiskAdjustments {
+		if !grade.Valid() || a <= 0 {
+			return ErrInvalidLimitRules
+		}
+	}
+	return nil
+}
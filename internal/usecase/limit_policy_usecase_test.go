@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLimitPolicyRepo struct {
+	policies  []*entity.LimitPolicy
+	published []uint32
+}
+
+func (m *mockLimitPolicyRepo) Create(ctx context.Context, tx *sql.Tx, p *entity.LimitPolicy) (uint32, error) {
+	p.Version = uint32(len(m.policies) + 1)
+	m.policies = append(m.policies, p)
+	return p.Version, nil
+}
+
+func (m *mockLimitPolicyRepo) FindByVersion(ctx context.Context, version uint32) (*entity.LimitPolicy, error) {
+	for _, p := range m.policies {
+		if p.Version == version {
+			return p, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockLimitPolicyRepo) FindByVersionForUpdate(ctx context.Context, tx *sql.Tx, version uint32) (*entity.LimitPolicy, error) {
+	return m.FindByVersion(ctx, version)
+}
+
+func (m *mockLimitPolicyRepo) FindActive(ctx context.Context) (*entity.LimitPolicy, error) {
+	var active *entity.LimitPolicy
+	for _, p := range m.policies {
+		if p.Published() && (active == nil || !p.PublishedAt.Before(*active.PublishedAt)) {
+			active = p
+		}
+	}
+	if active == nil {
+		return nil, sql.ErrNoRows
+	}
+	return active, nil
+}
+
+func (m *mockLimitPolicyRepo) List(ctx context.Context) ([]*entity.LimitPolicy, error) {
+	return m.policies, nil
+}
+
+func (m *mockLimitPolicyRepo) Publish(ctx context.Context, tx *sql.Tx, version uint32, staffUserID uint64) error {
+	m.published = append(m.published, version)
+	return nil
+}
+
+// testPublishedPolicy lends 0.4 of the salary per month for tenors 1 and 6,
+// caps the 6 month limit at 2000 and halves it for grade E.
+func testPublishedPolicy(version uint32) *entity.LimitPolicy {
+	now := time.Now()
+	return &entity.LimitPolicy{
+		Version: version,
+		Rules: entity.LimitRules{
+			SalaryBands:     []entity.SalaryBand{{MinSalary: 0, Ratio: 0.4}},
+			Tenors:          []entity.TenorRule{{Tenor: 1, Multiplier: 1}, {Tenor: 6, Multiplier: 6, Cap: 2000}},
+			RiskAdjustments: map[entity.RiskGrade]float64{entity.RiskGradeE: 0.5},
+		},
+		PublishedAt: &now,
+	}
+}
+
+func TestLimitPolicy_Preview(t *testing.T) {
+	policy := &entity.LimitPolicy{
+		Version: 2,
+		Rules: entity.LimitRules{
+			SalaryBands: []entity.SalaryBand{{MinSalary: 3000000, Ratio: 0.3}, {MinSalary: 10000000, Ratio: 0.4}},
+			Tenors: []entity.TenorRule{
+				{Tenor: 1, Multiplier: 1, Floor: 1000000},
+				{Tenor: 3, Multiplier: 3, Cap: 10000000},
+			},
+			RiskAdjustments: map[entity.RiskGrade]float64{entity.RiskGradeA: 1.2, entity.RiskGradeD: 0.5},
+		},
+	}
+	u := NewLimitPolicyUsecase(nil, &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{policy}}, &mockConsumerRepo{}, nil)
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		salary float64
+		grade  entity.RiskGrade
+		want   []entity.TenorLimit
+	}{
+		{"below lowest band", 2000000, "", []entity.TenorLimit{{Tenor: 1, MaxLimit: 0}, {Tenor: 3, MaxLimit: 0}}},
+		{"floor", 3000000, entity.RiskGradeD, []entity.TenorLimit{{Tenor: 1, MaxLimit: 1000000}, {Tenor: 3, MaxLimit: 1350000}}},
+		{"upper band", 10000000, "", []entity.TenorLimit{{Tenor: 1, MaxLimit: 4000000}, {Tenor: 3, MaxLimit: 10000000}}},
+		{"grade adjustment", 5000000, entity.RiskGradeA, []entity.TenorLimit{{Tenor: 1, MaxLimit: 1800000}, {Tenor: 3, MaxLimit: 5400000}}},
+		{"ungraded grade", 5000000, entity.RiskGradeB, []entity.TenorLimit{{Tenor: 1, MaxLimit: 1500000}, {Tenor: 3, MaxLimit: 4500000}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			limits, err := u.Preview(ctx, 2, PreviewLimitRequest{Salary: tc.salary, RiskGrade: tc.grade})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, limits)
+		})
+	}
+
+	_, err := u.Preview(ctx, 2, PreviewLimitRequest{Salary: 1, RiskGrade: "Z"})
+	require.Equal(t, ErrInvalidRiskGrade, err)
+	_, err = u.Preview(ctx, 9, PreviewLimitRequest{Salary: 1})
+	require.Equal(t, ErrLimitPolicyNotFound, err)
+}
+
+func TestLimitPolicy_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	repo := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(1)}}
+	u := NewLimitPolicyUsecase(db, repo, &mockConsumerRepo{}, nil)
+
+	p, err := u.Create(context.Background(), 3, CreateLimitPolicyRequest{Rules: testPublishedPolicy(0).Rules, Note: "raise caps"})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), p.Version)
+	require.Equal(t, uint64(3), *p.CreatedBy)
+	require.False(t, p.Published())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitPolicy_Create_InvalidRules(t *testing.T) {
+	u := NewLimitPolicyUsecase(nil, &mockLimitPolicyRepo{}, &mockConsumerRepo{}, nil)
+	valid := func() entity.LimitRules { return testPublishedPolicy(0).Rules }
+
+	cases := map[string]func(r *entity.LimitRules){
+		"no bands":           func(r *entity.LimitRules) { r.SalaryBands = nil },
+		"no tenors":          func(r *entity.LimitRules) { r.Tenors = nil },
+		"zero ratio":         func(r *entity.LimitRules) { r.SalaryBands[0].Ratio = 0 },
+		"duplicate tenor":    func(r *entity.LimitRules) { r.Tenors[1].Tenor = 1 },
+		"unsupported tenor":  func(r *entity.LimitRules) { r.Tenors[1].Tenor = 12 },
+		"zero multiplier":    func(r *entity.LimitRules) { r.Tenors[0].Multiplier = 0 },
+		"cap below floor":    func(r *entity.LimitRules) { r.Tenors[1].Floor = 3000 },
+		"unknown grade":      func(r *entity.LimitRules) { r.RiskAdjustments["Z"] = 1 },
+		"negative grade adj": func(r *entity.LimitRules) { r.RiskAdjustments[entity.RiskGradeA] = -1 },
+	}
+	for name, breakRules := range cases {
+		t.Run(name, func(t *testing.T) {
+			rules := valid()
+			breakRules(&rules)
+			_, err := u.Create(context.Background(), 1, CreateLimitPolicyRequest{Rules: rules})
+			require.Equal(t, ErrInvalidLimitRules, err)
+		})
+	}
+}
+
+func TestLimitPolicy_Publish(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	draft := testPublishedPolicy(2)
+	draft.PublishedAt = nil
+	repo := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(1), draft}}
+	recalc := NewLimitRecalcUsecase(nil, nil, nil, nil, nil)
+	u := NewLimitPolicyUsecase(db, repo, &mockConsumerRepo{}, recalc)
+
+	require.NoError(t, u.Publish(context.Background(), 2, 5))
+	require.Equal(t, []uint32{2}, repo.published)
+	// the recalculation worker is woken once the publish is committed
+	require.Len(t, recalc.published, 1)
+
+	err = u.Publish(context.Background(), 1, 5)
+	require.Equal(t, ErrLimitPolicyPublished, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitPolicy_PublishRejectsUnsupportedTenor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	// a draft saved before tenors were checked against the accepted set
+	draft := testPublishedPolicy(2)
+	draft.PublishedAt = nil
+	draft.Rules.Tenors = append(draft.Rules.Tenors, entity.TenorRule{Tenor: 12, Multiplier: 12})
+	repo := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(1), draft}}
+	recalc := NewLimitRecalcUsecase(nil, nil, nil, nil, nil)
+	u := NewLimitPolicyUsecase(db, repo, &mockConsumerRepo{}, recalc)
+
+	err = u.Publish(context.Background(), 2, 5)
+	require.Equal(t, ErrInvalidLimitRules, err)
+	require.Empty(t, recalc.published)
+	require.Empty(t, repo.published)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLimitPolicy_SetRiskGrade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var graded entity.RiskGrade
+	consumers := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			return &entity.Consumer{ID: id}, nil
+		},
+		gradeFn: func(ctx context.Context, tx *sql.Tx, id uint64, grade entity.RiskGrade) error {
+			require.NotNil(t, tx)
+			graded = grade
+			return nil
+		},
+	}
+	u := NewLimitPolicyUsecase(db, &mockLimitPolicyRepo{}, consumers, nil)
+
+	require.NoError(t, u.SetRiskGrade(context.Background(), 10, entity.RiskGradeC))
+	require.Equal(t, entity.RiskGradeC, graded)
+
+	require.Equal(t, ErrInvalidRiskGrade, u.SetRiskGrade(context.Background(), 10, "F"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
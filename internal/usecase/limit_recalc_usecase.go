@@ -0,0 +1,321 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrLimitNotOverridden = errors.New("limit has no staff override")
+
+// recalcBatch is how many consumers a background recalculation pass reads at
+// a time.
+const recalcBatch = 200
+
+type ClearLimitOverrideRequest struct {
+	Tenor  uint8  `json:"tenor" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// LimitChange is one tenor whose max limit a recalculation moves. Clamped
+// means the policy computed less than the consumer has already used or held,
+// so the max limit was kept at that amount instead.
+type LimitChange struct {
+	Tenor     uint8   `json:"tenor"`
+	OldMax    float64 `json:"old_max_limit"`
+	NewMax    float64 `json:"new_max_limit"`
+	Computed  float64 `json:"computed_max_limit"`
+	UsedLimit float64 `json:"used_limit"`
+	Clamped   bool    `json:"clamped"`
+}
+
+// LimitRecalculation reports what recalculating one consumer changed, or
+// would change on a dry run.
+type LimitRecalculation struct {
+	ConsumerID    uint64        `json:"consumer_id"`
+	PolicyVersion uint32        `json:"policy_version"`
+	DryRun        bool          `json:"dry_run"`
+	Changes       []LimitChange `json:"changes"`
+}
+
+// RecalcTrigger says why limits are recalculated; it is copied into the
+// limit ledger.
+type RecalcTrigger struct {
+	Source   entity.LimitSource
+	SourceID string
+	Reason   string
+}
+
+// LimitRecalcUsecase recomputes max limits from the consumer's salary and
+// risk grade with the active limit policy. It runs when staff approve a
+// salary change and, after a new policy is published, over every consumer
+// behind it from RunWorker. Only tenors the consumer already has are
+// recomputed, and limits set by an approved staff override are kept until
+// staff clear the override.
+type LimitRecalcUsecase struct {
+	db           *sql.DB
+	consumerRepo repository.ConsumerRepository
+	limitRepo    repository.ConsumerLimitRepository
+	policyRepo   repository.LimitPolicyRepository
+	ledgerRepo   repository.LimitLedgerRepository
+	published    chan struct{}
+}
+
+func NewLimitRecalcUsecase(db *sql.DB, c repository.ConsumerRepository, l repository.ConsumerLimitRepository, p repository.LimitPolicyRepository, lg repository.LimitLedgerRepository) *LimitRecalcUsecase {
+	return &LimitRecalcUsecase{db, c, l, p, lg, make(chan struct{}, 1)}
+}
+
+// Recalculate recomputes one consumer's limits. With dryRun nothing is
+// written and the result shows what would change.
+func (u *LimitRecalcUsecase) Recalculate(ctx context.Context, consumerID uint64, dryRun bool, trigger RecalcTrigger) (*LimitRecalculation, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := u.recalculate(ctx, tx, c, dryRun, trigger)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return res, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ClearOverride drops the staff override on one of the consumer's limits and
+// recalculates the consumer with the active policy in the same transaction,
+// so the limit is back under policy at once. Any change is logged as an
+// ADJUSTMENT carrying the staff user and reason.
+func (u *LimitRecalcUsecase) ClearOverride(ctx context.Context, staffUserID, consumerID uint64, req ClearLimitOverrideRequest) (*LimitRecalculation, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, consumerID, req.Tenor)
+	if err == sql.ErrNoRows {
+		return nil, ErrLimitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cl.OverrideID == nil {
+		return nil, ErrLimitNotOverridden
+	}
+
+	if err := u.limitRepo.ClearOverride(ctx, tx, cl.ID); err != nil {
+		return nil, err
+	}
+	res, err := u.recalculate(ctx, tx, c, false, RecalcTrigger{
+		Source:   entity.LimitSourceStaff,
+		SourceID: strconv.FormatUint(staffUserID, 10),
+		Reason:   fmt.Sprintf("override request %d cleared: %s", *cl.OverrideID, req.Reason),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RecalculateBatch recalculates up to limit consumers with an ID above
+// afterID, each in its own transaction, and returns the results with the
+// last consumer ID seen. An empty result means every consumer was done.
+func (u *LimitRecalcUsecase) RecalculateBatch(ctx context.Context, afterID uint64, limit int, dryRun bool, trigger RecalcTrigger) ([]*LimitRecalculation, uint64, error) {
+	ids, err := u.limitRepo.ListConsumerIDs(ctx, afterID, limit)
+	if err != nil {
+		return nil, afterID, err
+	}
+
+	res := make([]*LimitRecalculation, 0, len(ids))
+	for _, id := range ids {
+		r, err := u.Recalculate(ctx, id, dryRun, trigger)
+		if err != nil {
+			return res, afterID, fmt.Errorf("consumer %d: %w", id, err)
+		}
+		res = append(res, r)
+		afterID = id
+	}
+	return res, afterID, nil
+}
+
+// PolicyPublished wakes RunWorker for another pass. It never blocks; a
+// publish during a pass is picked up by the pass after it.
+func (u *LimitRecalcUsecase) PolicyPublished() {
+	select {
+	case u.published <- struct{}{}:
+	default:
+	}
+}
+
+// RunWorker moves every consumer behind the active policy to it until ctx is
+// done: once at start, which finishes a pass a restart cut short, and again
+// after every publish.
+func (u *LimitRecalcUsecase) RunWorker(ctx context.Context) {
+	for {
+		n, err := u.RecalculateBehindPolicy(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("recalculating limits: %v", err)
+		}
+		if n > 0 {
+			log.Printf("recalculated the limits of %d consumers", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.published:
+		}
+	}
+}
+
+// RecalculateBehindPolicy recalculates, in batches, every consumer with a
+// limit the active policy did not set and returns how many it recalculated.
+// A consumer that fails is logged and skipped so the others still move; the
+// next pass tries it again.
+func (u *LimitRecalcUsecase) RecalculateBehindPolicy(ctx context.Context) (int, error) {
+	policy, err := u.policyRepo.FindActive(ctx)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	trigger := RecalcTrigger{
+		Source:   entity.LimitSourceJob,
+		SourceID: "limit-recalc-worker",
+		Reason:   "limit policy recalculation",
+	}
+
+	var afterID uint64
+	n := 0
+	for {
+		ids, err := u.limitRepo.ListConsumerIDsBehindPolicy(ctx, policy.Version, afterID, recalcBatch)
+		if err != nil {
+			return n, err
+		}
+		if len(ids) == 0 {
+			return n, nil
+		}
+		for _, id := range ids {
+			afterID = id
+			if _, err := u.Recalculate(ctx, id, false, trigger); err != nil {
+				if ctx.Err() != nil {
+					return n, ctx.Err()
+				}
+				log.Printf("recalculating limits of consumer %d: %v", id, err)
+				continue
+			}
+			n++
+		}
+	}
+}
+
+// recalculate does the work inside the caller's transaction, with the
+// consumer row already locked. Erased consumers are left alone.
+func (u *LimitRecalcUsecase) recalculate(ctx context.Context, tx *sql.Tx, c *entity.Consumer, dryRun bool, trigger RecalcTrigger) (*LimitRecalculation, error) {
+	policy, err := u.policyRepo.FindActive(ctx)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoActiveLimitPolicy
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &LimitRecalculation{ConsumerID: c.ID, PolicyVersion: policy.Version, DryRun: dryRun, Changes: []LimitChange{}}
+	if c.ErasedAt != nil {
+		return res, nil
+	}
+
+	for _, tl := range policy.Rules.Compute(c.Salary, c.RiskGrade) {
+		cl, err := u.limitRepo.GetByConsumerAndTenorForUpdate(ctx, tx, c.ID, tl.Tenor)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cl.OverrideID != nil {
+			continue
+		}
+
+		// Credit already used or held stays covered.
+		newMax := tl.MaxLimit
+		floor := cl.UsedLimit + cl.HeldLimit
+		clamped := newMax < floor
+		if clamped {
+			newMax = floor
+		}
+
+		sameVersion := cl.PolicyVersion != nil && *cl.PolicyVersion == policy.Version
+		if newMax == cl.MaxLimit && sameVersion {
+			continue
+		}
+		if newMax != cl.MaxLimit {
+			res.Changes = append(res.Changes, LimitChange{
+				Tenor:     tl.Tenor,
+				OldMax:    cl.MaxLimit,
+				NewMax:    newMax,
+				Computed:  tl.MaxLimit,
+				UsedLimit: cl.UsedLimit,
+				Clamped:   clamped,
+			})
+		}
+		if dryRun {
+			continue
+		}
+
+		if err := u.limitRepo.UpdateMaxLimit(ctx, tx, cl.ID, newMax, policy.Version); err != nil {
+			return nil, err
+		}
+		if newMax == cl.MaxLimit {
+			continue
+		}
+		err = u.ledgerRepo.Create(ctx, tx, &entity.LimitLedgerEntry{
+			ConsumerLimitID: cl.ID,
+			ConsumerID:      c.ID,
+			TenorMonth:      tl.Tenor,
+			Kind:            entity.LimitAdjustment,
+			UsedBefore:      cl.UsedLimit,
+			UsedAfter:       cl.UsedLimit,
+			MaxBefore:       cl.MaxLimit,
+			MaxAfter:        newMax,
+			SourceType:      trigger.Source,
+			SourceID:        trigger.SourceID,
+			Reason:          fmt.Sprintf("%s, policy v%d", trigger.Reason, policy.Version),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
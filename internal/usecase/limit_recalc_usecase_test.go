@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// recalcFixture has consumer 10 earning 5,000 with limits for tenors 1 and
+// 6; the test policy gives them 2,000 each.
+func recalcFixture(t *testing.T, limits map[uint8]*entity.ConsumerLimit) (*LimitRecalcUsecase, sqlmock.Sqlmock, map[uint64]float64, *mockLimitLedgerRepo) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	consumers := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			if id != 10 {
+				return nil, sql.ErrNoRows
+			}
+			return &entity.Consumer{ID: 10, Salary: 5000}, nil
+		},
+	}
+	updated := map[uint64]float64{}
+	limitRepo := &mockConsumerLimitRepo{
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			cl, ok := limits[tenor]
+			if !ok {
+				return nil, sql.ErrNoRows
+			}
+			c := *cl
+			return &c, nil
+		},
+		maxFn: func(ctx context.Context, tx *sql.Tx, id uint64, maxLimit float64, policyVersion uint32) error {
+			require.Equal(t, uint32(2), policyVersion)
+			updated[id] = maxLimit
+			return nil
+		},
+	}
+	policies := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(2)}}
+	ledger := &mockLimitLedgerRepo{}
+
+	return NewLimitRecalcUsecase(db, consumers, limitRepo, policies, ledger), mock, updated, ledger
+}
+
+func TestRecalculate_NeverBelowUsed(t *testing.T) {
+	v1 := uint32(1)
+	u, mock, updated, ledger := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 1000, PolicyVersion: &v1},
+		6: {ID: 6, TenorMonth: 6, MaxLimit: 6000, UsedLimit: 2500, HeldLimit: 500, PolicyVersion: &v1},
+	})
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	res, err := u.Recalculate(context.Background(), 10, false, RecalcTrigger{Source: entity.LimitSourceJob, SourceID: "test", Reason: "new policy"})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), res.PolicyVersion)
+	require.Len(t, res.Changes, 2)
+
+	require.Equal(t, 2000.0, updated[1])
+	require.False(t, res.Changes[0].Clamped)
+	// tenor 6 computes 2000 but 3000 is used or held
+	require.Equal(t, 3000.0, updated[6])
+	require.True(t, res.Changes[1].Clamped)
+	require.Equal(t, 2000.0, res.Changes[1].Computed)
+
+	require.Len(t, ledger.entries, 2)
+	e := ledger.entries[1]
+	require.Equal(t, entity.LimitAdjustment, e.Kind)
+	require.Zero(t, e.Amount)
+	require.Equal(t, 6000.0, e.MaxBefore)
+	require.Equal(t, 3000.0, e.MaxAfter)
+	require.Equal(t, "new policy, policy v2", e.Reason)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculate_DryRunWritesNothing(t *testing.T) {
+	u, mock, updated, ledger := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 1000},
+	})
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	res, err := u.Recalculate(context.Background(), 10, true, RecalcTrigger{Source: entity.LimitSourceStaff})
+	require.NoError(t, err)
+	require.True(t, res.DryRun)
+	require.Len(t, res.Changes, 1)
+	require.Equal(t, 2000.0, res.Changes[0].NewMax)
+	require.Empty(t, updated)
+	require.Empty(t, ledger.entries)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculate_UnchangedLimitOnlyMovesVersion(t *testing.T) {
+	v1 := uint32(1)
+	u, mock, updated, ledger := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 2000, PolicyVersion: &v1},
+	})
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	res, err := u.Recalculate(context.Background(), 10, false, RecalcTrigger{Source: entity.LimitSourceJob})
+	require.NoError(t, err)
+	require.Empty(t, res.Changes)
+	require.Equal(t, 2000.0, updated[1])
+	require.Empty(t, ledger.entries)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculate_KeepsStaffOverride(t *testing.T) {
+	override := uint64(7)
+	u, mock, updated, ledger := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 9000, OverrideID: &override},
+	})
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	res, err := u.Recalculate(context.Background(), 10, false, RecalcTrigger{Source: entity.LimitSourceJob})
+	require.NoError(t, err)
+	require.Empty(t, res.Changes)
+	require.Empty(t, updated)
+	require.Empty(t, ledger.entries)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculate_ErasedConsumerLeftAlone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	erased := time.Now()
+	consumers := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			return &entity.Consumer{ID: id, ErasedAt: &erased}, nil
+		},
+	}
+	limits := &mockConsumerLimitRepo{
+		getFn: func(ctx context.Context, consumerID uint64, tenor uint8) (*entity.ConsumerLimit, error) {
+			t.Fatal("erased consumer limits must not be read")
+			return nil, nil
+		},
+	}
+	policies := &mockLimitPolicyRepo{policies: []*entity.LimitPolicy{testPublishedPolicy(2)}}
+
+	u := NewLimitRecalcUsecase(db, consumers, limits, policies, &mockLimitLedgerRepo{})
+	res, err := u.Recalculate(context.Background(), 10, false, RecalcTrigger{Source: entity.LimitSourceJob})
+	require.NoError(t, err)
+	require.Empty(t, res.Changes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculateBatch_PagesByConsumerID(t *testing.T) {
+	u, mock, _, _ := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 1000},
+	})
+	limitRepo := u.limitRepo.(*mockConsumerLimitRepo)
+	limitRepo.idsFn = func(ctx context.Context, afterID uint64, limit int) ([]uint64, error) {
+		require.Equal(t, 50, limit)
+		if afterID < 10 {
+			return []uint64{10}, nil
+		}
+		return nil, nil
+	}
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	res, last, err := u.RecalculateBatch(context.Background(), 0, 50, true, RecalcTrigger{Source: entity.LimitSourceJob})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, uint64(10), last)
+
+	res, last, err = u.RecalculateBatch(context.Background(), last, 50, true, RecalcTrigger{Source: entity.LimitSourceJob})
+	require.NoError(t, err)
+	require.Empty(t, res)
+	require.Equal(t, uint64(10), last)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecalculateBehindPolicy_SkipsFailedConsumers(t *testing.T) {
+	v1 := uint32(1)
+	u, mock, updated, _ := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 1000, PolicyVersion: &v1},
+	})
+	var pages []uint64
+	u.limitRepo.(*mockConsumerLimitRepo).behindFn = func(ctx context.Context, version uint32, afterID uint64, limit int) ([]uint64, error) {
+		require.Equal(t, uint32(2), version)
+		require.Equal(t, recalcBatch, limit)
+		pages = append(pages, afterID)
+		if afterID == 0 {
+			// consumer 9 no longer exists
+			return []uint64{9, 10}, nil
+		}
+		return nil, nil
+	}
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	n, err := u.RecalculateBehindPolicy(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []uint64{0, 10}, pages)
+	require.Equal(t, 2000.0, updated[1])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClearOverride_RecalculatesLimit(t *testing.T) {
+	override := uint64(7)
+	limits := map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 9000, UsedLimit: 500, OverrideID: &override},
+	}
+	u, mock, updated, ledger := recalcFixture(t, limits)
+	u.limitRepo.(*mockConsumerLimitRepo).clearFn = func(ctx context.Context, tx *sql.Tx, id uint64) error {
+		require.Equal(t, uint64(1), id)
+		limits[1].OverrideID = nil
+		return nil
+	}
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	res, err := u.ClearOverride(context.Background(), 3, 10, ClearLimitOverrideRequest{Tenor: 1, Reason: "bonus income ended"})
+	require.NoError(t, err)
+	require.Len(t, res.Changes, 1)
+	require.Equal(t, 2000.0, updated[1])
+
+	require.Len(t, ledger.entries, 1)
+	e := ledger.entries[0]
+	require.Equal(t, entity.LimitAdjustment, e.Kind)
+	require.Equal(t, 9000.0, e.MaxBefore)
+	require.Equal(t, 2000.0, e.MaxAfter)
+	require.Equal(t, entity.LimitSourceStaff, e.SourceType)
+	require.Equal(t, "3", e.SourceID)
+	require.Equal(t, "override request 7 cleared: bonus income ended, policy v2", e.Reason)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClearOverride_RequiresOverride(t *testing.T) {
+	u, mock, updated, ledger := recalcFixture(t, map[uint8]*entity.ConsumerLimit{
+		1: {ID: 1, TenorMonth: 1, MaxLimit: 1000},
+	})
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err := u.ClearOverride(context.Background(), 3, 10, ClearLimitOverrideRequest{Tenor: 1, Reason: "back to policy"})
+	require.ErrorIs(t, err, ErrLimitNotOverridden)
+	require.Empty(t, updated)
+	require.Empty(t, ledger.entries)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
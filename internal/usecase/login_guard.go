@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginLockedError is returned while an email or client IP is locked out.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// ipAttemptFactor lets a client IP fail more often than a single account,
+// since several customers may share one NAT address.
+const ipAttemptFactor = 4
+
+// loginGuardPruneInterval is how often a failure also sweeps the whole map for
+// stale keys, so keys that are never looked up again do not pile up.
+const loginGuardPruneInterval = time.Minute
+
+type loginAttempts struct {
+	failures    int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LoginGuard counts failed logins per email and per client IP. Email keys are
+// kept apart per subject type (utils.SubjectConsumer or utils.SubjectStaff),
+// so failures on one kind of account never lock the other kind with the same
+// email; the IP counter is shared, as a spray from one address is the same
+// attack whichever login it hits. Once a key reaches its limit it is locked;
+// every further lockout doubles the lock time up to maxLockout. A key that
+// stays quiet for maxLockout is forgotten.
+//
+// The counters live in memory, so lockout is per process: with several
+// instances behind a load balancer each keeps its own counts, and a restart
+// clears them.
+type LoginGuard struct {
+	mu          sync.Mutex
+	maxAttempts int
+	baseLockout time.Duration
+	maxLockout  time.Duration
+	now         func() time.Time
+	entries     map[string]*loginAttempts
+	lastPrune   time.Time
+}
+
+func NewLoginGuard(maxAttempts int, baseLockout, maxLockout time.Duration, now func() time.Time) *LoginGuard {
+	if now == nil {
+		now = time.Now
+	}
+	return &LoginGuard{
+		maxAttempts: maxAttempts,
+		baseLockout: baseLockout,
+		maxLockout:  maxLockout,
+		now:         now,
+		entries:     make(map[string]*loginAttempts),
+	}
+}
+
+// Allow returns a *LoginLockedError when either the email or the IP is locked.
+func (g *LoginGuard) Allow(subject, email, ip string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	var wait time.Duration
+	for _, key := range []string{emailKey(subject, email), ipKey(ip)} {
+		e := g.entry(key, now)
+		if e == nil {
+			continue
+		}
+		if d := e.lockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return &LoginLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (g *LoginGuard) Fail(subject, email, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if now.Sub(g.lastPrune) >= loginGuardPruneInterval {
+		g.prune(now)
+	}
+	g.fail(emailKey(subject, email), g.maxAttempts, now)
+	g.fail(ipKey(ip), g.maxAttempts*ipAttemptFactor, now)
+}
+
+// Succeed clears the account's counters. The IP counter is left alone so one
+// valid login cannot be used to keep spraying other accounts.
+func (g *LoginGuard) Succeed(subject, email string) {
+	g.Unlock(subject, email)
+}
+
+func (g *LoginGuard) Unlock(subject, email string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.entries, emailKey(subject, email))
+}
+
+func (g *LoginGuard) fail(key string, limit int, now time.Time) {
+	e := g.entry(key, now)
+	if e == nil {
+		e = &loginAttempts{}
+		g.entries[key] = e
+	}
+
+	e.failures++
+	e.lastFailure = now
+	if e.failures < limit {
+		return
+	}
+
+	lock := g.baseLockout << e.lockouts
+	if lock <= 0 || lock > g.maxLockout {
+		lock = g.maxLockout
+	}
+	e.lockouts++
+	e.failures = 0
+	e.lockedUntil = now.Add(lock)
+}
+
+// entry returns the counters for key, dropping them once they have gone stale.
+func (g *LoginGuard) entry(key string, now time.Time) *loginAttempts {
+	e, ok := g.entries[key]
+	if !ok {
+		return nil
+	}
+	if now.After(e.lockedUntil) && now.Sub(e.lastFailure) > g.maxLockout {
+		delete(g.entries, key)
+		return nil
+	}
+	return e
+}
+
+// prune drops every stale key.
+func (g *LoginGuard) prune(now time.Time) {
+	for key := range g.entries {
+		g.entry(key, now)
+	}
+	g.lastPrune = now
+}
+
+func emailKey(subject, email string) string {
+	return subject + ":email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newClockedLoginGuard() (*LoginGuard, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 2, 5, 10, 0, 0, 0, time.UTC)}
+	return NewLoginGuard(3, time.Minute, 10*time.Minute, clock.Now), clock
+}
+
+func newTestLoginGuard() *LoginGuard {
+	g, _ := newClockedLoginGuard()
+	return g
+}
+
+func TestLoginGuard_LocksAfterMaxAttempts(t *testing.T) {
+	g, _ := newClockedLoginGuard()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"))
+		g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+	}
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"))
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+
+	err := g.Allow(utils.SubjectConsumer, "BUDI@mail.com", "10.0.0.2")
+	require.ErrorIs(t, err, ErrLoginLocked)
+
+	var locked *LoginLockedError
+	require.True(t, errors.As(err, &locked))
+	require.Equal(t, time.Minute, locked.RetryAfter)
+
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "ani@mail.com", "10.0.0.1"))
+}
+
+func TestLoginGuard_ExponentialBackoff(t *testing.T) {
+	g, clock := newClockedLoginGuard()
+
+	fail := func(n int) {
+		for i := 0; i < n; i++ {
+			g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+		}
+	}
+
+	fail(3)
+	clock.Advance(time.Minute)
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"))
+
+	fail(3)
+	var locked *LoginLockedError
+	require.True(t, errors.As(g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"), &locked))
+	require.Equal(t, 2*time.Minute, locked.RetryAfter)
+
+	clock.Advance(2 * time.Minute)
+	fail(3)
+	require.True(t, errors.As(g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"), &locked))
+	require.Equal(t, 4*time.Minute, locked.RetryAfter)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(locked.RetryAfter)
+		fail(3)
+		require.True(t, errors.As(g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"), &locked))
+	}
+	require.Equal(t, 10*time.Minute, locked.RetryAfter)
+}
+
+func TestLoginGuard_IPLimitAcrossAccounts(t *testing.T) {
+	g, _ := newClockedLoginGuard()
+
+	for i := 0; i < 3*ipAttemptFactor; i++ {
+		g.Fail(utils.SubjectConsumer, string(rune('a'+i))+"@mail.com", "10.0.0.9")
+	}
+
+	require.ErrorIs(t, g.Allow(utils.SubjectConsumer, "fresh@mail.com", "10.0.0.9"), ErrLoginLocked)
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "fresh@mail.com", "10.0.0.10"))
+}
+
+func TestLoginGuard_UnlockAndSuccessReset(t *testing.T) {
+	g, _ := newClockedLoginGuard()
+
+	for i := 0; i < 3; i++ {
+		g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+	}
+	require.ErrorIs(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2"), ErrLoginLocked)
+
+	g.Unlock(utils.SubjectConsumer, "budi@mail.com")
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2"))
+
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2")
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2")
+	g.Succeed(utils.SubjectConsumer, "budi@mail.com")
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2")
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2"))
+}
+
+func TestLoginGuard_ForgetsQuietKeys(t *testing.T) {
+	g, clock := newClockedLoginGuard()
+
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+	clock.Advance(11 * time.Minute)
+	g.Fail(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1")
+
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.1"))
+}
+
+func TestLoginGuard_PrunesStaleKeys(t *testing.T) {
+	g, clock := newClockedLoginGuard()
+
+	for _, email := range []string{"a@mail.com", "b@mail.com", "c@mail.com"} {
+		g.Fail(utils.SubjectConsumer, email, "10.0.0.1")
+	}
+	require.Len(t, g.entries, 4)
+
+	// none of the old keys is looked up again, yet the next failure sweeps them
+	clock.Advance(11 * time.Minute)
+	g.Fail(utils.SubjectConsumer, "d@mail.com", "10.0.0.2")
+	require.Len(t, g.entries, 2)
+}
+
+func TestLoginGuard_StaffAndConsumerEmailsAreSeparate(t *testing.T) {
+	g := newTestLoginGuard()
+
+	for i := 0; i < 3; i++ {
+		g.Fail(utils.SubjectStaff, "budi@mail.com", "10.0.0.1")
+	}
+	require.ErrorIs(t, g.Allow(utils.SubjectStaff, "budi@mail.com", "10.0.0.2"), ErrLoginLocked)
+	// the consumer account with the same email is not locked
+	require.NoError(t, g.Allow(utils.SubjectConsumer, "budi@mail.com", "10.0.0.2"))
+
+	g.Unlock(utils.SubjectConsumer, "budi@mail.com")
+	require.ErrorIs(t, g.Allow(utils.SubjectStaff, "budi@mail.com", "10.0.0.2"), ErrLoginLocked)
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+var ErrMerchantNotFound = errors.New("merchant not found")
+var ErrMerchantAPIKeyNotFound = errors.New("api key not found")
+var ErrInvalidSignature = errors.New("invalid request signature")
+var ErrRequestExpired = errors.New("request timestamp outside allowed window")
+var ErrRequestReplayed = errors.New("request already received")
+
+type CreateMerchantRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+// MerchantAPIKeyResult carries a newly issued key. The secret is not stored
+// and cannot be shown again.
+type MerchantAPIKeyResult struct {
+	KeyID  string `json:"key_id"`
+	Secret string `json:"secret"`
+}
+
+// SignedRequest is what a merchant sends: the key ID, unix timestamp and
+// signature headers together with the request being signed.
+type SignedRequest struct {
+	KeyID     string
+	Timestamp string
+	Signature string
+	Method    string
+	URI       string
+	Body      []byte
+}
+
+// MerchantUsecase manages partner merchants and authenticates their signed
+// server-to-server requests. Key secrets are derived from masterKey and the
+// key ID, so only their hash is kept in the database.
+type MerchantUsecase struct {
+	db        *sql.DB
+	repo      repository.MerchantRepository
+	assetRepo repository.AssetRepository
+	masterKey []byte
+	window    time.Duration
+	now       func() time.Time
+}
+
+func NewMerchantUsecase(db *sql.DB, repo repository.MerchantRepository, assetRepo repository.AssetRepository, masterKey []byte, window time.Duration) *MerchantUsecase {
+	return &MerchantUsecase{db, repo, assetRepo, masterKey, window, time.Now}
+}
+
+func (u *MerchantUsecase) Create(ctx context.Context, req CreateMerchantRequest) (uint64, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := u.repo.Create(ctx, tx, &entity.Merchant{Code: req.Code, Name: req.Name, Active: true})
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (u *MerchantUsecase) IssueAPIKey(ctx context.Context, merchantID uint64) (*MerchantAPIKeyResult, error) {
+	if _, err := u.repo.FindByID(ctx, merchantID); err == sql.ErrNoRows {
+		return nil, ErrMerchantNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	random, err := utils.RandomToken(12)
+	if err != nil {
+		return nil, err
+	}
+	keyID := "mk_" + random
+	secret := utils.DeriveAPISecret(u.masterKey, keyID)
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = u.repo.CreateAPIKey(ctx, tx, &entity.MerchantAPIKey{
+		MerchantID: merchantID,
+		KeyID:      keyID,
+		SecretHash: utils.HashToken(secret),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &MerchantAPIKeyResult{KeyID: keyID, Secret: secret}, nil
+}
+
+func (u *MerchantUsecase) RevokeAPIKey(ctx context.Context, merchantID uint64, keyID string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	found, err := u.repo.RevokeAPIKey(ctx, tx, merchantID, keyID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrMerchantAPIKeyNotFound
+	}
+	return tx.Commit()
+}
+
+// Authenticate checks a signed request and returns the calling merchant. The
+// timestamp must be within the window of the server clock, and each signature
+// is accepted once; a retry has to be signed again with a new timestamp.
+func (u *MerchantUsecase) Authenticate(ctx context.Context, req SignedRequest) (*entity.Merchant, error) {
+	key, err := u.repo.FindAPIKeyByKeyID(ctx, req.KeyID)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidSignature
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.Revoked() {
+		return nil, ErrInvalidSignature
+	}
+
+	// A changed master key derives different secrets; the stored hash makes
+	// that fail closed instead of accepting signatures made with a new secret.
+	secret := utils.DeriveAPISecret(u.masterKey, key.KeyID)
+	if utils.HashToken(secret) != key.SecretHash {
+		return nil, ErrInvalidSignature
+	}
+	if !utils.VerifyRequestSignature(secret, req.Method, req.URI, req.Timestamp, req.Body, req.Signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	now := u.now().UTC()
+	signedAt := time.Unix(ts, 0).UTC()
+	if signedAt.Before(now.Add(-u.window)) || signedAt.After(now.Add(u.window)) {
+		return nil, ErrRequestExpired
+	}
+
+	merchant, err := u.repo.FindByID(ctx, key.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	if !merchant.Active {
+		return nil, ErrInvalidSignature
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := u.repo.PurgeSignatures(ctx, tx, key.ID, now); err != nil {
+		return nil, err
+	}
+	fresh, err := u.repo.RememberSignature(ctx, tx, key.ID, utils.HashToken(strings.ToLower(req.Signature)), signedAt.Add(u.window))
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrRequestReplayed
+	}
+	if err := u.repo.TouchAPIKey(ctx, tx, key.ID, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+func (u *MerchantUsecase) ListAssets(ctx context.Context, merchantID uint64) ([]*entity.Asset, error) {
+	return u.assetRepo.ListByMerchant(ctx, merchantID)
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMerchantRepo keeps one merchant, its keys and seen signatures in memory.
+type mockMerchantRepo struct {
+	merchant   *entity.Merchant
+	keys       map[string]*entity.MerchantAPIKey
+	signatures map[string]time.Time
+}
+
+func newMockMerchantRepo() *mockMerchantRepo {
+	return &mockMerchantRepo{
+		merchant:   &entity.Merchant{ID: 7, Code: "TOKO", Name: "Toko Elektronik", Active: true},
+		keys:       map[string]*entity.MerchantAPIKey{},
+		signatures: map[string]time.Time{},
+	}
+}
+
+func (m *mockMerchantRepo) Create(ctx context.Context, tx *sql.Tx, merchant *entity.Merchant) (uint64, error) {
+	return m.merchant.ID, nil
+}
+func (m *mockMerchantRepo) FindByID(ctx context.Context, id uint64) (*entity.Merchant, error) {
+	if id != m.merchant.ID {
+		return nil, sql.ErrNoRows
+	}
+	return m.merchant, nil
+}
+func (m *mockMerchantRepo) CreateAPIKey(ctx context.Context, tx *sql.Tx, k *entity.MerchantAPIKey) (uint64, error) {
+	k.ID = uint64(len(m.keys) + 1)
+	m.keys[k.KeyID] = k
+	return k.ID, nil
+}
+func (m *mockMerchantRepo) FindAPIKeyByKeyID(ctx context.Context, keyID string) (*entity.MerchantAPIKey, error) {
+	k, ok := m.keys[keyID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return k, nil
+}
+func (m *mockMerchantRepo) RevokeAPIKey(ctx context.Context, tx *sql.Tx, merchantID uint64, keyID string) (bool, error) {
+	k, ok := m.keys[keyID]
+	if !ok || k.MerchantID != merchantID || k.Revoked() {
+		return false, nil
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return true, nil
+}
+func (m *mockMerchantRepo) TouchAPIKey(ctx context.Context, tx *sql.Tx, id uint64, at time.Time) error {
+	return nil
+}
+func (m *mockMerchantRepo) RememberSignature(ctx context.Context, tx *sql.Tx, apiKeyID uint64, signatureHash string, expiresAt time.Time) (bool, error) {
+	if _, ok := m.signatures[signatureHash]; ok {
+		return false, nil
+	}
+	m.signatures[signatureHash] = expiresAt
+	return true, nil
+}
+func (m *mockMerchantRepo) PurgeSignatures(ctx context.Context, tx *sql.Tx, apiKeyID uint64, before time.Time) error {
+	for sig, exp := range m.signatures {
+		if exp.Before(before) {
+			delete(m.signatures, sig)
+		}
+	}
+	return nil
+}
+
+func newTestMerchantUsecase(t *testing.T, repo *mockMerchantRepo) *MerchantUsecase {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 10; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+	return NewMerchantUsecase(db, repo, &mockAssetRepo{}, []byte("merchant-master-key"), 5*time.Minute)
+}
+
+func signedRequest(key *MerchantAPIKeyResult, at time.Time, body string) SignedRequest {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return SignedRequest{
+		KeyID:     key.KeyID,
+		Timestamp: ts,
+		Signature: utils.SignRequest(key.Secret, "POST", "/api/merchant/purchases", ts, []byte(body)),
+		Method:    "POST",
+		URI:       "/api/merchant/purchases",
+		Body:      []byte(body),
+	}
+}
+
+func TestMerchantAuthenticate(t *testing.T) {
+	repo := newMockMerchantRepo()
+	u := newTestMerchantUsecase(t, repo)
+	ctx := context.Background()
+
+	key, err := u.IssueAPIKey(ctx, 7)
+	require.NoError(t, err)
+	require.NotEqual(t, key.Secret, repo.keys[key.KeyID].SecretHash)
+
+	req := signedRequest(key, time.Now(), `{"asset_id":1}`)
+	merchant, err := u.Authenticate(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), merchant.ID)
+
+	_, err = u.Authenticate(ctx, req)
+	require.Equal(t, ErrRequestReplayed, err)
+
+	tampered := signedRequest(key, time.Now().Add(time.Second), `{"asset_id":1}`)
+	tampered.Body = []byte(`{"asset_id":2}`)
+	_, err = u.Authenticate(ctx, tampered)
+	require.Equal(t, ErrInvalidSignature, err)
+
+	_, err = u.Authenticate(ctx, signedRequest(key, time.Now().Add(-10*time.Minute), `{}`))
+	require.Equal(t, ErrRequestExpired, err)
+
+	require.NoError(t, u.RevokeAPIKey(ctx, 7, key.KeyID))
+	_, err = u.Authenticate(ctx, signedRequest(key, time.Now().Add(2*time.Second), `{}`))
+	require.Equal(t, ErrInvalidSignature, err)
+	require.Equal(t, ErrMerchantAPIKeyNotFound, u.RevokeAPIKey(ctx, 7, key.KeyID))
+}
+
+func TestMerchantAuthenticate_RejectsKeyOfInactiveMerchant(t *testing.T) {
+	repo := newMockMerchantRepo()
+	u := newTestMerchantUsecase(t, repo)
+
+	key, err := u.IssueAPIKey(context.Background(), 7)
+	require.NoError(t, err)
+	repo.merchant.Active = false
+
+	_, err = u.Authenticate(context.Background(), signedRequest(key, time.Now(), `{}`))
+	require.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestMerchantIssueAPIKey_UnknownMerchant(t *testing.T) {
+	u := newTestMerchantUsecase(t, newMockMerchantRepo())
+
+	_, err := u.IssueAPIKey(context.Background(), 99)
+	require.Equal(t, ErrMerchantNotFound, err)
+}
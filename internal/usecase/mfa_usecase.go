@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// MFASubject identifies whose second factor is being used: a consumer auth
+// user or a staff user, plus the account name shown in authenticator apps.
+type MFASubject struct {
+	Type    string
+	ID      uint64
+	Account string
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrMFANotEnrolled = errors.New("two-factor authentication is not enrolled")
+var ErrInvalidMFACode = errors.New("invalid authentication code")
+var ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+var ErrMFAMandatory = errors.New("two-factor authentication is mandatory for staff")
+
+const recoveryCodeCount = 10
+
+func ConsumerMFASubject(u *entity.AuthUser) MFASubject {
+	return MFASubject{Type: utils.SubjectConsumer, ID: u.ID, Account: u.Email}
+}
+
+func StaffMFASubject(s *entity.StaffUser) MFASubject {
+	return MFASubject{Type: utils.SubjectStaff, ID: s.ID, Account: s.Email}
+}
+
+type MFAUsecase struct {
+	db         *sql.DB
+	mfaRepo    repository.MFARepository
+	challenges *utils.TokenManager
+	issuer     string
+	now        func() time.Time
+}
+
+func NewMFAUsecase(db *sql.DB, m repository.MFARepository, challenges *utils.TokenManager, issuer string) *MFAUsecase {
+	return &MFAUsecase{db, m, challenges, issuer, time.Now}
+}
+
+// Enabled reports whether the subject has a confirmed TOTP credential.
+func (u *MFAUsecase) Enabled(ctx context.Context, s MFASubject) (bool, error) {
+	c, err := u.mfaRepo.FindBySubject(ctx, s.Type, s.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return c.Confirmed(), nil
+}
+
+// Enroll starts enrollment with a fresh secret. MFA stays off until the
+// subject proves their authenticator works by calling Confirm.
+func (u *MFAUsecase) Enroll(ctx context.Context, s MFASubject) (*MFAEnrollment, error) {
+	enabled, err := u.Enabled(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := u.mfaRepo.Save(ctx, tx, &entity.MFACredential{SubjectType: s.Type, SubjectID: s.ID, Secret: secret}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{Secret: secret, URI: utils.TOTPURI(u.issuer, s.Account, secret)}, nil
+}
+
+// Confirm turns MFA on once the first code checks out and returns a fresh
+// set of recovery codes. They are only ever shown here.
+func (u *MFAUsecase) Confirm(ctx context.Context, s MFASubject, code string) ([]string, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c, err := u.mfaRepo.FindBySubjectForUpdate(ctx, tx, s.Type, s.ID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Confirmed() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(c.Secret, code, u.now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	if err := u.mfaRepo.Confirm(ctx, tx, c.ID, step); err != nil {
+		return nil, err
+	}
+
+	codes, err := u.replaceRecoveryCodes(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP or recovery code during login.
+func (u *MFAUsecase) Verify(ctx context.Context, s MFASubject, code string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.verify(ctx, tx, s, code); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Disable turns MFA off for a consumer. Staff cannot opt out.
+func (u *MFAUsecase) Disable(ctx context.Context, s MFASubject, code string) error {
+	if s.Type == utils.SubjectStaff {
+		return ErrMFAMandatory
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.verify(ctx, tx, s, code); err != nil {
+		return err
+	}
+	if err := u.mfaRepo.Delete(ctx, tx, s.Type, s.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not.
+func (u *MFAUsecase) RegenerateRecoveryCodes(ctx context.Context, s MFASubject, code string) ([]string, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := u.verify(ctx, tx, s, code); err != nil {
+		return nil, err
+	}
+	codes, err := u.replaceRecoveryCodes(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Challenge issues the short-lived token that carries a login from the
+// password step to the code step.
+func (u *MFAUsecase) Challenge(s MFASubject, consumerID uint64) (*LoginResult, error) {
+	token, claims, err := u.challenges.Issue(utils.TokenClaims{
+		Subject:     strconv.FormatUint(s.ID, 10),
+		SubjectType: s.Type + "_mfa",
+		ConsumerID:  consumerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{MFAToken: token, MFAExpiresIn: claims.ExpiresAt - claims.IssuedAt}, nil
+}
+
+// ParseChallenge returns the subject ID of a challenge token issued for
+// subjectType.
+func (u *MFAUsecase) ParseChallenge(token, subjectType string) (uint64, error) {
+	claims, err := u.challenges.Parse(token)
+	if err != nil || claims.SubjectType != subjectType+"_mfa" {
+		return 0, ErrInvalidMFAToken
+	}
+	id, err := claims.SubjectID()
+	if err != nil {
+		return 0, ErrInvalidMFAToken
+	}
+	return id, nil
+}
+
+func (u *MFAUsecase) verify(ctx context.Context, tx *sql.Tx, s MFASubject, code string) error {
+	c, err := u.mfaRepo.FindBySubjectForUpdate(ctx, tx, s.Type, s.ID)
+	if err == sql.ErrNoRows {
+		return ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if !c.Confirmed() {
+		return ErrMFANotEnrolled
+	}
+
+	if rc := normalizeRecoveryCode(code); len(rc) == 10 {
+		ok, err := u.mfaRepo.UseRecoveryCode(ctx, tx, s.Type, s.ID, utils.HashToken(rc))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	// A code is only good once, even inside its validity window.
+	step, ok := utils.ValidateTOTP(c.Secret, code, u.now())
+	if !ok || step <= c.LastUsedStep {
+		return ErrInvalidMFACode
+	}
+	return u.mfaRepo.UpdateLastUsedStep(ctx, tx, c.ID, step)
+}
+
+func (u *MFAUsecase) replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, s MFASubject) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := utils.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = utils.HashToken(raw)
+	}
+
+	if err := u.mfaRepo.ReplaceRecoveryCodes(ctx, tx, s.Type, s.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockMFARepo keeps a single credential in memory so enrollment and login
+// flows can run end to end.
+type mockMFARepo struct {
+	cred     *entity.MFACredential
+	recovery map[string]bool
+}
+
+func (m *mockMFARepo) FindBySubject(ctx context.Context, subjectType string, subjectID uint64) (*entity.MFACredential, error) {
+	if m.cred == nil {
+		return nil, sql.ErrNoRows
+	}
+	c := *m.cred
+	return &c, nil
+}
+func (m *mockMFARepo) FindBySubjectForUpdate(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64) (*entity.MFACredential, error) {
+	return m.FindBySubject(ctx, subjectType, subjectID)
+}
+func (m *mockMFARepo) Save(ctx context.Context, tx *sql.Tx, c *entity.MFACredential) error {
+	saved := *c
+	saved.ID = 1
+	m.cred = &saved
+	return nil
+}
+func (m *mockMFARepo) Confirm(ctx context.Context, tx *sql.Tx, id uint64, step int64) error {
+	now := time.Now()
+	m.cred.ConfirmedAt = &now
+	m.cred.LastUsedStep = step
+	return nil
+}
+func (m *mockMFARepo) UpdateLastUsedStep(ctx context.Context, tx *sql.Tx, id uint64, step int64) error {
+	m.cred.LastUsedStep = step
+	return nil
+}
+func (m *mockMFARepo) Delete(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64) error {
+	m.cred = nil
+	m.recovery = nil
+	return nil
+}
+func (m *mockMFARepo) ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64, hashes []string) error {
+	m.recovery = make(map[string]bool)
+	for _, h := range hashes {
+		m.recovery[h] = false
+	}
+	return nil
+}
+func (m *mockMFARepo) UseRecoveryCode(ctx context.Context, tx *sql.Tx, subjectType string, subjectID uint64, hash string) (bool, error) {
+	used, ok := m.recovery[hash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recovery[hash] = true
+	return true, nil
+}
+
+func newTestMFA(repo *mockMFARepo) *MFAUsecase {
+	db, mock, _ := sqlmock.New()
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 10; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+	}
+	challenges := utils.NewTokenManager("multifinance-core", "k1", map[string][]byte{"k1": []byte("test-secret")}, 5*time.Minute)
+	return NewMFAUsecase(db, repo, challenges, "Multifinance")
+}
+
+// enrolledMFARepo returns a repo with a confirmed credential whose last used
+// step is far in the past.
+func enrolledMFARepo(t *testing.T) *mockMFARepo {
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	confirmed := time.Now().Add(-time.Hour)
+	return &mockMFARepo{cred: &entity.MFACredential{ID: 1, Secret: secret, ConfirmedAt: &confirmed}}
+}
+
+func currentCode(t *testing.T, repo *mockMFARepo) string {
+	code, err := utils.TOTPCode(repo.cred.Secret, time.Now())
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFA_EnrollAndConfirm(t *testing.T) {
+	repo := &mockMFARepo{}
+	u := newTestMFA(repo)
+	subject := MFASubject{Type: utils.SubjectConsumer, ID: 5, Account: "budi@mail.com"}
+
+	enrollment, err := u.Enroll(context.Background(), subject)
+	require.NoError(t, err)
+	require.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	enabled, err := u.Enabled(context.Background(), subject)
+	require.NoError(t, err)
+	require.False(t, enabled, "unconfirmed enrollment does not protect logins")
+
+	_, err = u.Confirm(context.Background(), subject, "000000")
+	require.Equal(t, ErrInvalidMFACode, err)
+
+	codes, err := u.Confirm(context.Background(), subject, currentCode(t, repo))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	enabled, err = u.Enabled(context.Background(), subject)
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	_, err = u.Enroll(context.Background(), subject)
+	require.Equal(t, ErrMFAAlreadyEnabled, err)
+}
+
+func TestMFA_VerifyRejectsReplayedCode(t *testing.T) {
+	repo := enrolledMFARepo(t)
+	u := newTestMFA(repo)
+	subject := MFASubject{Type: utils.SubjectConsumer, ID: 5}
+	code := currentCode(t, repo)
+
+	require.NoError(t, u.Verify(context.Background(), subject, code))
+	require.Equal(t, ErrInvalidMFACode, u.Verify(context.Background(), subject, code))
+}
+
+func TestMFA_RecoveryCodeWorksOnce(t *testing.T) {
+	repo := enrolledMFARepo(t)
+	u := newTestMFA(repo)
+	subject := MFASubject{Type: utils.SubjectConsumer, ID: 5}
+
+	codes, err := u.RegenerateRecoveryCodes(context.Background(), subject, currentCode(t, repo))
+	require.NoError(t, err)
+
+	require.NoError(t, u.Verify(context.Background(), subject, codes[0]))
+	require.Equal(t, ErrInvalidMFACode, u.Verify(context.Background(), subject, codes[0]))
+}
+
+func TestMFA_StaffCannotDisable(t *testing.T) {
+	repo := enrolledMFARepo(t)
+	u := newTestMFA(repo)
+
+	err := u.Disable(context.Background(), MFASubject{Type: utils.SubjectStaff, ID: 3}, currentCode(t, repo))
+	require.Equal(t, ErrMFAMandatory, err)
+	require.NotNil(t, repo.cred)
+}
+
+func TestLogin_MFAChallenge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	hash, err := utils.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &entity.AuthUser{ID: 5, ConsumerID: 17, Email: "budi@mail.com", Password: hash}
+	authRepo := &mockAuthRepoForRegister{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.AuthUser, error) { return user, nil },
+		findByIDFn:    func(ctx context.Context, id uint64) (*entity.AuthUser, error) { return user, nil },
+	}
+	mfaRepo := enrolledMFARepo(t)
+	tokens := newTestTokenManager()
+	u := NewAuthUsecase(db, &mockConsumerRepo{}, authRepo, &mockDocumentRepo{}, &mockRefreshTokenRepo{}, &mockSessionRepo{}, tokens, time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, newTestMFA(mfaRepo), nil)
+
+	res, err := u.Login(context.Background(), LoginRequest{Email: "budi@mail.com", Password: "secret123"})
+	require.NoError(t, err)
+	require.True(t, res.MFARequired)
+	require.Nil(t, res.TokenPair)
+	require.NotEmpty(t, res.MFAToken)
+
+	claims, err := tokens.Parse(res.MFAToken)
+	require.NoError(t, err)
+	require.Equal(t, utils.SubjectConsumerMFA, claims.SubjectType, "AuthMiddleware refuses this subject type")
+
+	_, err = u.LoginMFA(context.Background(), MFALoginRequest{MFAToken: res.MFAToken, Code: "000000"})
+	require.Equal(t, ErrInvalidMFACode, err)
+
+	done, err := u.LoginMFA(context.Background(), MFALoginRequest{MFAToken: res.MFAToken, Code: currentCode(t, mfaRepo)})
+	require.NoError(t, err)
+	require.NotEmpty(t, done.AccessToken)
+	require.NotEmpty(t, done.RefreshToken)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
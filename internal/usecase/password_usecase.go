@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/infrastructure/notifier"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+var ErrWrongPassword = errors.New("current password is incorrect")
+var ErrSamePassword = errors.New("new password must differ from the current password")
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type PasswordUsecase struct {
+	db               *sql.DB
+	authRepo         repository.AuthRepository
+	staffRepo        repository.StaffRepository
+	userTokenRepo    repository.UserTokenRepository
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	notifier         notifier.Notifier
+	policy           utils.PasswordPolicy
+	resetTTL         time.Duration
+	resetURL         string
+	guard            *LoginGuard
+}
+
+func NewPasswordUsecase(db *sql.DB, a repository.AuthRepository, st repository.StaffRepository, ut repository.UserTokenRepository, s repository.SessionRepository, rt repository.RefreshTokenRepository, n notifier.Notifier, policy utils.PasswordPolicy, resetTTL time.Duration, resetURL string, guard *LoginGuard) *PasswordUsecase {
+	return &PasswordUsecase{db, a, st, ut, s, rt, n, policy, resetTTL, resetURL, guard}
+}
+
+// Change sets a new password for a consumer and signs out every other
+// session; the session making the change stays logged in.
+func (u *PasswordUsecase) Change(ctx context.Context, authUserID uint64, sessionID string, req ChangePasswordRequest) error {
+	user, err := u.authRepo.FindByID(ctx, authUserID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := u.newHash(user.Password, req)
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.authRepo.UpdatePassword(ctx, tx, authUserID, hash); err != nil {
+		return err
+	}
+	if err := u.sessionRepo.RevokeOthersByAuthUser(ctx, tx, authUserID, sessionID); err != nil {
+		return err
+	}
+	if err := u.refreshTokenRepo.RevokeOthersByAuthUser(ctx, tx, authUserID, sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ChangeStaff is Change for back-office accounts.
+func (u *PasswordUsecase) ChangeStaff(ctx context.Context, staffUserID uint64, sessionID string, req ChangePasswordRequest) error {
+	staff, err := u.staffRepo.FindByID(ctx, staffUserID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := u.newHash(staff.Password, req)
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.staffRepo.UpdatePassword(ctx, tx, staffUserID, hash); err != nil {
+		return err
+	}
+	if err := u.sessionRepo.RevokeOthersByStaffUser(ctx, tx, staffUserID, sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (u *PasswordUsecase) newHash(current string, req ChangePasswordRequest) (string, error) {
+	if err := utils.ComparePassword(current, req.OldPassword); err != nil {
+		return "", ErrWrongPassword
+	}
+	if req.NewPassword == req.OldPassword {
+		return "", ErrSamePassword
+	}
+	if err := u.policy.Validate(req.NewPassword); err != nil {
+		return "", err
+	}
+	return utils.HashPassword(req.NewPassword)
+}
+
+// Forgot mails a one-time reset link. Unknown emails succeed silently so the
+// endpoint cannot be used to find out who has an account.
+func (u *PasswordUsecase) Forgot(ctx context.Context, req ForgotPasswordRequest) error {
+	user, err := u.authRepo.FindByEmail(ctx, req.Email)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.userTokenRepo.InvalidateByAuthUser(ctx, tx, user.ID, entity.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+	err = u.userTokenRepo.Create(ctx, tx, &entity.UserToken{
+		AuthUserID: user.ID,
+		Purpose:    entity.TokenPurposePasswordReset,
+		TokenHash:  utils.HashToken(token),
+		ExpiresAt:  time.Now().UTC().Add(u.resetTTL),
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return u.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Open the link below to choose a new password. It expires in %s.\n\n%s?token=%s\n\nIf you did not ask for this, ignore this message.",
+			u.resetTTL, u.resetURL, url.QueryEscape(token)),
+	})
+}
+
+// Reset redeems a reset token, sets the new password and signs the user out
+// everywhere.
+func (u *PasswordUsecase) Reset(ctx context.Context, req ResetPasswordRequest) error {
+	if err := u.policy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, err := u.userTokenRepo.FindByHashForUpdate(ctx, tx, entity.TokenPurposePasswordReset, utils.HashToken(req.Token))
+	if err == sql.ErrNoRows {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if t.UsedAt != nil || !time.Now().UTC().Before(t.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := u.authRepo.FindByID(ctx, t.AuthUserID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := u.userTokenRepo.MarkUsed(ctx, tx, t.ID); err != nil {
+		return err
+	}
+	if err := u.authRepo.UpdatePassword(ctx, tx, user.ID, hash); err != nil {
+		return err
+	}
+	if err := u.sessionRepo.RevokeByAuthUser(ctx, tx, user.ID); err != nil {
+		return err
+	}
+	if err := u.refreshTokenRepo.RevokeByAuthUser(ctx, tx, user.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	u.guard.Unlock(utils.SubjectConsumer, user.Email)
+	return nil
+}
@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/infrastructure/notifier"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockUserTokenRepo struct {
+	createFn     func(ctx context.Context, tx *sql.Tx, t *entity.UserToken) error
+	findFn       func(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error)
+	markUsedFn   func(ctx context.Context, tx *sql.Tx, id uint64) error
+	invalidateFn func(ctx context.Context, tx *sql.Tx, authUserID uint64, purpose entity.TokenPurpose) error
+	created      []*entity.UserToken
+	deletedFor   []uint64
+}
+
+func (m *mockUserTokenRepo) Create(ctx context.Context, tx *sql.Tx, t *entity.UserToken) error {
+	m.created = append(m.created, t)
+	if m.createFn != nil {
+		return m.createFn(ctx, tx, t)
+	}
+	return nil
+}
+func (m *mockUserTokenRepo) FindByHashForUpdate(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error) {
+	if m.findFn != nil {
+		return m.findFn(ctx, tx, purpose, hash)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockUserTokenRepo) MarkUsed(ctx context.Context, tx *sql.Tx, id uint64) error {
+	if m.markUsedFn != nil {
+		return m.markUsedFn(ctx, tx, id)
+	}
+	return nil
+}
+func (m *mockUserTokenRepo) InvalidateByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, purpose entity.TokenPurpose) error {
+	if m.invalidateFn != nil {
+		return m.invalidateFn(ctx, tx, authUserID, purpose)
+	}
+	return nil
+}
+
+func (m *mockUserTokenRepo) DeleteByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+	m.deletedFor = append(m.deletedFor, authUserID)
+	return nil
+}
+
+type mockNotifier struct {
+	sent []notifier.Message
+}
+
+func (m *mockNotifier) Send(ctx context.Context, msg notifier.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func passwordAuthRepo(t *testing.T, password string) *mockAuthRepoForRegister {
+	hash, err := utils.HashPassword(password)
+	require.NoError(t, err)
+	user := &entity.AuthUser{ID: 5, ConsumerID: 17, Email: "budi@mail.com", Password: hash}
+	return &mockAuthRepoForRegister{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.AuthUser, error) {
+			if email != user.Email {
+				return nil, sql.ErrNoRows
+			}
+			return user, nil
+		},
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.AuthUser, error) {
+			return user, nil
+		},
+	}
+}
+
+func TestChangePassword_KeepsCurrentSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	authRepo := passwordAuthRepo(t, "Secret123")
+	var newHash string
+	authRepo.updatePwdFn = func(ctx context.Context, tx *sql.Tx, id uint64, hash string) error {
+		newHash = hash
+		return nil
+	}
+	var kept, keptFamily string
+	sessionRepo := &mockSessionRepo{
+		revokeOthersFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64, keepID string) error {
+			kept = keepID
+			return nil
+		},
+	}
+	refreshRepo := &mockRefreshTokenRepo{
+		revokeOthersFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64, keepFamilyID string) error {
+			keptFamily = keepFamilyID
+			return nil
+		},
+	}
+	u := NewPasswordUsecase(db, authRepo, nil, &mockUserTokenRepo{}, sessionRepo, refreshRepo, &mockNotifier{}, utils.DefaultPasswordPolicy, time.Hour, "", newTestLoginGuard())
+
+	err = u.Change(context.Background(), 5, "sess", ChangePasswordRequest{OldPassword: "Secret123", NewPassword: "Fresh4Secret"})
+	require.NoError(t, err)
+	require.NoError(t, utils.ComparePassword(newHash, "Fresh4Secret"))
+	require.Equal(t, "sess", kept)
+	require.Equal(t, "sess", keptFamily)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePassword_Rejected(t *testing.T) {
+	u := NewPasswordUsecase(nil, passwordAuthRepo(t, "Secret123"), nil, &mockUserTokenRepo{}, &mockSessionRepo{}, &mockRefreshTokenRepo{}, &mockNotifier{}, utils.DefaultPasswordPolicy, time.Hour, "", newTestLoginGuard())
+
+	err := u.Change(context.Background(), 5, "sess", ChangePasswordRequest{OldPassword: "wrong", NewPassword: "Fresh4Secret"})
+	require.Equal(t, ErrWrongPassword, err)
+
+	err = u.Change(context.Background(), 5, "sess", ChangePasswordRequest{OldPassword: "Secret123", NewPassword: "Secret123"})
+	require.Equal(t, ErrSamePassword, err)
+
+	err = u.Change(context.Background(), 5, "sess", ChangePasswordRequest{OldPassword: "Secret123", NewPassword: "short"})
+	require.ErrorIs(t, err, utils.ErrWeakPassword)
+}
+
+func TestForgotPassword_SendsOneTimeLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	tokenRepo := &mockUserTokenRepo{}
+	n := &mockNotifier{}
+	u := NewPasswordUsecase(db, passwordAuthRepo(t, "Secret123"), nil, tokenRepo, &mockSessionRepo{}, &mockRefreshTokenRepo{}, n, utils.DefaultPasswordPolicy, 30*time.Minute, "https://app.example/reset", newTestLoginGuard())
+
+	require.NoError(t, u.Forgot(context.Background(), ForgotPasswordRequest{Email: "budi@mail.com"}))
+	require.Len(t, n.sent, 1)
+	require.Equal(t, "budi@mail.com", n.sent[0].To)
+	require.Len(t, tokenRepo.created, 1)
+
+	_, token, ok := strings.Cut(n.sent[0].Body, "https://app.example/reset?token=")
+	require.True(t, ok)
+	token = strings.Fields(token)[0]
+	require.Equal(t, utils.HashToken(token), tokenRepo.created[0].TokenHash)
+	require.Equal(t, entity.TokenPurposePasswordReset, tokenRepo.created[0].Purpose)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForgotPassword_UnknownEmailIsSilent(t *testing.T) {
+	n := &mockNotifier{}
+	u := NewPasswordUsecase(nil, passwordAuthRepo(t, "Secret123"), nil, &mockUserTokenRepo{}, &mockSessionRepo{}, &mockRefreshTokenRepo{}, n, utils.DefaultPasswordPolicy, time.Hour, "", newTestLoginGuard())
+
+	require.NoError(t, u.Forgot(context.Background(), ForgotPasswordRequest{Email: "nobody@mail.com"}))
+	require.Empty(t, n.sent)
+}
+
+func TestResetPassword_RedeemsTokenAndRevokesSessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	authRepo := passwordAuthRepo(t, "Secret123")
+	tokenRepo := &mockUserTokenRepo{
+		findFn: func(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error) {
+			require.Equal(t, utils.HashToken("tok"), hash)
+			return &entity.UserToken{ID: 9, AuthUserID: 5, Purpose: purpose, ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+	}
+	var used uint64
+	tokenRepo.markUsedFn = func(ctx context.Context, tx *sql.Tx, id uint64) error {
+		used = id
+		return nil
+	}
+	var revoked bool
+	sessionRepo := &mockSessionRepo{
+		revokeByAuthUserFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			revoked = true
+			return nil
+		},
+	}
+	u := NewPasswordUsecase(db, authRepo, nil, tokenRepo, sessionRepo, &mockRefreshTokenRepo{}, &mockNotifier{}, utils.DefaultPasswordPolicy, time.Hour, "", newTestLoginGuard())
+
+	require.NoError(t, u.Reset(context.Background(), ResetPasswordRequest{Token: "tok", NewPassword: "Fresh4Secret"}))
+	require.Equal(t, uint64(9), used)
+	require.True(t, revoked)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResetPassword_UsedOrExpiredToken(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	for name, token := range map[string]*entity.UserToken{
+		"used":    {ID: 9, AuthUserID: 5, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt},
+		"expired": {ID: 9, AuthUserID: 5, ExpiresAt: time.Now().Add(-time.Minute)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			tokenRepo := &mockUserTokenRepo{
+				findFn: func(ctx context.Context, tx *sql.Tx, purpose entity.TokenPurpose, hash string) (*entity.UserToken, error) {
+					return token, nil
+				},
+			}
+			u := NewPasswordUsecase(db, passwordAuthRepo(t, "Secret123"), nil, tokenRepo, &mockSessionRepo{}, &mockRefreshTokenRepo{}, &mockNotifier{}, utils.DefaultPasswordPolicy, time.Hour, "", newTestLoginGuard())
+
+			err = u.Reset(context.Background(), ResetPasswordRequest{Token: "tok", NewPassword: "Fresh4Secret"})
+			require.Equal(t, ErrInvalidResetToken, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+
+	"multifinance-core/internal/repository"
+)
+
+// PIIUsecase maintains the encryption of consumer personal data.
+type PIIUsecase struct {
+	db           *sql.DB
+	consumerRepo repository.ConsumerRepository
+	changeRepo   repository.ConsumerChangeRequestRepository
+}
+
+func NewPIIUsecase(db *sql.DB, c repository.ConsumerRepository, ch repository.ConsumerChangeRequestRepository) *PIIUsecase {
+	return &PIIUsecase{db, c, ch}
+}
+
+// ReencryptBatch rewrites up to limit consumers and change requests that are
+// still plaintext or on an older key version under the current key, and
+// returns how many it moved. Consumers go first; change requests fill what is
+// left of the batch. Call it until it returns 0.
+func (u *PIIUsecase) ReencryptBatch(ctx context.Context, limit int) (int, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	ids, err := u.consumerRepo.ListIDsForReencryption(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return 0, err
+		}
+		if err := u.consumerRepo.Update(ctx, tx, c); err != nil {
+			return 0, err
+		}
+	}
+	n := len(ids)
+
+	if n < limit {
+		ids, err := u.changeRepo.ListIDsForReencryption(ctx, tx, limit-n)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			cr, err := u.changeRepo.FindByIDForUpdate(ctx, tx, id)
+			if err != nil {
+				return 0, err
+			}
+			if err := u.changeRepo.UpdatePII(ctx, tx, cr); err != nil {
+				return 0, err
+			}
+		}
+		n += len(ids)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
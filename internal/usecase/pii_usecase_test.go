@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReencryptBatch_RewritesEachRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var updated []uint64
+	repo := &mockConsumerRepo{
+		staleFn: func(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error) {
+			require.Equal(t, 50, limit)
+			return []uint64{3, 7}, nil
+		},
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			return &entity.Consumer{ID: id, NIK: "3173010101900001"}, nil
+		},
+		updateFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error {
+			updated = append(updated, c.ID)
+			return nil
+		},
+	}
+
+	changes := &mockChangeRequestRepo{}
+
+	n, err := NewPIIUsecase(db, repo, changes).ReencryptBatch(context.Background(), 50)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []uint64{3, 7}, updated)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptBatch_ChangeRequestsFillTheBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	repo := &mockConsumerRepo{
+		staleFn: func(ctx context.Context, tx *sql.Tx, limit int) ([]uint64, error) {
+			return []uint64{3}, nil
+		},
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			return &entity.Consumer{ID: id}, nil
+		},
+		updateFn: func(ctx context.Context, tx *sql.Tx, c *entity.Consumer) error {
+			return nil
+		},
+	}
+	nik := "3173010101900001"
+	changes := &mockChangeRequestRepo{
+		requests: []*entity.ConsumerChangeRequest{{ID: 1, ConsumerID: 3, NIK: &nik}, {ID: 2, ConsumerID: 4, NIK: &nik}},
+		stale:    []uint64{1, 2},
+	}
+
+	n, err := NewPIIUsecase(db, repo, changes).ReencryptBatch(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []uint64{1}, changes.resealed)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
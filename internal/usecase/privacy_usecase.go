@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/infrastructure/storage"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+var ErrOpenContracts = errors.New("consumer has open contracts")
+var ErrConsumerErased = errors.New("consumer data has already been erased")
+
+type EraseConsumerRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type ExportedAccount struct {
+	ID         uint64      `json:"id"`
+	Email      string      `json:"email"`
+	Role       entity.Role `json:"role"`
+	VerifiedAt *time.Time  `json:"verified_at,omitempty"`
+}
+
+type ExportedAudit struct {
+	KYCReviews     []*entity.KYCReview             `json:"kyc_reviews"`
+	StatusChanges  []*entity.ConsumerStatusChange  `json:"status_changes"`
+	ChangeRequests []*entity.ConsumerChangeRequest `json:"change_requests"`
+}
+
+// DataExport is everything held about one consumer, as handed out on a
+// personal data access request.
+type DataExport struct {
+	GeneratedAt  time.Time                  `json:"generated_at"`
+	Profile      *ConsumerProfile           `json:"profile"`
+	Account      *ExportedAccount           `json:"account,omitempty"`
+	Limits       []*entity.ConsumerLimit    `json:"limits"`
+	Transactions []*entity.Transaction      `json:"transactions"`
+	Repayments   []*entity.Repayment        `json:"repayments"`
+	LimitLedger  []*entity.LimitLedgerEntry `json:"limit_ledger"`
+	Documents    []*entity.Document         `json:"documents"`
+	Audit        ExportedAudit              `json:"audit"`
+}
+
+// PrivacyUsecase answers personal data requests: exports of everything held
+// about a consumer, and erasure. Erasure anonymises personal data but keeps
+// limits and transactions, which must be retained for financial records.
+type PrivacyUsecase struct {
+	db               *sql.DB
+	consumerRepo     repository.ConsumerRepository
+	authRepo         repository.AuthRepository
+	limitRepo        repository.ConsumerLimitRepository
+	txRepo           repository.ConsumerTransactionRepository
+	repaymentRepo    repository.RepaymentRepository
+	ledgerRepo       repository.LimitLedgerRepository
+	holdRepo         repository.LimitHoldRepository
+	docRepo          repository.DocumentRepository
+	changeRepo       repository.ConsumerChangeRequestRepository
+	reviewRepo       repository.KYCReviewRepository
+	statusRepo       repository.ConsumerStatusChangeRepository
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	mfaRepo          repository.MFARepository
+	userTokenRepo    repository.UserTokenRepository
+	store            storage.BlobStore
+}
+
+func NewPrivacyUsecase(db *sql.DB, c repository.ConsumerRepository, a repository.AuthRepository, l repository.ConsumerLimitRepository, t repository.ConsumerTransactionRepository, p repository.RepaymentRepository, lg repository.LimitLedgerRepository, h repository.LimitHoldRepository, d repository.DocumentRepository, ch repository.ConsumerChangeRequestRepository, r repository.KYCReviewRepository, sc repository.ConsumerStatusChangeRepository, s repository.SessionRepository, rt repository.RefreshTokenRepository, m repository.MFARepository, ut repository.UserTokenRepository, store storage.BlobStore) *PrivacyUsecase {
+	return &PrivacyUsecase{db, c, a, l, t, p, lg, h, d, ch, r, sc, s, rt, m, ut, store}
+}
+
+func (u *PrivacyUsecase) Export(ctx context.Context, consumerID uint64) (*DataExport, error) {
+	c, err := u.consumerRepo.FindByID(ctx, consumerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrConsumerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	export := &DataExport{GeneratedAt: time.Now().UTC(), Profile: consumerProfile(c)}
+
+	user, err := u.authRepo.FindByConsumerID(ctx, consumerID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if user != nil {
+		export.Profile.Email = user.Email
+		export.Profile.EmailVerified = user.Verified()
+		export.Account = &ExportedAccount{ID: user.ID, Email: user.Email, Role: entity.RoleConsumer, VerifiedAt: user.VerifiedAt}
+	}
+
+	if export.Limits, err = u.limitRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.Transactions, err = u.txRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.Repayments, err = u.repaymentRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.LimitLedger, err = u.ledgerRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.Documents, err = u.docRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.Audit.KYCReviews, err = u.reviewRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.Audit.StatusChanges, err = u.statusRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	if export.Audit.ChangeRequests, err = u.changeRepo.ListByConsumer(ctx, consumerID); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// WriteArchive writes the export as a ZIP holding data.json and the
+// consumer's identity photos under documents/.
+func (u *PrivacyUsecase) WriteArchive(ctx context.Context, consumerID uint64, w io.Writer) error {
+	export, err := u.Export(ctx, consumerID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return err
+	}
+
+	for _, d := range export.Documents {
+		if err := u.writeDocument(ctx, zw, d); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (u *PrivacyUsecase) writeDocument(ctx context.Context, zw *zip.Writer, d *entity.Document) error {
+	rc, err := u.store.Open(ctx, d.SHA256)
+	if err == storage.ErrBlobNotFound {
+		// The metadata in data.json still lists the document.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := zw.Create(fmt.Sprintf("documents/%s%s", d.ID, documentExtension(d.ContentType)))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, rc)
+	return err
+}
+
+// Erase anonymises the consumer and their login, removes their second factor,
+// one-time tokens and identity photos, and closes the account. It is refused
+// while the consumer has an open contract, a held limit or unpaid principal.
+func (u *PrivacyUsecase) Erase(ctx context.Context, consumerID, staffUserID uint64, req EraseConsumerRequest) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := u.consumerRepo.FindByIDForUpdate(ctx, tx, consumerID)
+	if err == sql.ErrNoRows {
+		return ErrConsumerNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if c.ErasedAt != nil {
+		return ErrConsumerErased
+	}
+
+	// HasOutstanding also locks the limits so no purchase or hold can slip in
+	// before the commit.
+	open, err := u.limitRepo.HasOutstanding(ctx, tx, c.ID)
+	if err != nil {
+		return err
+	}
+	if !open {
+		if open, err = u.txRepo.HasOpenByConsumer(ctx, tx, c.ID); err != nil {
+			return err
+		}
+	}
+	if !open {
+		if open, err = u.holdRepo.HasActiveByConsumer(ctx, tx, c.ID); err != nil {
+			return err
+		}
+	}
+	if open {
+		return ErrOpenContracts
+	}
+
+	if err := u.consumerRepo.Anonymise(ctx, tx, c.ID); err != nil {
+		return err
+	}
+	if err := u.changeRepo.AnonymiseByConsumer(ctx, tx, c.ID); err != nil {
+		return err
+	}
+
+	if c.Status != entity.ConsumerClosed {
+		if err := u.consumerRepo.UpdateStatus(ctx, tx, c.ID, entity.ConsumerClosed, req.Reason); err != nil {
+			return err
+		}
+		err = u.statusRepo.Create(ctx, tx, &entity.ConsumerStatusChange{
+			ConsumerID: c.ID,
+			FromStatus: c.Status,
+			ToStatus:   entity.ConsumerClosed,
+			Reason:     req.Reason,
+			ChangedBy:  staffUserID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	user, err := u.authRepo.FindByConsumerID(ctx, c.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if user != nil {
+		if err := u.authRepo.Anonymise(ctx, tx, user.ID, fmt.Sprintf("erased-%d@erased.invalid", user.ID)); err != nil {
+			return err
+		}
+		if err := u.refreshTokenRepo.RevokeByAuthUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		if err := u.sessionRepo.RevokeByAuthUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		if err := u.mfaRepo.Delete(ctx, tx, utils.SubjectConsumer, user.ID); err != nil {
+			return err
+		}
+		if err := u.userTokenRepo.DeleteByAuthUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	docs, err := u.docRepo.ListByConsumer(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if err := u.docRepo.DeleteByConsumer(ctx, tx, c.ID); err != nil {
+		return err
+	}
+	var orphans []string
+	for _, d := range docs {
+		shared, err := u.docRepo.ExistsBySHA256(ctx, tx, d.SHA256)
+		if err != nil {
+			return err
+		}
+		if !shared {
+			orphans = append(orphans, d.SHA256)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Blobs go only once the erasure is committed, so a failed commit never
+	// leaves a consumer whose photos are gone. A blob that fails to delete is
+	// logged for removal by hand; the erasure itself stands.
+	for _, sha := range orphans {
+		if err := u.store.Delete(ctx, sha); err != nil {
+			log.Printf("erasing consumer %d: deleting blob %s: %v", c.ID, sha, err)
+		}
+	}
+	return nil
+}
+
+func documentExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	}
+	return ""
+}
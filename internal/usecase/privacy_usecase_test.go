@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type privacyFixture struct {
+	consumer *entity.Consumer
+	user     *entity.AuthUser
+	limits   []*entity.ConsumerLimit
+	docs     *mockDocumentRepo
+	store    *memoryBlobStore
+	changes  *mockChangeRequestRepo
+	statuses *mockConsumerStatusChangeRepo
+	holds    *mockLimitHoldRepo
+	mfa      *mockMFARepo
+	tokens   *mockUserTokenRepo
+	revoked  []uint64
+	// openTx makes the consumer hold an unpaid contract; commitErr fails
+	// every commit.
+	openTx    bool
+	commitErr error
+}
+
+func newPrivacyFixture() *privacyFixture {
+	consumerID := uint64(10)
+	return &privacyFixture{
+		consumer: &entity.Consumer{ID: consumerID, NIK: "3173010101900001", FullName: "Budi", LegalName: "BUDI SANTOSO", Status: entity.ConsumerActive},
+		user:     &entity.AuthUser{ID: 50, ConsumerID: consumerID, Email: "budi@mail.com"},
+		limits: []*entity.ConsumerLimit{
+			{ID: 1, ConsumerID: consumerID, TenorMonth: 1, MaxLimit: 2000000},
+			{ID: 2, ConsumerID: consumerID, TenorMonth: 3, MaxLimit: 6000000},
+		},
+		docs: newMockDocumentRepo(
+			&entity.Document{ID: "doc_ktp1", ConsumerID: &consumerID, Kind: entity.DocumentKTP, SHA256: "aa11", ContentType: "image/jpeg"},
+			&entity.Document{ID: "doc_selfie1", ConsumerID: &consumerID, Kind: entity.DocumentSelfie, SHA256: "bb22", ContentType: "image/png"},
+		),
+		store:    &memoryBlobStore{blobs: map[string][]byte{"aa11": []byte("ktp"), "bb22": []byte("selfie")}},
+		changes:  &mockChangeRequestRepo{},
+		statuses: &mockConsumerStatusChangeRepo{},
+		holds:    newMockLimitHoldRepo(),
+		mfa:      &mockMFARepo{cred: &entity.MFACredential{ID: 1}, recovery: map[string]bool{"code": false}},
+		tokens:   &mockUserTokenRepo{},
+	}
+}
+
+func (f *privacyFixture) usecase(t *testing.T) *PrivacyUsecase {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 5; i++ {
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(f.commitErr)
+	}
+
+	consumers := &mockConsumerRepo{
+		findByIDFn: func(ctx context.Context, id uint64) (*entity.Consumer, error) {
+			if id != f.consumer.ID {
+				return nil, sql.ErrNoRows
+			}
+			c := *f.consumer
+			return &c, nil
+		},
+		eraseFn: func(ctx context.Context, tx *sql.Tx, id uint64) error {
+			now := time.Now()
+			f.consumer.NIK, f.consumer.FullName, f.consumer.LegalName = "", "", ""
+			f.consumer.ErasedAt = &now
+			return nil
+		},
+		setStatFn: func(ctx context.Context, tx *sql.Tx, id uint64, status entity.ConsumerStatus, reason string) error {
+			f.consumer.Status = status
+			f.consumer.StatusReason = reason
+			return nil
+		},
+	}
+	auth := &mockAuthRepoForRegister{
+		findByConsumerIDFn: func(ctx context.Context, consumerID uint64) (*entity.AuthUser, error) {
+			return f.user, nil
+		},
+		anonymiseFn: func(ctx context.Context, tx *sql.Tx, id uint64, email string) error {
+			f.user.Email = email
+			f.user.Password = ""
+			return nil
+		},
+	}
+	limits := &mockConsumerLimitRepo{
+		listFn: func(ctx context.Context, consumerID uint64) ([]*entity.ConsumerLimit, error) {
+			return f.limits, nil
+		},
+	}
+	txs := &mockTxRepoTx{
+		listByConsumerFn: func(ctx context.Context, consumerID uint64) ([]*entity.Transaction, error) {
+			return []*entity.Transaction{{ID: 7, ConsumerID: consumerID, ContractNo: "C-10-1", OTR: 1500000, Status: "SUCCESS"}}, nil
+		},
+		hasOpenFn: func(ctx context.Context, tx *sql.Tx, consumerID uint64) (bool, error) {
+			return f.openTx, nil
+		},
+	}
+	sessions := &mockSessionRepo{
+		revokeByAuthUserFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			f.revoked = append(f.revoked, authUserID)
+			return nil
+		},
+	}
+	repayments := &mockRepaymentRepo{created: []*entity.Repayment{
+		{ID: 1, TransactionID: 7, ConsumerID: f.consumer.ID, Kind: entity.RepaymentInstallment, Amount: 550000, Principal: 500000},
+	}}
+	ledger := &mockLimitLedgerRepo{entries: []*entity.LimitLedgerEntry{
+		{ID: 1, ConsumerLimitID: 2, ConsumerID: f.consumer.ID, TenorMonth: 3, Kind: entity.LimitDebit, Amount: 1500000},
+		{ID: 2, ConsumerLimitID: 2, ConsumerID: f.consumer.ID, TenorMonth: 3, Kind: entity.LimitRelease, Amount: -500000},
+		{ID: 3, ConsumerLimitID: 9, ConsumerID: 99, TenorMonth: 3, Kind: entity.LimitDebit, Amount: 100000},
+	}}
+	return NewPrivacyUsecase(db, consumers, auth, limits, txs, repayments, ledger, f.holds, f.docs, f.changes, &mockKYCReviewRepo{}, f.statuses, sessions, &mockRefreshTokenRepo{}, f.mfa, f.tokens, f.store)
+}
+
+func TestPrivacyExport_Archive(t *testing.T) {
+	f := newPrivacyFixture()
+	u := f.usecase(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, u.WriteArchive(context.Background(), 10, &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[zf.Name] = b
+	}
+	require.Equal(t, []byte("ktp"), files["documents/doc_ktp1.jpg"])
+	require.Equal(t, []byte("selfie"), files["documents/doc_selfie1.png"])
+
+	var export DataExport
+	require.NoError(t, json.Unmarshal(files["data.json"], &export))
+	require.Equal(t, "3173010101900001", export.Profile.NIK)
+	require.Equal(t, "budi@mail.com", export.Account.Email)
+	require.Len(t, export.Limits, 2)
+	require.Len(t, export.Transactions, 1)
+	require.Len(t, export.Repayments, 1)
+	require.Equal(t, int64(500000), export.Repayments[0].Principal)
+	// only the consumer's own ledger entries
+	require.Len(t, export.LimitLedger, 2)
+	require.Equal(t, entity.LimitRelease, export.LimitLedger[1].Kind)
+	require.Len(t, export.Documents, 2)
+}
+
+func TestPrivacyErase_RefusedWithOpenContracts(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(f *privacyFixture)
+	}{
+		{"used limit", func(f *privacyFixture) { f.limits[1].UsedLimit = 1500000 }},
+		{"open contract", func(f *privacyFixture) { f.openTx = true }},
+		{"held limit", func(f *privacyFixture) {
+			h := testHold(1, time.Now().Add(time.Hour))
+			h.ConsumerID = f.consumer.ID
+			f.holds.holds[h.ID] = h
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newPrivacyFixture()
+			tc.setup(f)
+			u := f.usecase(t)
+
+			err := u.Erase(context.Background(), 10, 2, EraseConsumerRequest{Reason: "customer request"})
+			require.ErrorIs(t, err, ErrOpenContracts)
+			require.Equal(t, "3173010101900001", f.consumer.NIK)
+			require.Len(t, f.store.blobs, 2)
+		})
+	}
+}
+
+func TestPrivacyErase_AnonymisesAndCloses(t *testing.T) {
+	f := newPrivacyFixture()
+	nik := "3173010101900002"
+	f.changes.requests = []*entity.ConsumerChangeRequest{{ID: 1, ConsumerID: 10, NIK: &nik, Status: entity.ChangeRequestRejected}}
+	u := f.usecase(t)
+
+	err := u.Erase(context.Background(), 10, 2, EraseConsumerRequest{Reason: "customer request"})
+	require.NoError(t, err)
+
+	require.Empty(t, f.consumer.NIK)
+	require.Equal(t, entity.ConsumerClosed, f.consumer.Status)
+	require.Equal(t, "erased-50@erased.invalid", f.user.Email)
+	require.Equal(t, []uint64{50}, f.revoked)
+	require.Nil(t, f.changes.requests[0].NIK)
+	require.Nil(t, f.mfa.cred)
+	require.Empty(t, f.mfa.recovery)
+	require.Equal(t, []uint64{50}, f.tokens.deletedFor)
+	require.Empty(t, f.docs.docs)
+	require.Empty(t, f.store.blobs)
+
+	require.Len(t, f.statuses.changes, 1)
+	require.Equal(t, entity.ConsumerClosed, f.statuses.changes[0].ToStatus)
+	require.Equal(t, uint64(2), f.statuses.changes[0].ChangedBy)
+
+	err = u.Erase(context.Background(), 10, 2, EraseConsumerRequest{Reason: "customer request"})
+	require.ErrorIs(t, err, ErrConsumerErased)
+}
+
+func TestPrivacyErase_SharedBlobKept(t *testing.T) {
+	f := newPrivacyFixture()
+	other := uint64(11)
+	f.docs.docs["doc_ktp9"] = &entity.Document{ID: "doc_ktp9", ConsumerID: &other, Kind: entity.DocumentKTP, SHA256: "aa11"}
+	u := f.usecase(t)
+
+	require.NoError(t, u.Erase(context.Background(), 10, 2, EraseConsumerRequest{Reason: "customer request"}))
+	require.Contains(t, f.store.blobs, "aa11")
+	require.NotContains(t, f.store.blobs, "bb22")
+}
+
+func TestPrivacyErase_BlobsKeptWhenCommitFails(t *testing.T) {
+	f := newPrivacyFixture()
+	f.commitErr = errors.New("connection lost")
+	u := f.usecase(t)
+
+	err := u.Erase(context.Background(), 10, 2, EraseConsumerRequest{Reason: "customer request"})
+	require.Error(t, err)
+	require.Len(t, f.store.blobs, 2)
+}
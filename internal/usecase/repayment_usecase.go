@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrContractNotFound = errors.New("contract not found")
+var ErrContractNotActive = errors.New("contract is not active")
+var ErrInvalidRepayment = errors.New("repayment must be positive and at most the outstanding balance")
+
+type RecordRepaymentRequest struct {
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Reference string `json:"reference" binding:"max=64"`
+}
+
+type CloseContractRequest struct {
+	Reference string `json:"reference" binding:"max=64"`
+}
+
+// RepaymentUsecase records payments against contracts. Each payment releases
+// its principal share back to the limit the contract was drawn from, in the
+// same database transaction, with a RELEASE entry in the limit ledger.
+type RepaymentUsecase struct {
+	db         *sql.DB
+	repo       repository.RepaymentRepository
+	txRepo     repository.ConsumerTransactionRepository
+	limitRepo  repository.ConsumerLimitRepository
+	ledgerRepo repository.LimitLedgerRepository
+}
+
+func NewRepaymentUsecase(db *sql.DB, r repository.RepaymentRepository, t repository.ConsumerTransactionRepository, l repository.ConsumerLimitRepository, lg repository.LimitLedgerRepository) *RepaymentUsecase {
+	return &RepaymentUsecase{db, r, t, l, lg}
+}
+
+// Record books an instalment. The payment that clears the balance closes the
+// contract.
+func (u *RepaymentUsecase) Record(ctx context.Context, staffID, transactionID uint64, req RecordRepaymentRequest) (*entity.Repayment, *entity.Transaction, error) {
+	return u.apply(ctx, staffID, transactionID, entity.RepaymentInstallment, req.Amount, req.Reference)
+}
+
+// Close settles everything still owed on a contract and closes it, releasing
+// the rest of its principal.
+func (u *RepaymentUsecase) Close(ctx context.Context, staffID, transactionID uint64, req CloseContractRequest) (*entity.Repayment, *entity.Transaction, error) {
+	return u.apply(ctx, staffID, transactionID, entity.RepaymentSettlement, 0, req.Reference)
+}
+
+// List returns the repayments of a contract, oldest first.
+func (u *RepaymentUsecase) List(ctx context.Context, transactionID uint64) ([]*entity.Repayment, error) {
+	if _, err := u.contract(ctx, transactionID); err != nil {
+		return nil, err
+	}
+	return u.repo.ListByTransaction(ctx, transactionID)
+}
+
+// ListForConsumer is List for the consumer's own contracts; other contracts
+// are reported as not found.
+func (u *RepaymentUsecase) ListForConsumer(ctx context.Context, consumerID, transactionID uint64) ([]*entity.Repayment, error) {
+	tr, err := u.contract(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if tr.ConsumerID != consumerID {
+		return nil, ErrContractNotFound
+	}
+	return u.repo.ListByTransaction(ctx, transactionID)
+}
+
+// apply records a payment of amount, or of the whole outstanding balance for
+// a settlement.
+func (u *RepaymentUsecase) apply(ctx context.Context, staffID, transactionID uint64, kind entity.RepaymentKind, amount int64, reference string) (*entity.Repayment, *entity.Transaction, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	tr, err := u.txRepo.FindByIDForUpdate(ctx, tx, transactionID)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrContractNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if tr.Status != entity.TransactionActive {
+		return nil, nil, ErrContractNotActive
+	}
+
+	outstanding := tr.Outstanding()
+	if kind == entity.RepaymentSettlement {
+		amount = outstanding
+	}
+	if amount <= 0 || amount > outstanding {
+		return nil, nil, ErrInvalidRepayment
+	}
+
+	principal := principalShare(tr, amount)
+	p := &entity.Repayment{
+		TransactionID: tr.ID,
+		ConsumerID:    tr.ConsumerID,
+		Kind:          kind,
+		Amount:        amount,
+		Principal:     principal,
+		Reference:     reference,
+		RecordedBy:    &staffID,
+	}
+	id, err := u.repo.Create(ctx, tx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.ID = id
+
+	cl, err := u.limitRepo.GetByIDForUpdate(ctx, tx, tr.ConsumerLimitID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Limits used before the ledger may carry less than the contract's
+	// principal; never release below zero.
+	release := math.Min(float64(principal), cl.UsedLimit)
+	if release > 0 {
+		if err := u.limitRepo.ReleaseUsedLimit(ctx, tx, cl.ID, release); err != nil {
+			return nil, nil, err
+		}
+		err = u.ledgerRepo.Create(ctx, tx, &entity.LimitLedgerEntry{
+			ConsumerLimitID: cl.ID,
+			ConsumerID:      cl.ConsumerID,
+			TenorMonth:      cl.TenorMonth,
+			Kind:            entity.LimitRelease,
+			Amount:          -release,
+			UsedBefore:      cl.UsedLimit,
+			UsedAfter:       cl.UsedLimit - release,
+			MaxBefore:       cl.MaxLimit,
+			MaxAfter:        cl.MaxLimit,
+			SourceType:      entity.LimitSourceTransaction,
+			SourceID:        strconv.FormatUint(tr.ID, 10),
+			Reason:          "repayment " + strconv.FormatUint(id, 10) + " of " + tr.ContractNo,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tr.AmountPaid += amount
+	tr.PrincipalPaid += principal
+	if tr.Outstanding() == 0 {
+		now := time.Now().UTC()
+		tr.Status = entity.TransactionClosed
+		tr.ClosedAt = &now
+	}
+	if err := u.txRepo.UpdateRepayment(ctx, tx, tr); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return p, tr, nil
+}
+
+func (u *RepaymentUsecase) contract(ctx context.Context, transactionID uint64) (*entity.Transaction, error) {
+	tr, err := u.txRepo.FindByID(ctx, transactionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrContractNotFound
+	}
+	return tr, err
+}
+
+// principalShare is the part of a payment that repays the OTR. Payments are
+// split pro rata between principal and fees plus interest; the payment that
+// clears the balance takes whatever principal is left, so rounding never
+// strands credit on the limit.
+func principalShare(tr *entity.Transaction, amount int64) int64 {
+	remaining := tr.OTR - tr.PrincipalPaid
+	if amount == tr.Outstanding() {
+		return remaining
+	}
+	share := int64(math.Round(float64(amount) * float64(tr.OTR) / float64(tr.Total())))
+	if share > remaining {
+		return remaining
+	}
+	return share
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepaymentRepo struct {
+	created []*entity.Repayment
+}
+
+func (m *mockRepaymentRepo) Create(ctx context.Context, tx *sql.Tx, p *entity.Repayment) (uint64, error) {
+	m.created = append(m.created, p)
+	return uint64(len(m.created)), nil
+}
+
+func (m *mockRepaymentRepo) ListByTransaction(ctx context.Context, transactionID uint64) ([]*entity.Repayment, error) {
+	var res []*entity.Repayment
+	for _, p := range m.created {
+		if p.TransactionID == transactionID {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRepaymentRepo) ListByConsumer(ctx context.Context, consumerID uint64) ([]*entity.Repayment, error) {
+	var res []*entity.Repayment
+	for _, p := range m.created {
+		if p.ConsumerID == consumerID {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// testContract is a 3 month contract over an OTR of 1,000,000: 50,000 admin
+// fee and 60,000 interest, paid as 370,000 a month.
+func testContract(amountPaid, principalPaid int64) *entity.Transaction {
+	return &entity.Transaction{
+		ID:              42,
+		ContractNo:      "C-1-1",
+		ConsumerID:      1,
+		ConsumerLimitID: 4,
+		TenorMonth:      3,
+		OTR:             1000000,
+		AdminFee:        50000,
+		JumlahBunga:     60000,
+		JumlahCicilan:   370000,
+		Status:          entity.TransactionActive,
+		AmountPaid:      amountPaid,
+		PrincipalPaid:   principalPaid,
+	}
+}
+
+func repaymentFixture(t *testing.T, tr *entity.Transaction, used float64) (*RepaymentUsecase, sqlmock.Sqlmock, *mockTxRepoTx, *mockLimitLedgerRepo, *float64) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	txRepo := &mockTxRepoTx{
+		findFn: func(ctx context.Context, id uint64) (*entity.Transaction, error) {
+			if id != tr.ID {
+				return nil, sql.ErrNoRows
+			}
+			return tr, nil
+		},
+	}
+	released := new(float64)
+	limitRepo := &mockConsumerLimitRepo{
+		byIDFn: func(ctx context.Context, id uint64) (*entity.ConsumerLimit, error) {
+			require.Equal(t, tr.ConsumerLimitID, id)
+			return &entity.ConsumerLimit{ID: id, ConsumerID: tr.ConsumerID, TenorMonth: tr.TenorMonth, MaxLimit: 6000000, UsedLimit: used, Active: true}, nil
+		},
+		freeFn: func(ctx context.Context, tx *sql.Tx, id uint64, amount float64) error {
+			*released += amount
+			return nil
+		},
+	}
+	ledger := &mockLimitLedgerRepo{}
+
+	u := NewRepaymentUsecase(db, &mockRepaymentRepo{}, txRepo, limitRepo, ledger)
+	return u, mock, txRepo, ledger, released
+}
+
+func TestRecordRepayment_Installment(t *testing.T) {
+	u, mock, txRepo, ledger, released := repaymentFixture(t, testContract(0, 0), 1500000)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	p, tr, err := u.Record(context.Background(), 3, 42, RecordRepaymentRequest{Amount: 370000, Reference: "VA-001"})
+	require.NoError(t, err)
+	require.Equal(t, int64(333333), p.Principal)
+	require.Equal(t, uint64(3), *p.RecordedBy)
+	require.Equal(t, 333333.0, *released)
+	require.Equal(t, entity.TransactionActive, tr.Status)
+	require.Len(t, txRepo.updated, 1)
+	require.Equal(t, int64(370000), txRepo.updated[0].AmountPaid)
+
+	require.Len(t, ledger.entries, 1)
+	e := ledger.entries[0]
+	require.Equal(t, entity.LimitRelease, e.Kind)
+	require.Equal(t, -333333.0, e.Amount)
+	require.Equal(t, 1500000.0, e.UsedBefore)
+	require.Equal(t, 1166667.0, e.UsedAfter)
+	require.Equal(t, "42", e.SourceID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordRepayment_FinalInstallmentClosesContract(t *testing.T) {
+	u, mock, _, _, released := repaymentFixture(t, testContract(740000, 666666), 1000000)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	p, tr, err := u.Record(context.Background(), 3, 42, RecordRepaymentRequest{Amount: 370000})
+	require.NoError(t, err)
+	// the last payment takes the rounding remainder
+	require.Equal(t, int64(333334), p.Principal)
+	require.Equal(t, 333334.0, *released)
+	require.Equal(t, entity.TransactionClosed, tr.Status)
+	require.NotNil(t, tr.ClosedAt)
+	require.Equal(t, tr.OTR, tr.PrincipalPaid)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCloseContract_SettlesBalance(t *testing.T) {
+	u, mock, _, _, released := repaymentFixture(t, testContract(370000, 333333), 1000000)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	p, tr, err := u.Close(context.Background(), 3, 42, CloseContractRequest{Reference: "early payoff"})
+	require.NoError(t, err)
+	require.Equal(t, entity.RepaymentSettlement, p.Kind)
+	require.Equal(t, int64(740000), p.Amount)
+	require.Equal(t, 666667.0, *released)
+	require.Equal(t, entity.TransactionClosed, tr.Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordRepayment_NeverReleasesBelowZero(t *testing.T) {
+	u, mock, _, ledger, released := repaymentFixture(t, testContract(0, 0), 100000)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	_, _, err := u.Record(context.Background(), 3, 42, RecordRepaymentRequest{Amount: 370000})
+	require.NoError(t, err)
+	require.Equal(t, 100000.0, *released)
+	require.Equal(t, 0.0, ledger.entries[0].UsedAfter)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordRepayment_Refused(t *testing.T) {
+	closed := testContract(1110000, 1000000)
+	closed.Status = entity.TransactionClosed
+
+	cases := []struct {
+		name   string
+		tr     *entity.Transaction
+		id     uint64
+		amount int64
+		want   error
+	}{
+		{"missing", testContract(0, 0), 99, 370000, ErrContractNotFound},
+		{"closed", closed, 42, 370000, ErrContractNotActive},
+		{"overpaid", testContract(740000, 666666), 42, 370001, ErrInvalidRepayment},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, mock, txRepo, ledger, released := repaymentFixture(t, tc.tr, 1000000)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			_, _, err := u.Record(context.Background(), 3, tc.id, RecordRepaymentRequest{Amount: tc.amount})
+			require.ErrorIs(t, err, tc.want)
+			require.Zero(t, *released)
+			require.Empty(t, ledger.entries)
+			require.Empty(t, txRepo.updated)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListRepaymentsForConsumer_OtherConsumer(t *testing.T) {
+	u, _, _, _, _ := repaymentFixture(t, testContract(0, 0), 0)
+
+	_, err := u.ListForConsumer(context.Background(), 2, 42)
+	require.ErrorIs(t, err, ErrContractNotFound)
+}
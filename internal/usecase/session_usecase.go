@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionUsecase struct {
+	db               *sql.DB
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+}
+
+func NewSessionUsecase(db *sql.DB, s repository.SessionRepository, rt repository.RefreshTokenRepository) *SessionUsecase {
+	return &SessionUsecase{db, s, rt}
+}
+
+func (u *SessionUsecase) List(ctx context.Context, authUserID uint64) ([]*entity.Session, error) {
+	return u.sessionRepo.ListActiveByAuthUser(ctx, authUserID)
+}
+
+// Logout ends the session the current access token belongs to. The caller has
+// already proven ownership by presenting a valid token for it.
+func (u *SessionUsecase) Logout(ctx context.Context, sessionID string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.sessionRepo.Revoke(ctx, tx, sessionID); err != nil {
+		return err
+	}
+	if err := u.refreshTokenRepo.RevokeByFamily(ctx, tx, sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Revoke ends one of the caller's sessions together with its refresh tokens.
+func (u *SessionUsecase) Revoke(ctx context.Context, authUserID uint64, sessionID string) error {
+	s, err := u.sessionRepo.FindByID(ctx, sessionID)
+	if err == sql.ErrNoRows {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if s.AuthUserID != authUserID {
+		return ErrSessionNotFound
+	}
+	return u.Logout(ctx, sessionID)
+}
+
+// RevokeAll ends every session of the user, including the current one.
+func (u *SessionUsecase) RevokeAll(ctx context.Context, authUserID uint64) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := u.sessionRepo.RevokeByAuthUser(ctx, tx, authUserID); err != nil {
+		return err
+	}
+	if err := u.refreshTokenRepo.RevokeByAuthUser(ctx, tx, authUserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSessionRepo struct {
+	createFn           func(ctx context.Context, tx *sql.Tx, s *entity.Session) error
+	findByIDFn         func(ctx context.Context, id string) (*entity.Session, error)
+	listFn             func(ctx context.Context, authUserID uint64) ([]*entity.Session, error)
+	touchFn            func(ctx context.Context, tx *sql.Tx, id string, expiresAt time.Time) error
+	revokeFn           func(ctx context.Context, tx *sql.Tx, id string) error
+	revokeByAuthUserFn func(ctx context.Context, tx *sql.Tx, authUserID uint64) error
+	revokeOthersFn     func(ctx context.Context, tx *sql.Tx, authUserID uint64, keepID string) error
+	revokeOthersStaff  func(ctx context.Context, tx *sql.Tx, staffUserID uint64, keepID string) error
+}
+
+func (m *mockSessionRepo) Create(ctx context.Context, tx *sql.Tx, s *entity.Session) error {
+	if m.createFn != nil {
+		return m.createFn(ctx, tx, s)
+	}
+	return nil
+}
+func (m *mockSessionRepo) FindByID(ctx context.Context, id string) (*entity.Session, error) {
+	if m.findByIDFn != nil {
+		return m.findByIDFn(ctx, id)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockSessionRepo) ListActiveByAuthUser(ctx context.Context, authUserID uint64) ([]*entity.Session, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx, authUserID)
+	}
+	return nil, nil
+}
+func (m *mockSessionRepo) Touch(ctx context.Context, tx *sql.Tx, id string, expiresAt time.Time) error {
+	if m.touchFn != nil {
+		return m.touchFn(ctx, tx, id, expiresAt)
+	}
+	return nil
+}
+func (m *mockSessionRepo) Revoke(ctx context.Context, tx *sql.Tx, id string) error {
+	if m.revokeFn != nil {
+		return m.revokeFn(ctx, tx, id)
+	}
+	return nil
+}
+func (m *mockSessionRepo) RevokeByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+	if m.revokeByAuthUserFn != nil {
+		return m.revokeByAuthUserFn(ctx, tx, authUserID)
+	}
+	return nil
+}
+func (m *mockSessionRepo) RevokeOthersByAuthUser(ctx context.Context, tx *sql.Tx, authUserID uint64, keepID string) error {
+	if m.revokeOthersFn != nil {
+		return m.revokeOthersFn(ctx, tx, authUserID, keepID)
+	}
+	return nil
+}
+func (m *mockSessionRepo) RevokeOthersByStaffUser(ctx context.Context, tx *sql.Tx, staffUserID uint64, keepID string) error {
+	if m.revokeOthersStaff != nil {
+		return m.revokeOthersStaff(ctx, tx, staffUserID, keepID)
+	}
+	return nil
+}
+
+func TestSessionRevoke_RevokesSessionAndRefreshFamily(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var revokedSession, revokedFamily string
+	sessionRepo := &mockSessionRepo{
+		findByIDFn: func(ctx context.Context, id string) (*entity.Session, error) {
+			return &entity.Session{ID: id, AuthUserID: 5}, nil
+		},
+		revokeFn: func(ctx context.Context, tx *sql.Tx, id string) error {
+			revokedSession = id
+			return nil
+		},
+	}
+	refreshRepo := &mockRefreshTokenRepo{
+		revokeFamilyFn: func(ctx context.Context, tx *sql.Tx, familyID string) error {
+			revokedFamily = familyID
+			return nil
+		},
+	}
+
+	u := NewSessionUsecase(db, sessionRepo, refreshRepo)
+	err = u.Revoke(context.Background(), 5, "sess-1")
+	require.NoError(t, err)
+	require.Equal(t, "sess-1", revokedSession)
+	require.Equal(t, "sess-1", revokedFamily)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRevoke_OtherUsersSession(t *testing.T) {
+	sessionRepo := &mockSessionRepo{
+		findByIDFn: func(ctx context.Context, id string) (*entity.Session, error) {
+			return &entity.Session{ID: id, AuthUserID: 9}, nil
+		},
+	}
+
+	u := NewSessionUsecase(nil, sessionRepo, &mockRefreshTokenRepo{})
+	err := u.Revoke(context.Background(), 5, "sess-1")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSessionRevokeAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var sessionsFor, tokensFor uint64
+	sessionRepo := &mockSessionRepo{
+		revokeByAuthUserFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			sessionsFor = authUserID
+			return nil
+		},
+	}
+	refreshRepo := &mockRefreshTokenRepo{
+		revokeFn: func(ctx context.Context, tx *sql.Tx, authUserID uint64) error {
+			tokensFor = authUserID
+			return nil
+		},
+	}
+
+	u := NewSessionUsecase(db, sessionRepo, refreshRepo)
+	require.NoError(t, u.RevokeAll(context.Background(), 5))
+	require.Equal(t, uint64(5), sessionsFor)
+	require.Equal(t, uint64(5), tokensFor)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/utils"
+)
+
+type CreateStaffRequest struct {
+	Email    string      `json:"email" binding:"required,email"`
+	FullName string      `json:"full_name" binding:"required"`
+	Password string      `json:"password" binding:"required"`
+	Role     entity.Role `json:"role" binding:"required"`
+}
+
+var ErrInvalidStaffRole = errors.New("invalid staff role")
+var ErrAdminAlreadyExists = errors.New("an admin account already exists")
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type StaffUsecase struct {
+	db          *sql.DB
+	staffRepo   repository.StaffRepository
+	sessionRepo repository.SessionRepository
+	tokens      *utils.TokenManager
+	sessionTTL  time.Duration
+	guard       *LoginGuard
+	policy      utils.PasswordPolicy
+	mfa         *MFAUsecase
+}
+
+func NewStaffUsecase(db *sql.DB, st repository.StaffRepository, s repository.SessionRepository, tokens *utils.TokenManager, sessionTTL time.Duration, guard *LoginGuard, policy utils.PasswordPolicy, mfa *MFAUsecase) *StaffUsecase {
+	return &StaffUsecase{db, st, s, tokens, sessionTTL, guard, policy, mfa}
+}
+
+func (u *StaffUsecase) Create(ctx context.Context, req CreateStaffRequest) (uint64, error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := u.create(ctx, tx, req)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// BootstrapAdmin creates the first admin account. It refuses to run once an
+// admin exists so it cannot be used to mint extra admins later.
+func (u *StaffUsecase) BootstrapAdmin(ctx context.Context, req CreateStaffRequest) (uint64, error) {
+	req.Role = entity.RoleAdmin
+
+	tx, err := u.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := u.staffRepo.CountByRole(ctx, tx, entity.RoleAdmin)
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		return 0, ErrAdminAlreadyExists
+	}
+
+	id, err := u.create(ctx, tx, req)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (u *StaffUsecase) create(ctx context.Context, tx *sql.Tx, req CreateStaffRequest) (uint64, error) {
+	if !req.Role.IsStaff() {
+		return 0, ErrInvalidStaffRole
+	}
+	if err := u.policy.Validate(req.Password); err != nil {
+		return 0, err
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	return u.staffRepo.Create(ctx, tx, &entity.StaffUser{
+		Email:    req.Email,
+		Password: hash,
+		FullName: req.FullName,
+		Role:     req.Role,
+		Active:   true,
+	})
+}
+
+// Login checks a staff user's password. TOTP is mandatory for staff, so the
+// result is always an MFA token: for LoginMFA if the user has enrolled, or
+// for EnrollMFA/ConfirmMFA if they have not.
+func (u *StaffUsecase) Login(ctx context.Context, req LoginRequest) (*LoginResult, error) {
+	if err := u.guard.Allow(utils.SubjectStaff, req.Email, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	staff, err := u.staffRepo.FindByEmail(ctx, req.Email)
+	if err == sql.ErrNoRows {
+		u.guard.Fail(utils.SubjectStaff, req.Email, req.IPAddress)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ComparePassword(staff.Password, req.Password); err != nil || !staff.Active {
+		u.guard.Fail(utils.SubjectStaff, req.Email, req.IPAddress)
+		return nil, ErrInvalidCredentials
+	}
+
+	subject := StaffMFASubject(staff)
+	enabled, err := u.mfa.Enabled(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	res, err := u.mfa.Challenge(subject, 0)
+	if err != nil {
+		return nil, err
+	}
+	res.MFARequired = enabled
+	res.MFAEnrollmentRequired = !enabled
+	return res, nil
+}
+
+// EnrollMFA starts TOTP enrollment for a staff user who has passed the
+// password step but has no authenticator yet.
+func (u *StaffUsecase) EnrollMFA(ctx context.Context, mfaToken string) (*MFAEnrollment, error) {
+	staff, err := u.challengedStaff(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return u.mfa.Enroll(ctx, StaffMFASubject(staff))
+}
+
+// ConfirmMFA finishes enrollment and logs the staff user in, returning their
+// recovery codes alongside the access token.
+func (u *StaffUsecase) ConfirmMFA(ctx context.Context, req MFALoginRequest) (*LoginResult, error) {
+	staff, err := u.challengedStaff(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.guard.Allow(utils.SubjectStaff, staff.Email, req.IPAddress); err != nil {
+		return nil, err
+	}
+	codes, err := u.mfa.Confirm(ctx, StaffMFASubject(staff), req.Code)
+	if err != nil {
+		if err == ErrInvalidMFACode {
+			u.guard.Fail(utils.SubjectStaff, staff.Email, req.IPAddress)
+		}
+		return nil, err
+	}
+	u.guard.Succeed(utils.SubjectStaff, staff.Email)
+
+	res, err := u.startSession(ctx, staff, req.UserAgent, req.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	res.RecoveryCodes = codes
+	return res, nil
+}
+
+// LoginMFA completes a staff login with a TOTP or recovery code.
+func (u *StaffUsecase) LoginMFA(ctx context.Context, req MFALoginRequest) (*LoginResult, error) {
+	staff, err := u.challengedStaff(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.guard.Allow(utils.SubjectStaff, staff.Email, req.IPAddress); err != nil {
+		return nil, err
+	}
+	if err := u.mfa.Verify(ctx, StaffMFASubject(staff), req.Code); err != nil {
+		if err == ErrInvalidMFACode {
+			u.guard.Fail(utils.SubjectStaff, staff.Email, req.IPAddress)
+		}
+		return nil, err
+	}
+	u.guard.Succeed(utils.SubjectStaff, staff.Email)
+
+	return u.startSession(ctx, staff, req.UserAgent, req.IPAddress)
+}
+
+func (u *StaffUsecase) challengedStaff(ctx context.Context, mfaToken string) (*entity.StaffUser, error) {
+	id, err := u.mfa.ParseChallenge(mfaToken, utils.SubjectStaff)
+	if err != nil {
+		return nil, err
+	}
+	staff, err := u.staffRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !staff.Active {
+		return nil, ErrInvalidCredentials
+	}
+	return staff, nil
+}
+
+// startSession issues a staff access token. Staff sessions are not
+// refreshable; they last one working session.
+func (u *StaffUsecase) startSession(ctx context.Context, staff *entity.StaffUser, userAgent, ip string) (*LoginResult, error) {
+	sessionID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = u.sessionRepo.Create(ctx, tx, &entity.Session{
+		ID:          sessionID,
+		StaffUserID: staff.ID,
+		UserAgent:   truncate(userAgent, 255),
+		IPAddress:   ip,
+		ExpiresAt:   time.Now().UTC().Add(u.sessionTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	access, claims, err := u.tokens.Issue(utils.TokenClaims{
+		Subject:     strconv.FormatUint(staff.ID, 10),
+		SubjectType: utils.SubjectStaff,
+		SessionID:   sessionID,
+		Role:        string(staff.Role),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &LoginResult{TokenPair: &TokenPair{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   claims.ExpiresAt - claims.IssuedAt,
+	}}, nil
+}
@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"multifinance-core/internal/domain/entity"
+	"multifinance-core/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStaffRepo struct {
+	createFn      func(ctx context.Context, tx *sql.Tx, s *entity.StaffUser) (uint64, error)
+	findByEmailFn func(ctx context.Context, email string) (*entity.StaffUser, error)
+	findByIDFn    func(ctx context.Context, id uint64) (*entity.StaffUser, error)
+	countFn       func(ctx context.Context, tx *sql.Tx, role entity.Role) (int, error)
+	updatePwdFn   func(ctx context.Context, tx *sql.Tx, id uint64, hash string) error
+}
+
+func (m *mockStaffRepo) Create(ctx context.Context, tx *sql.Tx, s *entity.StaffUser) (uint64, error) {
+	if m.createFn != nil {
+		return m.createFn(ctx, tx, s)
+	}
+	return 0, nil
+}
+func (m *mockStaffRepo) FindByEmail(ctx context.Context, email string) (*entity.StaffUser, error) {
+	if m.findByEmailFn != nil {
+		return m.findByEmailFn(ctx, email)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockStaffRepo) FindByID(ctx context.Context, id uint64) (*entity.StaffUser, error) {
+	if m.findByIDFn != nil {
+		return m.findByIDFn(ctx, id)
+	}
+	return nil, sql.ErrNoRows
+}
+func (m *mockStaffRepo) CountByRole(ctx context.Context, tx *sql.Tx, role entity.Role) (int, error) {
+	if m.countFn != nil {
+		return m.countFn(ctx, tx, role)
+	}
+	return 0, nil
+}
+func (m *mockStaffRepo) UpdatePassword(ctx context.Context, tx *sql.Tx, id uint64, hash string) error {
+	if m.updatePwdFn != nil {
+		return m.updatePwdFn(ctx, tx, id, hash)
+	}
+	return nil
+}
+
+func TestBootstrapAdmin_CreatesFirstAdmin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	repo := &mockStaffRepo{
+		createFn: func(ctx context.Context, tx *sql.Tx, s *entity.StaffUser) (uint64, error) {
+			require.Equal(t, entity.RoleAdmin, s.Role)
+			require.NotEqual(t, "S3cret-pass", s.Password)
+			require.True(t, s.Active)
+			return 1, nil
+		},
+	}
+
+	u := NewStaffUsecase(db, repo, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil)
+	id, err := u.BootstrapAdmin(context.Background(), CreateStaffRequest{Email: "admin@multifinance.id", FullName: "Admin", Password: "S3cret-pass"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBootstrapAdmin_RefusesWhenAdminExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	repo := &mockStaffRepo{
+		countFn: func(ctx context.Context, tx *sql.Tx, role entity.Role) (int, error) {
+			return 1, nil
+		},
+	}
+
+	u := NewStaffUsecase(db, repo, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil)
+	_, err = u.BootstrapAdmin(context.Background(), CreateStaffRequest{Email: "x@multifinance.id", FullName: "X", Password: "p"})
+	require.ErrorIs(t, err, ErrAdminAlreadyExists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateStaff_RejectsConsumerRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	u := NewStaffUsecase(db, &mockStaffRepo{}, nil, nil, 0, nil, utils.DefaultPasswordPolicy, nil)
+	_, err = u.Create(context.Background(), CreateStaffRequest{Email: "x@multifinance.id", FullName: "X", Password: "p", Role: entity.RoleConsumer})
+	require.ErrorIs(t, err, ErrInvalidStaffRole)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStaffLogin_RequiresMFAEnrollment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	hash, err := utils.HashPassword("S3cret-pass")
+	require.NoError(t, err)
+
+	staff := &entity.StaffUser{ID: 3, Email: "op@multifinance.id", Password: hash, Role: entity.RoleOperator, Active: true}
+	repo := &mockStaffRepo{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.StaffUser, error) { return staff, nil },
+		findByIDFn:    func(ctx context.Context, id uint64) (*entity.StaffUser, error) { return staff, nil },
+	}
+	var session *entity.Session
+	sessionRepo := &mockSessionRepo{
+		createFn: func(ctx context.Context, tx *sql.Tx, s *entity.Session) error {
+			session = s
+			return nil
+		},
+	}
+	tokens := newTestTokenManager()
+	mfaRepo := &mockMFARepo{}
+
+	u := NewStaffUsecase(db, repo, sessionRepo, tokens, 8*time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, newTestMFA(mfaRepo))
+	res, err := u.Login(context.Background(), LoginRequest{Email: "op@multifinance.id", Password: "S3cret-pass"})
+	require.NoError(t, err)
+	require.True(t, res.MFAEnrollmentRequired)
+	require.Nil(t, res.TokenPair)
+
+	_, err = u.LoginMFA(context.Background(), MFALoginRequest{MFAToken: res.MFAToken, Code: "000000"})
+	require.Equal(t, ErrMFANotEnrolled, err)
+
+	enrollment, err := u.EnrollMFA(context.Background(), res.MFAToken)
+	require.NoError(t, err)
+	code, err := utils.TOTPCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+
+	done, err := u.ConfirmMFA(context.Background(), MFALoginRequest{MFAToken: res.MFAToken, Code: code})
+	require.NoError(t, err)
+	require.Len(t, done.RecoveryCodes, recoveryCodeCount)
+	require.Empty(t, done.RefreshToken)
+
+	claims, err := tokens.Parse(done.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, utils.SubjectStaff, claims.SubjectType)
+	require.Equal(t, "3", claims.Subject)
+	require.Equal(t, "operator", claims.Role)
+	require.Equal(t, uint64(0), claims.ConsumerID)
+	require.Equal(t, uint64(3), session.StaffUserID)
+	require.Equal(t, uint64(0), session.AuthUserID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStaffLogin_InactiveAccount(t *testing.T) {
+	hash, err := utils.HashPassword("S3cret-pass")
+	require.NoError(t, err)
+
+	repo := &mockStaffRepo{
+		findByEmailFn: func(ctx context.Context, email string) (*entity.StaffUser, error) {
+			return &entity.StaffUser{ID: 3, Email: email, Password: hash, Role: entity.RoleOperator, Active: false}, nil
+		},
+	}
+
+	u := NewStaffUsecase(nil, repo, &mockSessionRepo{}, newTestTokenManager(), time.Hour, newTestLoginGuard(), utils.DefaultPasswordPolicy, nil)
+	_, err = u.Login(context.Background(), LoginRequest{Email: "op@multifinance.id", Password: "S3cret-pass"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrUploadLimited = errors.New("too many document uploads")
+
+// UploadLimitedError is returned while a client IP has used up its uploads for
+// the current window.
+type UploadLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UploadLimitedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrUploadLimited, e.RetryAfter.Round(time.Second))
+}
+
+func (e *UploadLimitedError) Is(target error) bool {
+	return target == ErrUploadLimited
+}
+
+type uploadWindow struct {
+	count int
+	start time.Time
+}
+
+// UploadLimiter caps anonymous document uploads per client IP in fixed
+// windows. Like LoginGuard the counts live in memory, so the cap is per
+// process; windows that have ended are pruned as new uploads come in.
+type UploadLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	now       func() time.Time
+	entries   map[string]*uploadWindow
+	lastPrune time.Time
+}
+
+func NewUploadLimiter(limit int, window time.Duration, now func() time.Time) *UploadLimiter {
+	if now == nil {
+		now = time.Now
+	}
+	return &UploadLimiter{
+		limit:   limit,
+		window:  window,
+		now:     now,
+		entries: make(map[string]*uploadWindow),
+	}
+}
+
+// Allow counts an upload from ip and returns an *UploadLimitedError once the
+// IP has reached its limit for the window.
+func (l *UploadLimiter) Allow(ip string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) >= l.window {
+		l.prune(now)
+	}
+
+	e, ok := l.entries[ip]
+	if !ok || now.Sub(e.start) >= l.window {
+		e = &uploadWindow{start: now}
+		l.entries[ip] = e
+	}
+	if e.count >= l.limit {
+		return &UploadLimitedError{RetryAfter: e.start.Add(l.window).Sub(now)}
+	}
+	e.count++
+	return nil
+}
+
+// prune drops every IP whose window has ended.
+func (l *UploadLimiter) prune(now time.Time) {
+	for ip, e := range l.entries {
+		if now.Sub(e.start) >= l.window {
+			delete(l.entries, ip)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadLimiter_LimitsPerIPWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 2, 5, 10, 0, 0, 0, time.UTC)}
+	l := NewUploadLimiter(2, time.Hour, clock.Now)
+
+	require.NoError(t, l.Allow("10.0.0.1"))
+	require.NoError(t, l.Allow("10.0.0.1"))
+
+	clock.Advance(20 * time.Minute)
+	err := l.Allow("10.0.0.1")
+	require.ErrorIs(t, err, ErrUploadLimited)
+	var limited *UploadLimitedError
+	require.True(t, errors.As(err, &limited))
+	require.Equal(t, 40*time.Minute, limited.RetryAfter)
+
+	// other clients have their own count
+	require.NoError(t, l.Allow("10.0.0.2"))
+
+	clock.Advance(40 * time.Minute)
+	require.NoError(t, l.Allow("10.0.0.1"))
+}
+
+func TestUploadLimiter_PrunesEndedWindows(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 2, 5, 10, 0, 0, 0, time.UTC)}
+	l := NewUploadLimiter(2, time.Hour, clock.Now)
+
+	require.NoError(t, l.Allow("10.0.0.1"))
+	require.NoError(t, l.Allow("10.0.0.2"))
+	require.Len(t, l.entries, 2)
+
+	clock.Advance(2 * time.Hour)
+	require.NoError(t, l.Allow("10.0.0.3"))
+	require.Len(t, l.entries, 1)
+}
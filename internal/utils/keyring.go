@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+var ErrDecryptFailed = errors.New("cannot decrypt value")
+
+// dataKeySize is the AES-256 key length used for data keys.
+const dataKeySize = 32
+
+// Keyring implements envelope encryption: each record gets a random data key
+// that encrypts its fields, and the data key is stored wrapped by a versioned
+// key-encryption key. Rotating means adding a new version and re-wrapping;
+// older versions stay in the ring until no record uses them.
+//
+// The blind index key is separate and must never change, or indexed lookups
+// stop matching existing rows.
+type Keyring struct {
+	current  string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// NewKeyring derives a key-encryption key from each secret. current names the
+// version new data keys are wrapped with.
+func NewKeyring(current string, secrets map[string][]byte, indexSecret []byte) (*Keyring, error) {
+	keys := make(map[string][]byte, len(secrets))
+	for version, secret := range secrets {
+		if version == "" || strings.Contains(version, ":") {
+			return nil, errors.New("key version must be non-empty and must not contain ':'")
+		}
+		sum := sha256.Sum256(secret)
+		keys[version] = sum[:]
+	}
+	if _, ok := keys[current]; !ok {
+		return nil, ErrUnknownKeyVersion
+	}
+	if len(indexSecret) == 0 {
+		return nil, errors.New("blind index key is required")
+	}
+	return &Keyring{current: current, keys: keys, indexKey: indexSecret}, nil
+}
+
+// Version returns the key version new data keys are wrapped with.
+func (k *Keyring) Version() string {
+	return k.current
+}
+
+// NewDataKey returns a random data key and its wrapped form,
+// "<version>:<base64 nonce+ciphertext>", for storage next to the record.
+func (k *Keyring) NewDataKey() ([]byte, string, error) {
+	dek := make([]byte, dataKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, "", err
+	}
+	wrapped, err := seal(k.keys[k.current], []byte(k.current), dek)
+	if err != nil {
+		return nil, "", err
+	}
+	return dek, k.current + ":" + wrapped, nil
+}
+
+func (k *Keyring) UnwrapDataKey(wrapped string) ([]byte, error) {
+	version, sealed, ok := strings.Cut(wrapped, ":")
+	if !ok {
+		return nil, ErrDecryptFailed
+	}
+	kek, ok := k.keys[version]
+	if !ok {
+		return nil, ErrUnknownKeyVersion
+	}
+	return open(kek, []byte(version), sealed)
+}
+
+// BlindIndex returns a deterministic keyed hash of value, so equality lookups
+// work on a column whose contents are encrypted.
+func (k *Keyring) BlindIndex(value string) string {
+	return hex.EncodeToString(signHS256(k.indexKey, value))
+}
+
+// SealString encrypts plaintext with a data key. label binds the ciphertext to
+// its column so values cannot be swapped between fields.
+func SealString(dek []byte, label, plaintext string) (string, error) {
+	return seal(dek, []byte(label), []byte(plaintext))
+}
+
+func OpenString(dek []byte, label, sealed string) (string, error) {
+	b, err := open(dek, []byte(label), sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func seal(key, aad, plaintext []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, aad)), nil
+}
+
+func open(key, aad []byte, sealed string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(b) < gcm.NonceSize() {
+		return nil, ErrDecryptFailed
+	}
+	plaintext, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring_EnvelopeRoundTrip(t *testing.T) {
+	old, err := NewKeyring("k1", map[string][]byte{"k1": []byte("first-secret")}, []byte("index-secret"))
+	require.NoError(t, err)
+
+	dek, wrapped, err := old.NewDataKey()
+	require.NoError(t, err)
+	require.Contains(t, wrapped, "k1:")
+
+	sealed, err := SealString(dek, "nik", "3173010101900001")
+	require.NoError(t, err)
+	require.NotContains(t, sealed, "3173010101900001")
+
+	_, err = OpenString(dek, "legal_name", sealed)
+	require.ErrorIs(t, err, ErrDecryptFailed)
+
+	// After rotation the ring still opens data keys wrapped with k1.
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": []byte("first-secret"), "k2": []byte("second-secret")}, []byte("index-secret"))
+	require.NoError(t, err)
+	require.Equal(t, "k2", rotated.Version())
+
+	unwrapped, err := rotated.UnwrapDataKey(wrapped)
+	require.NoError(t, err)
+	plain, err := OpenString(unwrapped, "nik", sealed)
+	require.NoError(t, err)
+	require.Equal(t, "3173010101900001", plain)
+
+	_, newWrapped, err := rotated.NewDataKey()
+	require.NoError(t, err)
+	_, err = old.UnwrapDataKey(newWrapped)
+	require.ErrorIs(t, err, ErrUnknownKeyVersion)
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	k, err := NewKeyring("k1", map[string][]byte{"k1": []byte("first-secret")}, []byte("index-secret"))
+	require.NoError(t, err)
+	other, err := NewKeyring("k1", map[string][]byte{"k1": []byte("first-secret")}, []byte("another-index-secret"))
+	require.NoError(t, err)
+
+	require.Equal(t, k.BlindIndex("3173010101900001"), k.BlindIndex("3173010101900001"))
+	require.NotEqual(t, k.BlindIndex("3173010101900001"), k.BlindIndex("3173010101900002"))
+	require.NotEqual(t, k.BlindIndex("3173010101900001"), other.BlindIndex("3173010101900001"))
+}
+
+func TestNewKeyring_RequiresCurrentKey(t *testing.T) {
+	_, err := NewKeyring("k2", map[string][]byte{"k1": []byte("first-secret")}, []byte("index-secret"))
+	require.ErrorIs(t, err, ErrUnknownKeyVersion)
+
+	_, err = NewKeyring("k1", map[string][]byte{"k1": []byte("first-secret")}, nil)
+	require.Error(t, err)
+}
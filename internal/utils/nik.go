@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidNIK = errors.New("invalid NIK")
+
+// nikProvinces are the two-digit province codes used by Dukcapil.
+var nikProvinces = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+	"51": true, "52": true, "53": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true,
+	"71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+	"81": true, "82": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true,
+}
+
+// NIK is a parsed Indonesian population identity number: PPRRDD DDMMYY SSSS,
+// i.e. province, regency and district codes, birth date with 40 added to the
+// day for women, and a serial number.
+type NIK struct {
+	Province  string
+	Regency   string
+	District  string
+	BirthDate time.Time
+	Female    bool
+	Serial    string
+}
+
+// ParseNIK validates the layout of a NIK. The two-digit birth year is put in
+// the latest century that does not lie in the future relative to now.
+func ParseNIK(s string, now time.Time) (*NIK, error) {
+	if len(s) != 16 {
+		return nil, fmt.Errorf("%w: must be 16 digits", ErrInvalidNIK)
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return nil, fmt.Errorf("%w: must be 16 digits", ErrInvalidNIK)
+		}
+	}
+
+	n := &NIK{Province: s[0:2], Regency: s[2:4], District: s[4:6], Serial: s[12:16]}
+	if !nikProvinces[n.Province] {
+		return nil, fmt.Errorf("%w: unknown province code %s", ErrInvalidNIK, n.Province)
+	}
+	if n.Regency == "00" || n.District == "00" {
+		return nil, fmt.Errorf("%w: regency and district codes cannot be 00", ErrInvalidNIK)
+	}
+	if n.Serial == "0000" {
+		return nil, fmt.Errorf("%w: serial number cannot be 0000", ErrInvalidNIK)
+	}
+
+	day, _ := strconv.Atoi(s[6:8])
+	month, _ := strconv.Atoi(s[8:10])
+	yy, _ := strconv.Atoi(s[10:12])
+	if day > 40 {
+		n.Female = true
+		day -= 40
+	}
+
+	year := now.Year()/100*100 + yy
+	if year > now.Year() {
+		year -= 100
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if day < 1 || month < 1 || month > 12 || date.Day() != day {
+		return nil, fmt.Errorf("%w: invalid birth date", ErrInvalidNIK)
+	}
+	n.BirthDate = date
+	return n, nil
+}
+
+// MatchesBirthDate reports whether the encoded birth date agrees with t. The
+// century is not encoded, so only the last two digits of the year count.
+func (n *NIK) MatchesBirthDate(t time.Time) bool {
+	return n.BirthDate.Day() == t.Day() && n.BirthDate.Month() == t.Month() && n.BirthDate.Year()%100 == t.Year()%100
+}
+
+// ParseBirthDate accepts a date as YYYY-MM-DD or an RFC 3339 timestamp.
+func ParseBirthDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNIK(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	n, err := ParseNIK("3173010101900001", now)
+	require.NoError(t, err)
+	require.Equal(t, "31", n.Province)
+	require.Equal(t, "73", n.Regency)
+	require.Equal(t, "01", n.District)
+	require.Equal(t, "0001", n.Serial)
+	require.False(t, n.Female)
+	require.Equal(t, time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), n.BirthDate)
+
+	n, err = ParseNIK("3273015708050002", now)
+	require.NoError(t, err)
+	require.True(t, n.Female)
+	require.Equal(t, time.Date(2005, 8, 17, 0, 0, 0, 0, time.UTC), n.BirthDate)
+	require.True(t, n.MatchesBirthDate(time.Date(2005, 8, 17, 0, 0, 0, 0, time.UTC)))
+	require.False(t, n.MatchesBirthDate(time.Date(2005, 8, 18, 0, 0, 0, 0, time.UTC)))
+
+	for _, bad := range []string{
+		"",
+		"317301010190000",   // 15 digits
+		"31730101019000011", // 17 digits
+		"3173O10101900001",  // letter O
+		"9973010101900001",  // unknown province
+		"3100010101900001",  // regency 00
+		"3173003101900001",  // district 00
+		"3173013102900001",  // 31 February
+		"3173017201900001",  // day 32 for a woman
+		"3173010013900001",  // month 13
+		"3173010101900000",  // serial 0000
+	} {
+		_, err := ParseNIK(bad, now)
+		require.ErrorIs(t, err, ErrInvalidNIK, bad)
+	}
+}
+
+func TestParseBirthDate(t *testing.T) {
+	d, err := ParseBirthDate("1990-01-01")
+	require.NoError(t, err)
+	require.Equal(t, 1990, d.Year())
+
+	d, err = ParseBirthDate("1990-01-01T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, time.January, d.Month())
+
+	_, err = ParseBirthDate("01/01/1990")
+	require.Error(t, err)
+}
@@ -1,6 +1,83 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// maxPasswordLength is where bcrypt stops reading input.
+const maxPasswordLength = 72
+
+// commonPasswords are rejected regardless of the configured rules.
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"12345678": true, "123456789": true, "1234567890": true, "qwerty123": true,
+	"iloveyou": true, "admin123": true, "welcome1": true, "indonesia": true,
+}
+
+// PasswordPolicy holds the strength rules applied whenever a password is set.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy is used when nothing else is configured.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    8,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+}
+
+// Validate returns an error wrapping ErrWeakPassword that names the first
+// rule the password breaks.
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrWeakPassword, maxPasswordLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !upper:
+		return fmt.Errorf("%w: must contain an uppercase letter", ErrWeakPassword)
+	case p.RequireLower && !lower:
+		return fmt.Errorf("%w: must contain a lowercase letter", ErrWeakPassword)
+	case p.RequireDigit && !digit:
+		return fmt.Errorf("%w: must contain a digit", ErrWeakPassword)
+	case p.RequireSymbol && !symbol:
+		return fmt.Errorf("%w: must contain a symbol", ErrWeakPassword)
+	}
+
+	if commonPasswords[strings.ToLower(password)] {
+		return fmt.Errorf("%w: password is too common", ErrWeakPassword)
+	}
+	return nil
+}
 
 func HashPassword(p string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(p), bcrypt.DefaultCost)
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -32,3 +33,17 @@ func TestHashEmptyPassword(t *testing.T) {
 	err = ComparePassword(hash, plain)
 	require.NoError(t, err)
 }
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	p := DefaultPasswordPolicy
+
+	require.NoError(t, p.Validate("Secret123"))
+
+	for _, weak := range []string{"a", "Sec1", "secret123", "SECRET123", "SecretPass", "Password1", strings.Repeat("Aa1", 25)} {
+		require.ErrorIs(t, p.Validate(weak), ErrWeakPassword, weak)
+	}
+
+	p.RequireSymbol = true
+	require.ErrorIs(t, p.Validate("Secret123"), ErrWeakPassword)
+	require.NoError(t, p.Validate("Secret123!"))
+}
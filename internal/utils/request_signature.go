@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// DeriveAPISecret computes the signing secret of an API key from the server's
+// master key, so the secret never has to be stored.
+func DeriveAPISecret(masterKey []byte, keyID string) string {
+	return hex.EncodeToString(signHS256(masterKey, keyID))
+}
+
+// CanonicalRequest is the string a signed request covers: the method, the
+// request URI (path and query), the unix timestamp and the hex SHA-256 of the
+// body, joined by newlines.
+func CanonicalRequest(method, uri, timestamp string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{strings.ToUpper(method), uri, timestamp, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// SignRequest returns the hex HMAC-SHA256 of the canonical request.
+func SignRequest(secret, method, uri, timestamp string, body []byte) string {
+	return hex.EncodeToString(signHS256([]byte(secret), CanonicalRequest(method, uri, timestamp, body)))
+}
+
+func VerifyRequestSignature(secret, method, uri, timestamp string, body []byte, signature string) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sig, signHS256([]byte(secret), CanonicalRequest(method, uri, timestamp, body)))
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSignature(t *testing.T) {
+	secret := DeriveAPISecret([]byte("master-key"), "mk_1")
+	require.Len(t, secret, 64)
+	require.Equal(t, secret, DeriveAPISecret([]byte("master-key"), "mk_1"))
+	require.NotEqual(t, secret, DeriveAPISecret([]byte("master-key"), "mk_2"))
+
+	body := []byte(`{"asset_id":1}`)
+	sig := SignRequest(secret, "post", "/api/merchant/purchases", "1700000000", body)
+	require.True(t, VerifyRequestSignature(secret, "POST", "/api/merchant/purchases", "1700000000", body, sig))
+
+	require.False(t, VerifyRequestSignature(secret, "GET", "/api/merchant/purchases", "1700000000", body, sig))
+	require.False(t, VerifyRequestSignature(secret, "POST", "/api/merchant/assets", "1700000000", body, sig))
+	require.False(t, VerifyRequestSignature(secret, "POST", "/api/merchant/purchases", "1700000001", body, sig))
+	require.False(t, VerifyRequestSignature(secret, "POST", "/api/merchant/purchases", "1700000000", []byte(`{"asset_id":2}`), sig))
+	require.False(t, VerifyRequestSignature("other", "POST", "/api/merchant/purchases", "1700000000", body, sig))
+	require.False(t, VerifyRequestSignature(secret, "POST", "/api/merchant/purchases", "1700000000", body, "not-hex"))
+}
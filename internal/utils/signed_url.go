@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"strconv"
+)
+
+// SignURL returns the hex HMAC-SHA256 of a URL path and its unix expiry, so a
+// link can be handed out without a session and stops working once it expires.
+func SignURL(key []byte, path string, expires int64) string {
+	return hex.EncodeToString(signHS256(key, path+"\n"+strconv.FormatInt(expires, 10)))
+}
+
+func VerifyURLSignature(key []byte, path string, expires int64, signature string) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sig, signHS256(key, path+"\n"+strconv.FormatInt(expires, 10)))
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignURL(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	sig := SignURL(key, "/api/documents/doc_1/content", 1700000000)
+
+	require.True(t, VerifyURLSignature(key, "/api/documents/doc_1/content", 1700000000, sig))
+	require.False(t, VerifyURLSignature(key, "/api/documents/doc_2/content", 1700000000, sig))
+	require.False(t, VerifyURLSignature(key, "/api/documents/doc_1/content", 1700000001, sig))
+	require.False(t, VerifyURLSignature([]byte("another-key"), "/api/documents/doc_1/content", 1700000000, sig))
+	require.False(t, VerifyURLSignature(key, "/api/documents/doc_1/content", 1700000000, "not-hex"))
+}
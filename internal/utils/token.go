@@ -1,7 +1,14 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -9,17 +16,181 @@ import (
 var ErrInvalidToken = errors.New("invalid token")
 var ErrExpiredToken = errors.New("expired token")
 
-func ParseToken(token string, ttl time.Duration) (string, time.Time, error) {
-	parts := strings.SplitN(token, ":", 2)
-	if len(parts) != 2 {
-		return "", time.Time{}, ErrInvalidToken
+const tokenAlgorithm = "HS256"
+
+// Subject types distinguish consumer tokens from back-office staff tokens.
+// The MFA variants mark short-lived challenge tokens that only prove the
+// password step of a login; they are never accepted as access tokens.
+const (
+	SubjectConsumer    = "consumer"
+	SubjectStaff       = "staff"
+	SubjectConsumerMFA = "consumer_mfa"
+	SubjectStaffMFA    = "staff_mfa"
+)
+
+// TokenClaims is the payload carried by a signed access token.
+type TokenClaims struct {
+	Issuer      string `json:"iss"`
+	Subject     string `json:"sub"`
+	SubjectType string `json:"sub_type"`
+	ConsumerID  uint64 `json:"cid"`
+	SessionID   string `json:"sid"`
+	Role        string `json:"role"`
+	ID          string `json:"jti"`
+	IssuedAt    int64  `json:"iat"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+// SubjectID returns the auth user or staff user ID encoded in the subject
+// claim, depending on SubjectType.
+func (c *TokenClaims) SubjectID() (uint64, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidToken
 	}
-	ts, err := time.Parse(time.RFC3339, parts[1])
+	return id, nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// TokenManager issues and verifies HMAC-SHA256 signed JWTs. New tokens are
+// signed with the active key; any key in the ring is accepted on verification
+// so secrets can be rotated without logging everybody out.
+type TokenManager struct {
+	issuer    string
+	activeKID string
+	keys      map[string][]byte
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+func NewTokenManager(issuer, activeKID string, keys map[string][]byte, ttl time.Duration) *TokenManager {
+	return &TokenManager{
+		issuer:    issuer,
+		activeKID: activeKID,
+		keys:      keys,
+		ttl:       ttl,
+		now:       time.Now,
+	}
+}
+
+// Issue signs the given subject claims, filling in the issuer, token ID and
+// validity window.
+func (m *TokenManager) Issue(subject TokenClaims) (string, *TokenClaims, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := m.now().UTC()
+	claims := subject
+	claims.Issuer = m.issuer
+	claims.ID = jti
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(m.ttl).Unix()
+
+	token, err := m.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
+}
+
+func (m *TokenManager) Parse(token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if header.Alg != tokenAlgorithm {
+		return nil, ErrInvalidToken
+	}
+	key, ok := m.keys[header.Kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
 	if err != nil {
-		return "", time.Time{}, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
-	if time.Since(ts) > ttl {
-		return "", time.Time{}, ErrExpiredToken
+	if !hmac.Equal(sig, signHS256(key, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
 	}
-	return parts[0], ts, nil
+
+	var claims TokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != m.issuer || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if m.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return &claims, nil
+}
+
+func (m *TokenManager) sign(claims any) (string, error) {
+	key, ok := m.keys[m.activeKID]
+	if !ok {
+		return "", errors.New("token signing key not configured")
+	}
+
+	header, err := encodeSegment(tokenHeader{Alg: tokenAlgorithm, Typ: "JWT", Kid: m.activeKID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + payload
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signHS256(key, signingInput)), nil
+}
+
+// RandomToken returns n random bytes encoded as hex.
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func signHS256(key []byte, input string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// HashToken returns the hex SHA-256 digest used to store opaque tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
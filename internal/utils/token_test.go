@@ -1,42 +1,125 @@
 package utils
 
 import (
+	"encoding/base64"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 )
 
-func TestParseToken_Valid(t *testing.T) {
-	user := "alice"
-	ts := time.Now().UTC()
-	token := user + ":" + ts.Format(time.RFC3339)
+func newTestTokenManager() *TokenManager {
+	return NewTokenManager("multifinance-core", "k1", map[string][]byte{
+		"k1": []byte("test-secret-1"),
+		"k0": []byte("test-secret-0"),
+	}, time.Hour)
+}
+
+var testSubject = TokenClaims{Subject: "1", ConsumerID: 1, SessionID: "sess"}
+
+func TestTokenManager_IssueAndParse(t *testing.T) {
+	m := newTestTokenManager()
+
+	token, issued, err := m.Issue(TokenClaims{Subject: "7", ConsumerID: 17, SessionID: "sess", Role: "consumer"})
+	require.NoError(t, err)
+	require.Len(t, strings.Split(token, "."), 3)
+
+	claims, err := m.Parse(token)
+	require.NoError(t, err)
+	require.Equal(t, "multifinance-core", claims.Issuer)
+	require.Equal(t, "7", claims.Subject)
+	require.Equal(t, uint64(17), claims.ConsumerID)
+	require.Equal(t, "sess", claims.SessionID)
+	require.Equal(t, "consumer", claims.Role)
+	require.Equal(t, issued.ID, claims.ID)
+
+	id, err := claims.SubjectID()
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), id)
+}
+
+func TestTokenManager_RotatedKeyStillAccepted(t *testing.T) {
+	old := NewTokenManager("multifinance-core", "k0", map[string][]byte{
+		"k0": []byte("test-secret-0"),
+	}, time.Hour)
+	token, _, err := old.Issue(testSubject)
+	require.NoError(t, err)
+
+	_, err = newTestTokenManager().Parse(token)
+	require.NoError(t, err)
+}
+
+func TestTokenManager_InvalidFormat(t *testing.T) {
+	_, err := newTestTokenManager().Parse("budi@mail.com:2026-02-05T10:15:58Z")
+	require.Equal(t, ErrInvalidToken, err)
+}
 
-	gotUser, gotTs, err := ParseToken(token, time.Hour)
+func TestTokenManager_BadSignature(t *testing.T) {
+	m := newTestTokenManager()
+	token, _, err := m.Issue(testSubject)
 	require.NoError(t, err)
-	require.Equal(t, user, gotUser)
-	require.WithinDuration(t, ts, gotTs, time.Second)
+
+	forged := NewTokenManager("multifinance-core", "k1", map[string][]byte{
+		"k1": []byte("attacker-secret"),
+	}, time.Hour)
+	forgedToken, _, err := forged.Issue(testSubject)
+	require.NoError(t, err)
+
+	_, err = m.Parse(forgedToken)
+	require.Equal(t, ErrInvalidToken, err)
+
+	parts := strings.Split(token, ".")
+	payload, _ := encodeSegment(map[string]any{"iss": "multifinance-core", "sub": "2", "cid": 2, "exp": time.Now().Add(time.Hour).Unix()})
+	_, err = m.Parse(parts[0] + "." + payload + "." + parts[2])
+	require.Equal(t, ErrInvalidToken, err)
+}
+
+func TestTokenManager_WrongAlgorithm(t *testing.T) {
+	m := newTestTokenManager()
+	token, _, err := m.Issue(testSubject)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+
+	none, _ := encodeSegment(tokenHeader{Alg: "none", Typ: "JWT", Kid: "k1"})
+	_, err = m.Parse(none + "." + parts[1] + ".")
+	require.Equal(t, ErrInvalidToken, err)
+
+	hs512, _ := encodeSegment(tokenHeader{Alg: "HS512", Typ: "JWT", Kid: "k1"})
+	sig := base64.RawURLEncoding.EncodeToString(signHS256([]byte("test-secret-1"), hs512+"."+parts[1]))
+	_, err = m.Parse(hs512 + "." + parts[1] + "." + sig)
+	require.Equal(t, ErrInvalidToken, err)
 }
 
-func TestParseToken_InvalidFormat(t *testing.T) {
-	_, _, err := ParseToken("invalidtoken", time.Hour)
-	require.Error(t, err)
+func TestTokenManager_UnknownKeyID(t *testing.T) {
+	other := NewTokenManager("multifinance-core", "k9", map[string][]byte{
+		"k9": []byte("test-secret-1"),
+	}, time.Hour)
+	token, _, err := other.Issue(testSubject)
+	require.NoError(t, err)
+
+	_, err = newTestTokenManager().Parse(token)
 	require.Equal(t, ErrInvalidToken, err)
 }
 
-func TestParseToken_BadTimestamp(t *testing.T) {
-	token := "bob:badtime"
-	_, _, err := ParseToken(token, time.Hour)
-	require.Error(t, err)
+func TestTokenManager_WrongIssuer(t *testing.T) {
+	other := NewTokenManager("someone-else", "k1", map[string][]byte{
+		"k1": []byte("test-secret-1"),
+	}, time.Hour)
+	token, _, err := other.Issue(testSubject)
+	require.NoError(t, err)
+
+	_, err = newTestTokenManager().Parse(token)
 	require.Equal(t, ErrInvalidToken, err)
 }
 
-func TestParseToken_Expired(t *testing.T) {
-	user := "eve"
-	ts := time.Now().Add(-48 * time.Hour).UTC()
-	token := user + ":" + ts.Format(time.RFC3339)
+func TestTokenManager_Expired(t *testing.T) {
+	m := newTestTokenManager()
+	m.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	token, _, err := m.Issue(testSubject)
+	require.NoError(t, err)
 
-	_, _, err := ParseToken(token, 24*time.Hour)
-	require.Error(t, err)
+	m.now = time.Now
+	_, err = m.Parse(token)
 	require.Equal(t, ErrExpiredToken, err)
 }
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 appendix B.
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range cases {
+		code, err := TOTPCode(rfc6238Secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, want, code, unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	now := time.Date(2026, 2, 5, 10, 0, 15, 0, time.UTC)
+
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30, step)
+
+	_, ok = ValidateTOTP(secret, code, now.Add(30*time.Second))
+	require.True(t, ok, "one step of drift is tolerated")
+
+	_, ok = ValidateTOTP(secret, code, now.Add(90*time.Second))
+	require.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Multifinance", "budi@mail.com", "ABC")
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Multifinance:budi@mail.com?"))
+	require.Contains(t, uri, "secret=ABC")
+	require.Contains(t, uri, "issuer=Multifinance")
+}
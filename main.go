@@ -1,21 +1,41 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	nethttp "net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 
+	"multifinance-core/internal/config"
 	"multifinance-core/internal/infrastructure/http"
+	"multifinance-core/internal/infrastructure/storage"
+	"multifinance-core/internal/repository"
+	"multifinance-core/internal/usecase"
 )
 
+// shutdownTimeout bounds how long in-flight requests get to finish once a
+// shutdown signal arrives.
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("warning: .env not found, falling back to environment")
 	}
 
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
 	dsn := os.Getenv("DSN")
 	if dsn == "" {
 		log.Fatal("DSN environment variable is required")
@@ -31,15 +51,59 @@ func main() {
 		log.Fatalf("failed to ping db: %v", err)
 	}
 
-	router := http.NewRouter(db)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The sweepers release expired holds and delete unclaimed documents until
+	// shutdown. The hold sweeper only touches the hold and limit repositories,
+	// so the others are left nil. The recalculation worker moves limits to a
+	// newly published policy; the router shares it so Publish can wake it.
+	holds := usecase.NewLimitHoldUsecase(db, repository.NewLimitHoldRepo(db), repository.NewConsumerLimitRepo(db), nil, nil, nil, nil, nil, nil, cfg.Limit.HoldTTL)
+	documents := usecase.NewDocumentUsecase(db, repository.NewDocumentRepo(db), storage.NewLocalStore(cfg.Document.Dir),
+		cfg.Document.URLKey, cfg.Document.URLTTL, cfg.Document.MaxSize, cfg.Document.UnclaimedTTL)
+	recalc := usecase.NewLimitRecalcUsecase(db, repository.NewConsumerRepo(db, cfg.PII), repository.NewConsumerLimitRepo(db),
+		repository.NewLimitPolicyRepo(db), repository.NewLimitLedgerRepo(db))
+	var sweepers sync.WaitGroup
+	sweepers.Add(3)
+	go func() {
+		defer sweepers.Done()
+		holds.RunSweeper(ctx, cfg.Limit.HoldSweepInterval)
+	}()
+	go func() {
+		defer sweepers.Done()
+		documents.RunSweeper(ctx, cfg.Document.SweepInterval)
+	}()
+	go func() {
+		defer sweepers.Done()
+		recalc.RunWorker(ctx)
+	}()
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("starting server on :%s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("server exited: %v", err)
+	srv := &nethttp.Server{Addr: ":" + port, Handler: http.NewRouter(db, cfg, recalc)}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("starting server on :%s", port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		stop()
+		sweepers.Wait()
+		if !errors.Is(err, nethttp.ErrServerClosed) {
+			log.Fatalf("server exited: %v", err)
+		}
+	case <-ctx.Done():
+		log.Println("shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("server shutdown: %v", err)
+		}
+		sweepers.Wait()
 	}
 }
@@ -33,6 +33,427 @@ INSERT INTO `consumer_limits` (`id`, `consumer_id`, `tenor_month`, `max_limit`,
 (8, 18, 6, '24000000.00', '0.00', '2026-02-05 10:24:37', '2026-02-05 10:24:37');
 
 
+DROP TABLE IF EXISTS `refresh_tokens`;
+CREATE TABLE `refresh_tokens` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `auth_user_id` bigint unsigned NOT NULL,
+  `family_id` char(32) NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` timestamp NOT NULL,
+  `rotated_at` timestamp NULL DEFAULT NULL,
+  `revoked_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_token_hash` (`token_hash`),
+  KEY `idx_auth_user_id` (`auth_user_id`),
+  KEY `idx_family_id` (`family_id`),
+  CONSTRAINT `fk_refresh_token_auth_user` FOREIGN KEY (`auth_user_id`) REFERENCES `auth_users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `sessions`;
+CREATE TABLE `sessions` (
+  `id` char(32) NOT NULL,
+  `auth_user_id` bigint unsigned DEFAULT NULL,
+  `staff_user_id` bigint unsigned DEFAULT NULL,
+  `user_agent` varchar(255) NOT NULL DEFAULT '',
+  `ip_address` varchar(45) NOT NULL DEFAULT '',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `last_seen_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `expires_at` timestamp NOT NULL,
+  `revoked_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_auth_user_id` (`auth_user_id`),
+  KEY `idx_staff_user_id` (`staff_user_id`),
+  CONSTRAINT `fk_session_auth_user` FOREIGN KEY (`auth_user_id`) REFERENCES `auth_users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_session_staff_user` FOREIGN KEY (`staff_user_id`) REFERENCES `staff_users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `staff_users`;
+CREATE TABLE `staff_users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `email` varchar(255) NOT NULL,
+  `password` varchar(255) NOT NULL,
+  `full_name` varchar(255) NOT NULL,
+  `role` varchar(20) NOT NULL,
+  `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_staff_email` (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- auth_users only holds consumers; back-office accounts live here and the
+-- first admin is created with cmd/bootstrap-admin.
+
+
+DROP TABLE IF EXISTS `user_tokens`;
+CREATE TABLE `user_tokens` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `auth_user_id` bigint unsigned NOT NULL,
+  `purpose` varchar(32) NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` timestamp NOT NULL,
+  `used_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_purpose_token_hash` (`purpose`,`token_hash`),
+  KEY `idx_auth_user_purpose` (`auth_user_id`,`purpose`),
+  CONSTRAINT `fk_user_token_auth_user` FOREIGN KEY (`auth_user_id`) REFERENCES `auth_users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+ALTER TABLE `auth_users` ADD COLUMN `verified_at` timestamp NULL DEFAULT NULL;
+-- accounts created before email verification existed are treated as verified
+UPDATE `auth_users` SET `verified_at` = CURRENT_TIMESTAMP WHERE `verified_at` IS NULL;
+
+
+DROP TABLE IF EXISTS `mfa_credentials`;
+CREATE TABLE `mfa_credentials` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `subject_type` varchar(16) NOT NULL,
+  `subject_id` bigint unsigned NOT NULL,
+  `secret` varchar(64) NOT NULL,
+  `confirmed_at` timestamp NULL DEFAULT NULL,
+  `last_used_step` bigint NOT NULL DEFAULT '0',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_subject` (`subject_type`,`subject_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `mfa_recovery_codes`;
+CREATE TABLE `mfa_recovery_codes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `subject_type` varchar(16) NOT NULL,
+  `subject_id` bigint unsigned NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_subject` (`subject_type`,`subject_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `merchants`;
+CREATE TABLE `merchants` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `code` varchar(50) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_merchant_code` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `merchant_api_keys`;
+CREATE TABLE `merchant_api_keys` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `merchant_id` bigint unsigned NOT NULL,
+  `key_id` varchar(64) NOT NULL,
+  `secret_hash` char(64) NOT NULL,
+  `last_used_at` timestamp NULL DEFAULT NULL,
+  `revoked_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_merchant_key_id` (`key_id`),
+  KEY `idx_merchant_api_key_merchant` (`merchant_id`),
+  CONSTRAINT `fk_merchant_api_key_merchant` FOREIGN KEY (`merchant_id`) REFERENCES `merchants` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `merchant_request_signatures`;
+CREATE TABLE `merchant_request_signatures` (
+  `api_key_id` bigint unsigned NOT NULL,
+  `signature_hash` char(64) NOT NULL,
+  `expires_at` timestamp NOT NULL,
+  PRIMARY KEY (`api_key_id`,`signature_hash`),
+  KEY `idx_signature_expiry` (`api_key_id`,`expires_at`),
+  CONSTRAINT `fk_request_signature_api_key` FOREIGN KEY (`api_key_id`) REFERENCES `merchant_api_keys` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+ALTER TABLE `assets`
+  ADD COLUMN `merchant_id` bigint unsigned NULL DEFAULT NULL AFTER `seller`,
+  ADD KEY `idx_asset_merchant` (`merchant_id`),
+  ADD CONSTRAINT `fk_asset_merchant` FOREIGN KEY (`merchant_id`) REFERENCES `merchants` (`id`) ON DELETE SET NULL;
+
+
+DROP TABLE IF EXISTS `consumer_change_requests`;
+CREATE TABLE `consumer_change_requests` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_id` bigint unsigned NOT NULL,
+  `nik` varchar(32) DEFAULT NULL,
+  `legal_name` varchar(255) DEFAULT NULL,
+  `birth_place` varchar(255) DEFAULT NULL,
+  `birth_date` varchar(32) DEFAULT NULL,
+  `salary` decimal(15,2) DEFAULT NULL,
+  `ktp_document_id` varchar(64) DEFAULT NULL,
+  `selfie_document_id` varchar(64) DEFAULT NULL,
+  `status` varchar(16) NOT NULL DEFAULT 'PENDING',
+  `reviewed_by` bigint unsigned DEFAULT NULL,
+  `review_note` varchar(255) NOT NULL DEFAULT '',
+  `reviewed_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_change_request_consumer_status` (`consumer_id`,`status`),
+  CONSTRAINT `fk_change_request_consumer` FOREIGN KEY (`consumer_id`) REFERENCES `consumers` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_change_request_reviewer` FOREIGN KEY (`reviewed_by`) REFERENCES `staff_users` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+ALTER TABLE `consumers`
+  ADD COLUMN `kyc_status` varchar(32) NOT NULL DEFAULT 'PENDING_REVIEW',
+  ADD KEY `idx_consumer_kyc_status` (`kyc_status`);
+ALTER TABLE `consumer_limits` ADD COLUMN `active` tinyint(1) NOT NULL DEFAULT '0' AFTER `used_limit`;
+-- consumers registered before KYC review existed keep their limits
+UPDATE `consumers` SET `kyc_status` = 'APPROVED';
+UPDATE `consumer_limits` SET `active` = 1;
+
+DROP TABLE IF EXISTS `kyc_reviews`;
+CREATE TABLE `kyc_reviews` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_id` bigint unsigned NOT NULL,
+  `from_status` varchar(32) NOT NULL,
+  `to_status` varchar(32) NOT NULL,
+  `note` varchar(255) NOT NULL DEFAULT '',
+  `reviewed_by` bigint unsigned DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_kyc_review_consumer` (`consumer_id`),
+  CONSTRAINT `fk_kyc_review_consumer` FOREIGN KEY (`consumer_id`) REFERENCES `consumers` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_kyc_review_reviewer` FOREIGN KEY (`reviewed_by`) REFERENCES `staff_users` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `documents`;
+CREATE TABLE `documents` (
+  `id` varchar(64) NOT NULL,
+  `consumer_id` bigint unsigned DEFAULT NULL,
+  `kind` varchar(16) NOT NULL,
+  `sha256` char(64) NOT NULL,
+  `content_type` varchar(64) NOT NULL,
+  `size` bigint NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_document_consumer` (`consumer_id`,`created_at`),
+  CONSTRAINT `fk_document_consumer` FOREIGN KEY (`consumer_id`) REFERENCES `consumers` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- ktp_photo / selfie_photo keep the free-form values of consumers registered
+-- before document uploads existed; the application only reads the document IDs.
+ALTER TABLE `consumers`
+  ADD COLUMN `ktp_document_id` varchar(64) NOT NULL DEFAULT '',
+  ADD COLUMN `selfie_document_id` varchar(64) NOT NULL DEFAULT '';
+
+
+-- NIK, legal name, birth date and salary hold ciphertext. Existing rows keep an
+-- empty pii_key and are read as plaintext until cmd/reencrypt-pii runs.
+ALTER TABLE `consumers`
+  MODIFY COLUMN `nik` varchar(255) NOT NULL,
+  MODIFY COLUMN `legal_name` varchar(512) NOT NULL,
+  MODIFY COLUMN `birth_date` varchar(255) NOT NULL,
+  MODIFY COLUMN `salary` varchar(255) NOT NULL,
+  ADD COLUMN `nik_index` char(64) DEFAULT NULL,
+  ADD COLUMN `pii_key` varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN `key_version` varchar(32) NOT NULL DEFAULT '',
+  ADD UNIQUE KEY `uq_consumer_nik_index` (`nik_index`),
+  ADD KEY `idx_consumer_key_version` (`key_version`);
+
+-- pending change requests carry the same fields, sealed the same way
+ALTER TABLE `consumer_change_requests`
+  MODIFY COLUMN `nik` varchar(255) DEFAULT NULL,
+  MODIFY COLUMN `legal_name` varchar(512) DEFAULT NULL,
+  MODIFY COLUMN `birth_date` varchar(255) DEFAULT NULL,
+  MODIFY COLUMN `salary` varchar(255) DEFAULT NULL,
+  ADD COLUMN `pii_key` varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN `key_version` varchar(32) NOT NULL DEFAULT '',
+  ADD KEY `idx_change_request_key_version` (`key_version`);
+
+
+ALTER TABLE `consumers`
+  ADD COLUMN `status` varchar(16) NOT NULL DEFAULT 'ACTIVE',
+  ADD COLUMN `status_reason` varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN `status_changed_at` timestamp NULL DEFAULT NULL;
+
+DROP TABLE IF EXISTS `consumer_status_changes`;
+CREATE TABLE `consumer_status_changes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_id` bigint unsigned NOT NULL,
+  `from_status` varchar(16) NOT NULL,
+  `to_status` varchar(16) NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `changed_by` bigint unsigned NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_status_change_consumer` (`consumer_id`),
+  CONSTRAINT `fk_status_change_consumer` FOREIGN KEY (`consumer_id`) REFERENCES `consumers` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_status_change_staff` FOREIGN KEY (`changed_by`) REFERENCES `staff_users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+ALTER TABLE `consumers` ADD COLUMN `erased_at` timestamp NULL DEFAULT NULL;
+
+
+DROP TABLE IF EXISTS `limit_policies`;
+CREATE TABLE `limit_policies` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `version` int unsigned NOT NULL,
+  `rules` json NOT NULL,
+  `note` varchar(255) NOT NULL DEFAULT '',
+  `created_by` bigint unsigned DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `published_by` bigint unsigned DEFAULT NULL,
+  `published_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_limit_policy_version` (`version`),
+  KEY `idx_limit_policy_published` (`published_at`),
+  CONSTRAINT `fk_limit_policy_creator` FOREIGN KEY (`created_by`) REFERENCES `staff_users` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_limit_policy_publisher` FOREIGN KEY (`published_by`) REFERENCES `staff_users` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- version 1 is the formula registration used before policies existed:
+-- salary x 0.4 x tenor for tenors 1, 2, 3 and 6
+INSERT INTO `limit_policies` (`version`, `rules`, `note`, `published_at`) VALUES
+(1, '{"salary_bands":[{"min_salary":0,"ratio":0.4}],"tenors":[{"tenor":1,"multiplier":1,"floor":0,"cap":0},{"tenor":2,"multiplier":2,"floor":0,"cap":0},{"tenor":3,"multiplier":3,"floor":0,"cap":0},{"tenor":6,"multiplier":6,"floor":0,"cap":0}]}',
+  'salary x 0.4 x tenor', CURRENT_TIMESTAMP);
+
+ALTER TABLE `consumers` ADD COLUMN `risk_grade` varchar(1) NOT NULL DEFAULT '';
+-- limits set before policies existed keep a NULL policy_version
+ALTER TABLE `consumer_limits` ADD COLUMN `policy_version` int unsigned DEFAULT NULL AFTER `active`;
+
+
+
+DROP TABLE IF EXISTS `limit_ledger`;
+CREATE TABLE `limit_ledger` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_limit_id` bigint unsigned NOT NULL,
+  `consumer_id` bigint unsigned NOT NULL,
+  `tenor_month` tinyint unsigned NOT NULL,
+  `kind` varchar(16) NOT NULL,
+  `amount` decimal(15,2) NOT NULL,
+  `used_before` decimal(15,2) NOT NULL,
+  `used_after` decimal(15,2) NOT NULL,
+  `max_before` decimal(15,2) NOT NULL,
+  `max_after` decimal(15,2) NOT NULL,
+  `source_type` varchar(16) NOT NULL,
+  `source_id` varchar(64) NOT NULL DEFAULT '',
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_ledger_limit` (`consumer_limit_id`),
+  KEY `idx_ledger_consumer` (`consumer_id`),
+  CONSTRAINT `fk_ledger_limit` FOREIGN KEY (`consumer_limit_id`) REFERENCES `consumer_limits` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- limits used before the ledger existed open it with their current balance
+INSERT INTO `limit_ledger` (`consumer_limit_id`, `consumer_id`, `tenor_month`, `kind`, `amount`, `used_before`, `used_after`,
+  `max_before`, `max_after`, `source_type`, `source_id`, `reason`)
+SELECT `id`, `consumer_id`, `tenor_month`, 'ADJUSTMENT', `used_limit`, 0, `used_limit`, `max_limit`, `max_limit`, 'JOB', 'migration', 'opening balance'
+FROM `consumer_limits` WHERE `used_limit` <> 0;
+
+
+
+DROP TABLE IF EXISTS `limit_holds`;
+CREATE TABLE `limit_holds` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_id` bigint unsigned NOT NULL,
+  `consumer_limit_id` bigint unsigned NOT NULL,
+  `tenor_month` tinyint unsigned NOT NULL,
+  `asset_id` bigint unsigned NOT NULL,
+  `merchant_id` bigint unsigned DEFAULT NULL,
+  `amount` decimal(15,2) NOT NULL,
+  `status` varchar(16) NOT NULL DEFAULT 'HELD',
+  `transaction_id` bigint unsigned DEFAULT NULL,
+  `expires_at` timestamp NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_hold_consumer` (`consumer_id`),
+  KEY `idx_hold_status_expires` (`status`,`expires_at`),
+  CONSTRAINT `fk_hold_limit` FOREIGN KEY (`consumer_limit_id`) REFERENCES `consumer_limits` (`id`),
+  CONSTRAINT `fk_hold_merchant` FOREIGN KEY (`merchant_id`) REFERENCES `merchants` (`id`),
+  CONSTRAINT `fk_hold_transaction` FOREIGN KEY (`transaction_id`) REFERENCES `consumer_transactions` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- credit reserved by open holds; available = max_limit - used_limit - held_limit
+ALTER TABLE `consumer_limits` ADD COLUMN `held_limit` decimal(15,2) NOT NULL DEFAULT '0.00' AFTER `used_limit`;
+
+
+
+ALTER TABLE `consumer_transactions`
+  ADD COLUMN `amount_paid` bigint NOT NULL DEFAULT '0' AFTER `status`,
+  ADD COLUMN `principal_paid` bigint NOT NULL DEFAULT '0' AFTER `amount_paid`,
+  ADD COLUMN `closed_at` timestamp NULL DEFAULT NULL AFTER `principal_paid`;
+
+DROP TABLE IF EXISTS `repayments`;
+CREATE TABLE `repayments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `transaction_id` bigint unsigned NOT NULL,
+  `consumer_id` bigint unsigned NOT NULL,
+  `kind` varchar(16) NOT NULL,
+  `amount` bigint NOT NULL,
+  `principal` bigint NOT NULL,
+  `reference` varchar(64) NOT NULL DEFAULT '',
+  `recorded_by` bigint unsigned DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_repayment_transaction` (`transaction_id`),
+  KEY `idx_repayment_consumer` (`consumer_id`),
+  CONSTRAINT `fk_repayment_transaction` FOREIGN KEY (`transaction_id`) REFERENCES `consumer_transactions` (`id`),
+  CONSTRAINT `fk_repayment_staff` FOREIGN KEY (`recorded_by`) REFERENCES `staff_users` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+
+DROP TABLE IF EXISTS `limit_override_requests`;
+CREATE TABLE `limit_override_requests` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_limit_id` bigint unsigned NOT NULL,
+  `consumer_id` bigint unsigned NOT NULL,
+  `tenor_month` tinyint unsigned NOT NULL,
+  `current_max_limit` decimal(15,2) NOT NULL,
+  `new_max_limit` decimal(15,2) NOT NULL,
+  `justification` varchar(500) NOT NULL,
+  `status` varchar(16) NOT NULL DEFAULT 'PENDING',
+  `requested_by` bigint unsigned NOT NULL,
+  `reviewed_by` bigint unsigned DEFAULT NULL,
+  `review_note` varchar(255) NOT NULL DEFAULT '',
+  `reviewed_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_override_limit_status` (`consumer_limit_id`,`status`),
+  KEY `idx_override_consumer` (`consumer_id`),
+  KEY `idx_override_status` (`status`),
+  CONSTRAINT `fk_override_limit` FOREIGN KEY (`consumer_limit_id`) REFERENCES `consumer_limits` (`id`),
+  CONSTRAINT `fk_override_requester` FOREIGN KEY (`requested_by`) REFERENCES `staff_users` (`id`),
+  CONSTRAINT `fk_override_reviewer` FOREIGN KEY (`reviewed_by`) REFERENCES `staff_users` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- the approved override that set max_limit; recalculation skips these limits
+ALTER TABLE `consumer_limits` ADD COLUMN `override_request_id` bigint unsigned DEFAULT NULL AFTER `policy_version`;
+
+
+DROP TABLE IF EXISTS `purchase_authorizations`;
+CREATE TABLE `purchase_authorizations` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `consumer_id` bigint unsigned NOT NULL,
+  `merchant_id` bigint unsigned NOT NULL,
+  `asset_id` bigint unsigned NOT NULL,
+  `tenor_month` tinyint unsigned NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` timestamp NOT NULL,
+  `used_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_purchase_auth_token_hash` (`token_hash`),
+  KEY `idx_purchase_auth_consumer` (`consumer_id`),
+  CONSTRAINT `fk_purchase_auth_consumer` FOREIGN KEY (`consumer_id`) REFERENCES `consumers` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_purchase_auth_merchant` FOREIGN KEY (`merchant_id`) REFERENCES `merchants` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 /*!40101 SET SQL_MODE=@OLD_SQL_MODE */;
 /*!40014 SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS */;
 /*!40014 SET UNIQUE_CHECKS=@OLD_UNIQUE_CHECKS */;